@@ -0,0 +1,21 @@
+// Command ame-room-server runs a self-hosted room party server, for hosts
+// where the ame desktop app doesn't run. Point ame at it with the room
+// server URL setting, e.g. ws://host:8787.
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+
+	roomserver "github.com/hoangvu12/ame/internal/roomparty/server"
+)
+
+func main() {
+	err := roomserver.Run(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	log.Fatal(err)
+}
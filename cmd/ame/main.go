@@ -4,6 +4,8 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
@@ -20,6 +22,7 @@ import (
 	"github.com/hoangvu12/ame/internal/game"
 	"github.com/hoangvu12/ame/internal/i18n"
 	"github.com/hoangvu12/ame/internal/lcu"
+	roomserver "github.com/hoangvu12/ame/internal/roomparty/server"
 	"github.com/hoangvu12/ame/internal/server"
 	"github.com/hoangvu12/ame/internal/setup"
 	"github.com/hoangvu12/ame/internal/startup"
@@ -357,6 +360,15 @@ func runLauncher() {
 }
 
 func main() {
+	// Room server mode needs neither admin rights nor the launcher
+	if len(os.Args) > 1 && os.Args[1] == "room-server" {
+		if err := roomserver.Run(os.Args[2:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+			fmt.Printf("  ! Room server stopped: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Check for admin privileges first (both launcher and core need it)
 	if !isAdmin() {
 		runAsAdmin()
@@ -475,6 +487,9 @@ func main() {
 
 	display.Init(Version)
 	display.Log("Started")
+	if dir := game.FindGameDir(); dir != "" {
+		display.SetGame(game.Patch(game.Version(dir)))
+	}
 
 	// Run system tray (blocks until quit)
 	runTray()
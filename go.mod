@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/energye/systray v1.0.2
 	github.com/gorilla/websocket v1.5.1
+	github.com/klauspost/compress v1.17.4
 	golang.org/x/sys v0.15.0
 )
 
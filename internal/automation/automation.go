@@ -0,0 +1,378 @@
+// Package automation accepts ready checks and picks or bans champions from
+// the configured per-role priority lists, driven by LCU events. It is the
+// only implementation: the plugin no longer acts on ready checks or champ select.
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/config"
+	"github.com/hoangvu12/ame/internal/lcu"
+)
+
+// AcceptDelay is how long a ready check waits before being accepted.
+const AcceptDelay = 2 * time.Second
+
+// EndpointReadyCheck is the matchmaking ready-check resource.
+const EndpointReadyCheck = "/lol-matchmaking/v1/ready-check"
+
+// LCU is the part of lcu.Client the engine uses.
+type LCU interface {
+	Get(path string, out interface{}) error
+	Post(path string, body, out interface{}) error
+	Patch(path string, body, out interface{}) error
+}
+
+// Settings supplies the automation options. The zero value of each func
+// field falls back to config.
+type Settings struct {
+	AutoAccept func() bool
+	AutoSelect func() bool
+	Roles      func() map[string]config.RoleConfig
+	DryRun     func() bool
+}
+
+// ReadyCheck is the subset of /lol-matchmaking/v1/ready-check used here.
+type ReadyCheck struct {
+	State          string `json:"state"`          // "InProgress" while waiting for responses
+	PlayerResponse string `json:"playerResponse"` // "None", "Accepted" or "Declined"
+}
+
+type ownedChampion struct {
+	ID        int `json:"id"`
+	Ownership struct {
+		Owned  bool `json:"owned"`
+		Rental struct {
+			Rented bool `json:"rented"`
+		} `json:"rental"`
+	} `json:"ownership"`
+	FreeToPlay bool `json:"freeToPlay"`
+}
+
+// Engine reacts to ready checks and champ select sessions.
+type Engine struct {
+	lcu      LCU
+	settings Settings
+	log      func(string)
+
+	mu           sync.Mutex
+	acceptTimer  *time.Timer
+	gameID       int64
+	owned        map[int]bool
+	lastActionID int
+	lastChampion int
+	lastComplete bool
+
+	// Champ select updates waiting for the worker; only the latest matters
+	next    *lcu.ChampSelectSession
+	queued  bool
+	working bool
+}
+
+// NewEngine creates an engine that calls the LCU through client and reports
+// every action through log.
+func NewEngine(client LCU, settings Settings, log func(string)) *Engine {
+	if settings.AutoAccept == nil {
+		settings.AutoAccept = config.AutoAccept
+	}
+	if settings.AutoSelect == nil {
+		settings.AutoSelect = config.AutoSelect
+	}
+	if settings.Roles == nil {
+		settings.Roles = config.AutoSelectRoles
+	}
+	if settings.DryRun == nil {
+		settings.DryRun = config.AutomationDryRun
+	}
+	return &Engine{lcu: client, settings: settings, log: log}
+}
+
+// Attach subscribes the engine to ready-check and champ select events.
+// Sessions are handled on a worker goroutine, since picking a champion makes
+// LCU requests that must not hold up the event reader.
+func (e *Engine) Attach(events *lcu.EventStream) {
+	events.Subscribe(EndpointReadyCheck, func(ev lcu.Event) {
+		if ev.URI != EndpointReadyCheck || ev.EventType == "Delete" {
+			e.CancelAccept()
+			return
+		}
+		var rc ReadyCheck
+		if err := json.Unmarshal(ev.Data, &rc); err == nil {
+			e.HandleReadyCheck(rc)
+		}
+	})
+	events.SubscribeChampSelect(e.queueSession)
+}
+
+// queueSession hands a champ select update (nil when it ended) to the
+// worker, replacing any update it has not picked up yet.
+func (e *Engine) queueSession(session *lcu.ChampSelectSession) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.next = session
+	e.queued = true
+	if !e.working {
+		e.working = true
+		go e.work()
+	}
+}
+
+// work handles queued champ select updates until none is left.
+func (e *Engine) work() {
+	for {
+		e.mu.Lock()
+		if !e.queued {
+			e.working = false
+			e.mu.Unlock()
+			return
+		}
+		session := e.next
+		e.next = nil
+		e.queued = false
+		e.mu.Unlock()
+
+		if session == nil {
+			e.Reset()
+		} else {
+			e.HandleSession(session)
+		}
+	}
+}
+
+// Reset forgets per-champ-select state.
+func (e *Engine) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.gameID = 0
+	e.owned = nil
+	e.lastActionID = 0
+	e.lastChampion = 0
+	e.lastComplete = false
+}
+
+// HandleReadyCheck schedules an accept after AcceptDelay if auto-accept is
+// on and the player hasn't responded yet.
+func (e *Engine) HandleReadyCheck(rc ReadyCheck) {
+	if rc.State != "InProgress" || rc.PlayerResponse != "None" {
+		e.CancelAccept()
+		return
+	}
+	if !e.settings.AutoAccept() {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.acceptTimer != nil {
+		return
+	}
+	e.acceptTimer = time.AfterFunc(AcceptDelay, e.accept)
+}
+
+// CancelAccept drops a pending accept.
+func (e *Engine) CancelAccept() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.acceptTimer != nil {
+		e.acceptTimer.Stop()
+		e.acceptTimer = nil
+	}
+}
+
+func (e *Engine) accept() {
+	e.mu.Lock()
+	e.acceptTimer = nil
+	e.mu.Unlock()
+
+	// Re-check: the player may have responded or the check expired meanwhile
+	var rc ReadyCheck
+	if err := e.lcu.Get(EndpointReadyCheck, &rc); err != nil {
+		return
+	}
+	if rc.State != "InProgress" || rc.PlayerResponse != "None" {
+		return
+	}
+	if e.settings.DryRun() {
+		e.log("Auto-accept: [dry run] would accept ready check")
+		return
+	}
+	if err := e.lcu.Post(EndpointReadyCheck+"/accept", nil, nil); err != nil {
+		e.log(fmt.Sprintf("! Auto-accept failed: %v", err))
+		return
+	}
+	e.log("Auto-accept: accepted ready check")
+}
+
+// HandleSession hovers, locks or bans the best available champion for the
+// local player's next action. One action is taken per session update.
+func (e *Engine) HandleSession(session *lcu.ChampSelectSession) {
+	if !e.settings.AutoSelect() || session.IsSpectating {
+		return
+	}
+	me := session.LocalPlayer()
+	if me == nil {
+		return
+	}
+	position := strings.ToLower(me.AssignedPosition)
+	if position == "" {
+		return
+	}
+	role, ok := e.settings.Roles()[position]
+	if !ok {
+		return
+	}
+
+	e.mu.Lock()
+	if e.gameID != session.GameID {
+		e.gameID = session.GameID
+		e.owned = nil
+		e.lastActionID = 0
+	}
+	e.mu.Unlock()
+
+	banned := make(map[int]bool)
+	for _, id := range append(append([]int{}, session.Bans.MyTeamBans...), session.Bans.TheirTeamBans...) {
+		if id > 0 {
+			banned[id] = true
+		}
+	}
+	picked := make(map[int]bool)
+	for _, p := range append(append([]lcu.ChampSelectPlayer{}, session.MyTeam...), session.TheirTeam...) {
+		if p.ChampionID > 0 {
+			picked[p.ChampionID] = true
+		}
+	}
+	// Never ban what a teammate picked, hovered or intends to play
+	allies := make(map[int]bool)
+	for _, p := range session.MyTeam {
+		if p.CellID == session.LocalPlayerCellID {
+			continue
+		}
+		for _, id := range []int{p.ChampionID, p.ChampionPickIntent} {
+			if id > 0 {
+				allies[id] = true
+			}
+		}
+	}
+	for _, turn := range session.Actions {
+		for _, action := range turn {
+			if action.IsAllyAction && action.Type == "pick" && action.ActorCellID != session.LocalPlayerCellID && action.ChampionID > 0 {
+				allies[action.ChampionID] = true
+			}
+		}
+	}
+
+	for _, turn := range session.Actions {
+		for _, action := range turn {
+			if action.ActorCellID != session.LocalPlayerCellID || action.Completed {
+				continue
+			}
+			isBan := action.Type == "ban"
+			if !isBan && action.Type != "pick" {
+				continue
+			}
+
+			list := role.Picks
+			if isBan {
+				list = role.Bans
+			}
+			championID := 0
+			for _, id := range list {
+				if banned[id] || (isBan && (allies[id] || picked[id])) || (!isBan && picked[id] && id != action.ChampionID) {
+					continue
+				}
+				if !isBan && !e.isOwned(id) {
+					continue
+				}
+				championID = id
+				break
+			}
+			if championID == 0 {
+				continue
+			}
+
+			// Lock in only during our turn; before it, just hover the pick
+			complete := action.IsInProgress
+			if !complete && isBan {
+				continue
+			}
+			if e.alreadyDone(action.ID, championID, complete) || (!complete && action.ChampionID == championID) {
+				return
+			}
+			e.perform(action, championID, complete, position)
+			return
+		}
+	}
+}
+
+// alreadyDone reports whether this exact action was the last one sent.
+func (e *Engine) alreadyDone(actionID, championID int, complete bool) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastActionID == actionID && e.lastChampion == championID && e.lastComplete == complete
+}
+
+func (e *Engine) perform(action lcu.ChampSelectAction, championID int, complete bool, position string) {
+	verb, done := "hover", "hovered"
+	switch {
+	case action.Type == "ban":
+		verb, done = "ban", "banned"
+	case complete:
+		verb, done = "lock", "locked"
+	}
+
+	e.mu.Lock()
+	e.lastActionID = action.ID
+	e.lastChampion = championID
+	e.lastComplete = complete
+	e.mu.Unlock()
+
+	if e.settings.DryRun() {
+		e.log(fmt.Sprintf("Auto-select: [dry run] would %s champion %d (%s)", verb, championID, position))
+		return
+	}
+
+	body := map[string]interface{}{"championId": championID}
+	if complete {
+		body["completed"] = true
+	}
+	if err := e.lcu.Patch(fmt.Sprintf("/lol-champ-select/v1/session/actions/%d", action.ID), body, nil); err != nil {
+		// Forget it so the next session update retries
+		e.mu.Lock()
+		e.lastActionID = 0
+		e.mu.Unlock()
+		e.log(fmt.Sprintf("! Auto-select: failed to %s champion %d: %v", verb, championID, err))
+		return
+	}
+	e.log(fmt.Sprintf("Auto-select: %s champion %d (%s)", done, championID, position))
+}
+
+// isOwned reports whether the player can pick championID (owned, rented or
+// free to play). Ownership is fetched once per champ select; if it can't be
+// fetched every champion is assumed pickable.
+func (e *Engine) isOwned(championID int) bool {
+	e.mu.Lock()
+	owned := e.owned
+	e.mu.Unlock()
+
+	if owned == nil {
+		var champions []ownedChampion
+		if err := e.lcu.Get("/lol-champions/v1/owned-champions-minimal", &champions); err != nil {
+			return true
+		}
+		owned = make(map[int]bool, len(champions))
+		for _, c := range champions {
+			if c.Ownership.Owned || c.Ownership.Rental.Rented || c.FreeToPlay {
+				owned[c.ID] = true
+			}
+		}
+		e.mu.Lock()
+		e.owned = owned
+		e.mu.Unlock()
+	}
+	return owned[championID]
+}
@@ -0,0 +1,312 @@
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/config"
+	"github.com/hoangvu12/ame/internal/lcu"
+)
+
+// fakeLCU records writes and answers reads from canned responses.
+type fakeLCU struct {
+	mu         sync.Mutex
+	readyCheck ReadyCheck
+	owned      []int // nil fails the ownership request
+	calls      []string
+	patchGate  chan struct{} // if set, PATCH waits until it is closed
+}
+
+func (f *fakeLCU) Get(path string, out interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var v interface{}
+	switch path {
+	case EndpointReadyCheck:
+		v = f.readyCheck
+	case "/lol-champions/v1/owned-champions-minimal":
+		if f.owned == nil {
+			return fmt.Errorf("unavailable")
+		}
+		var list []map[string]interface{}
+		for _, id := range f.owned {
+			list = append(list, map[string]interface{}{"id": id, "ownership": map[string]interface{}{"owned": true}})
+		}
+		v = list
+	default:
+		return fmt.Errorf("unexpected GET %s", path)
+	}
+	data, _ := json.Marshal(v)
+	return json.Unmarshal(data, out)
+}
+
+func (f *fakeLCU) Post(path string, body, out interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "POST "+path)
+	return nil
+}
+
+func (f *fakeLCU) Patch(path string, body, out interface{}) error {
+	if f.patchGate != nil {
+		<-f.patchGate
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, _ := json.Marshal(body)
+	f.calls = append(f.calls, fmt.Sprintf("PATCH %s %s", path, data))
+	return nil
+}
+
+func (f *fakeLCU) takeCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+func newTestEngine(client LCU, dryRun bool) *Engine {
+	on := func() bool { return true }
+	return NewEngine(client, Settings{
+		AutoAccept: on,
+		AutoSelect: on,
+		Roles: func() map[string]config.RoleConfig {
+			return map[string]config.RoleConfig{
+				"middle": {Picks: []int{103, 7, 61}, Bans: []int{157, 238, 555, 91}},
+			}
+		},
+		DryRun: func() bool { return dryRun },
+	}, func(string) {})
+}
+
+// session builds a champ select where the local player (cell 2, mid) has a
+// ban action 10 and a pick action 20. Allies are cells 0-4, enemies 5-9.
+func session(mutate func(s *lcu.ChampSelectSession)) *lcu.ChampSelectSession {
+	s := &lcu.ChampSelectSession{GameID: 1, LocalPlayerCellID: 2}
+	for cell := 0; cell < 5; cell++ {
+		s.MyTeam = append(s.MyTeam, lcu.ChampSelectPlayer{CellID: cell})
+		s.TheirTeam = append(s.TheirTeam, lcu.ChampSelectPlayer{CellID: cell + 5})
+	}
+	s.MyTeam[2].AssignedPosition = "middle"
+	s.Actions = [][]lcu.ChampSelectAction{
+		{{ID: 10, ActorCellID: 2, Type: "ban", IsAllyAction: true}},
+		{{ID: 20, ActorCellID: 2, Type: "pick", IsAllyAction: true}},
+	}
+	if mutate != nil {
+		mutate(s)
+	}
+	return s
+}
+
+func TestChampSelectScript(t *testing.T) {
+	client := &fakeLCU{owned: []int{7, 61}}
+	e := newTestEngine(client, false)
+
+	steps := []struct {
+		name    string
+		session *lcu.ChampSelectSession
+		want    []string
+	}{
+		{
+			name: "planning: ban not our turn yet, pick hovered",
+			session: session(func(s *lcu.ChampSelectSession) {
+				s.MyTeam[0].ChampionPickIntent = 157
+			}),
+			want: []string{`PATCH /lol-champ-select/v1/session/actions/20 {"championId":7}`},
+		},
+		{
+			name: "same update again: nothing resent",
+			session: session(func(s *lcu.ChampSelectSession) {
+				s.MyTeam[0].ChampionPickIntent = 157
+				s.Actions[1][0].ChampionID = 7
+			}),
+			want: nil,
+		},
+		{
+			name: "ban turn: skips intent, ally hover, ally lock and enemy pick",
+			session: session(func(s *lcu.ChampSelectSession) {
+				s.MyTeam[0].ChampionPickIntent = 157
+				s.MyTeam[1].ChampionID = 238
+				s.TheirTeam[0].ChampionID = 555
+				s.Actions[0][0].IsInProgress = true
+				s.Actions = append(s.Actions, []lcu.ChampSelectAction{
+					{ID: 30, ActorCellID: 3, Type: "pick", IsAllyAction: true, ChampionID: 91},
+				})
+			}),
+			want: nil, // every ban candidate is taken
+		},
+		{
+			name: "ban turn: first free ban locked",
+			session: session(func(s *lcu.ChampSelectSession) {
+				s.MyTeam[0].ChampionPickIntent = 157
+				s.Actions[0][0].IsInProgress = true
+			}),
+			want: []string{`PATCH /lol-champ-select/v1/session/actions/10 {"championId":238,"completed":true}`},
+		},
+		{
+			name: "pick turn: skips unowned, banned and picked",
+			session: session(func(s *lcu.ChampSelectSession) {
+				s.Actions[0][0].Completed = true
+				s.Actions[1][0].IsInProgress = true
+				s.Bans.TheirTeamBans = []int{7}
+			}),
+			want: []string{`PATCH /lol-champ-select/v1/session/actions/20 {"championId":61,"completed":true}`},
+		},
+		{
+			name: "all done",
+			session: session(func(s *lcu.ChampSelectSession) {
+				s.Actions[0][0].Completed = true
+				s.Actions[1][0].Completed = true
+			}),
+			want: nil,
+		},
+	}
+	for _, step := range steps {
+		e.HandleSession(step.session)
+		got := client.takeCalls()
+		if fmt.Sprint(got) != fmt.Sprint(step.want) {
+			t.Errorf("%s:\n got %q\nwant %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestChampSelectSkips(t *testing.T) {
+	tests := []struct {
+		name    string
+		session *lcu.ChampSelectSession
+	}{
+		{"spectating", session(func(s *lcu.ChampSelectSession) { s.IsSpectating = true })},
+		{"no position", session(func(s *lcu.ChampSelectSession) { s.MyTeam[2].AssignedPosition = "" })},
+		{"unconfigured role", session(func(s *lcu.ChampSelectSession) { s.MyTeam[2].AssignedPosition = "top" })},
+		{"not in team", session(func(s *lcu.ChampSelectSession) { s.LocalPlayerCellID = 42 })},
+	}
+	for _, tt := range tests {
+		client := &fakeLCU{owned: []int{103}}
+		newTestEngine(client, false).HandleSession(tt.session)
+		if calls := client.takeCalls(); len(calls) != 0 {
+			t.Errorf("%s: got %q, want no calls", tt.name, calls)
+		}
+	}
+}
+
+func TestOwnershipUnavailable(t *testing.T) {
+	client := &fakeLCU{} // ownership request fails: everything counts as owned
+	newTestEngine(client, false).HandleSession(session(nil))
+	want := `PATCH /lol-champ-select/v1/session/actions/20 {"championId":103}`
+	if calls := client.takeCalls(); len(calls) != 1 || calls[0] != want {
+		t.Errorf("got %q, want %q", calls, want)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	client := &fakeLCU{owned: []int{103}, readyCheck: ReadyCheck{State: "InProgress", PlayerResponse: "None"}}
+	e := newTestEngine(client, true)
+	e.HandleSession(session(func(s *lcu.ChampSelectSession) { s.Actions[1][0].IsInProgress = true }))
+	e.accept()
+	if calls := client.takeCalls(); len(calls) != 0 {
+		t.Errorf("dry run sent %q", calls)
+	}
+}
+
+func TestReadyCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		check     ReadyCheck
+		scheduled bool
+	}{
+		{"waiting", ReadyCheck{State: "InProgress", PlayerResponse: "None"}, true},
+		{"already accepted", ReadyCheck{State: "InProgress", PlayerResponse: "Accepted"}, false},
+		{"declined", ReadyCheck{State: "InProgress", PlayerResponse: "Declined"}, false},
+		{"expired", ReadyCheck{State: "Invalid", PlayerResponse: "None"}, false},
+	}
+	for _, tt := range tests {
+		client := &fakeLCU{readyCheck: tt.check}
+		e := newTestEngine(client, false)
+		e.HandleReadyCheck(tt.check)
+		e.mu.Lock()
+		scheduled := e.acceptTimer != nil
+		e.mu.Unlock()
+		if scheduled != tt.scheduled {
+			t.Errorf("%s: scheduled = %v, want %v", tt.name, scheduled, tt.scheduled)
+		}
+		e.CancelAccept()
+
+		// The delayed accept re-checks the ready check before posting
+		e.accept()
+		calls := client.takeCalls()
+		if tt.scheduled != (len(calls) == 1 && calls[0] == "POST "+EndpointReadyCheck+"/accept") {
+			t.Errorf("%s: accept sent %q", tt.name, calls)
+		}
+	}
+}
+
+func TestQueuedSessions(t *testing.T) {
+	gate := make(chan struct{})
+	client := &fakeLCU{owned: []int{7, 61}, patchGate: gate}
+	e := newTestEngine(client, false)
+
+	// The event reader is never held up by a slow PATCH
+	queued := make(chan struct{})
+	go func() {
+		e.queueSession(session(nil)) // hovers 7, PATCH blocks
+		for taken := false; !taken; time.Sleep(time.Millisecond) {
+			e.mu.Lock()
+			taken = !e.queued
+			e.mu.Unlock()
+		}
+		e.queueSession(session(func(s *lcu.ChampSelectSession) { // ban turn, superseded
+			s.Actions[0][0].IsInProgress = true
+		}))
+		e.queueSession(session(func(s *lcu.ChampSelectSession) { // pick turn
+			s.Actions[0][0].Completed = true
+			s.Actions[1][0].IsInProgress = true
+		}))
+		close(queued)
+	}()
+	select {
+	case <-queued:
+	case <-time.After(2 * time.Second):
+		t.Fatal("queueSession blocked on the LCU")
+	}
+
+	close(gate)
+	waitIdle(t, e)
+	want := []string{
+		`PATCH /lol-champ-select/v1/session/actions/20 {"championId":7}`,
+		`PATCH /lol-champ-select/v1/session/actions/20 {"championId":7,"completed":true}`,
+	}
+	if got := client.takeCalls(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("calls:\n got %q\nwant %q", got, want)
+	}
+
+	// The end of champ select resets the engine
+	e.queueSession(nil)
+	waitIdle(t, e)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.gameID != 0 || e.lastActionID != 0 || e.owned != nil {
+		t.Errorf("state after the session ended: game %d, action %d, owned %v", e.gameID, e.lastActionID, e.owned)
+	}
+}
+
+// waitIdle waits until the engine's worker has handled every queued session.
+func waitIdle(t *testing.T, e *Engine) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		e.mu.Lock()
+		idle := !e.working
+		e.mu.Unlock()
+		if idle {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("worker still busy")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -14,6 +14,8 @@ var (
 	SkinsDir   = filepath.Join(AmeDir, "skins")
 	ModsDir    = filepath.Join(AmeDir, "mods")
 	OverlayDir = filepath.Join(AmeDir, "overlay")
+	OverlayCacheDir = filepath.Join(AmeDir, "overlays")
+	GlobalModsDir   = filepath.Join(AmeDir, "global_mods")
 	PenguDir   = filepath.Join(AmeDir, "pengu")
 )
 
@@ -34,6 +36,7 @@ type RoleConfig struct {
 // Settings holds persisted application settings.
 type Settings struct {
 	GamePath         string                `json:"gamePath"`
+	GamePathSelected bool                  `json:"gamePathSelected,omitempty"` // chosen by the user, not detected
 	AutoAccept       bool                  `json:"autoAccept"`
 	BenchSwap             bool                  `json:"benchSwap"`
 	BenchSwapSkipCooldown bool                  `json:"benchSwapSkipCooldown"`
@@ -42,9 +45,21 @@ type Settings struct {
 	AutoSelect       bool                  `json:"autoSelect"`
 	AutoSelectRoles   map[string]RoleConfig `json:"autoSelectRoles"`
 	RoomParty         bool                  `json:"roomParty"`
+	RoomPartyLAN      bool                  `json:"roomPartyLan"`
 	ChatAvailability  string                `json:"chatAvailability"`
 	ChatStatusMessage string                `json:"chatStatusMessage"`
 	RandomSkin        string                `json:"randomSkin"`
+	ModPriority       []string              `json:"modPriority"`
+	OverlayBackend    string                `json:"overlayBackend"`
+	OverlayAutoRestart bool                 `json:"overlayAutoRestart"`
+	OverlayBuildTimeout int                 `json:"overlayBuildTimeout"`
+	OverlayCacheSize  int                   `json:"overlayCacheSize"`
+	AutomationDryRun  bool                  `json:"automationDryRun"`
+	Favorites         map[string]ChampionFavorites `json:"favorites,omitempty"`
+	QuarantineThreshold   int               `json:"quarantineThreshold"`
+	QuarantineCrashWindow int               `json:"quarantineCrashWindow"`
+	RoomServerURL     string                `json:"roomServerUrl"`
+	Teammates         TeammatePolicy        `json:"teammates"`
 }
 
 // Init loads settings from disk.
@@ -104,11 +119,31 @@ func GamePath() string {
 	return settings.GamePath
 }
 
-// SetGamePath updates and persists the game directory path.
+// GamePathSelected reports whether the user picked the game directory
+// instead of it being detected.
+func GamePathSelected() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.GamePathSelected
+}
+
+// SetGamePath updates and persists a detected game directory path,
+// replacing any path the user selected.
 func SetGamePath(path string) error {
 	mu.Lock()
 	defer mu.Unlock()
 	settings.GamePath = path
+	settings.GamePathSelected = false
+	return save()
+}
+
+// SelectGamePath updates and persists a game directory path picked by the
+// user, which detection keeps as long as it stays valid.
+func SelectGamePath(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.GamePath = path
+	settings.GamePathSelected = true
 	return save()
 }
 
@@ -232,6 +267,21 @@ func SetRoomParty(enabled bool) error {
 	return save()
 }
 
+// RoomPartyLAN returns whether room party also discovers teammates on the local network.
+func RoomPartyLAN() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.RoomPartyLAN
+}
+
+// SetRoomPartyLAN updates and persists the room party LAN discovery setting.
+func SetRoomPartyLAN(enabled bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.RoomPartyLAN = enabled
+	return save()
+}
+
 // SetAutoSelectRole updates and persists the pick/ban config for a single role.
 func SetAutoSelectRole(role string, picks, bans []int) error {
 	mu.Lock()
@@ -255,7 +305,7 @@ func ChatStatusMessage() string {
 	return settings.ChatStatusMessage
 }
 
-// RandomSkin returns the current random-skin mode ("", "all", or "top3").
+// RandomSkin returns the current random-skin mode ("", "all", "rotation", "favorites" or "top3").
 func RandomSkin() string {
 	mu.RLock()
 	defer mu.RUnlock()
@@ -270,6 +320,141 @@ func SetRandomSkin(mode string) error {
 	return save()
 }
 
+// ModPriority returns a copy of the overlay mod category priority (highest first).
+// Returns nil when unset, meaning the overlay package default applies.
+func ModPriority() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(settings.ModPriority) == 0 {
+		return nil
+	}
+	cp := make([]string, len(settings.ModPriority))
+	copy(cp, settings.ModPriority)
+	return cp
+}
+
+// SetModPriority updates and persists the overlay mod category priority.
+func SetModPriority(priority []string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.ModPriority = priority
+	return save()
+}
+
+// OverlayBackend returns the overlay builder backend ("" or "modtools" for mod-tools, "native" for the Go builder).
+func OverlayBackend() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.OverlayBackend
+}
+
+// SetOverlayBackend updates and persists the overlay builder backend.
+func SetOverlayBackend(backend string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.OverlayBackend = backend
+	return save()
+}
+
+// OverlayAutoRestart returns whether a crashed runoverlay is restarted while the game runs.
+func OverlayAutoRestart() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.OverlayAutoRestart
+}
+
+// SetOverlayAutoRestart updates and persists the overlay auto-restart setting.
+func SetOverlayAutoRestart(enabled bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.OverlayAutoRestart = enabled
+	return save()
+}
+
+// OverlayBuildTimeout returns the overlay build timeout in seconds (0 means the default).
+func OverlayBuildTimeout() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.OverlayBuildTimeout
+}
+
+// SetOverlayBuildTimeout updates and persists the overlay build timeout in seconds.
+func SetOverlayBuildTimeout(seconds int) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.OverlayBuildTimeout = seconds
+	return save()
+}
+
+// OverlayCacheSize returns how many built overlays to keep (0 means the default).
+func OverlayCacheSize() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.OverlayCacheSize
+}
+
+// SetOverlayCacheSize updates and persists the overlay cache limit.
+func SetOverlayCacheSize(size int) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.OverlayCacheSize = size
+	return save()
+}
+
+// AutomationDryRun returns whether auto-accept/auto-select only log what they would do.
+func AutomationDryRun() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.AutomationDryRun
+}
+
+// SetAutomationDryRun updates and persists the automation dry-run setting.
+func SetAutomationDryRun(enabled bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.AutomationDryRun = enabled
+	return save()
+}
+
+// QuarantineThreshold returns how many failures in a row quarantine a skin (0 means the default).
+func QuarantineThreshold() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.QuarantineThreshold
+}
+
+// QuarantineCrashWindow returns how many seconds after the hook a game exit
+// counts as a crash (0 means the default).
+func QuarantineCrashWindow() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.QuarantineCrashWindow
+}
+
+// SetQuarantine updates and persists the quarantine threshold and crash window.
+func SetQuarantine(threshold, crashWindow int) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.QuarantineThreshold = threshold
+	settings.QuarantineCrashWindow = crashWindow
+	return save()
+}
+
+// RoomServerURL returns the room party server URL ("" means the default worker).
+func RoomServerURL() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.RoomServerURL
+}
+
+// SetRoomServerURL updates and persists the room party server URL.
+func SetRoomServerURL(url string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.RoomServerURL = url
+	return save()
+}
+
 // SetChatStatus updates and persists both chat availability and status message.
 func SetChatStatus(availability, statusMessage string) error {
 	mu.Lock()
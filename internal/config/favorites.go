@@ -0,0 +1,145 @@
+package config
+
+// Queue types that can have their own favorite skin rule.
+const (
+	QueueARAM   = "aram"
+	QueueArena  = "arena"
+	QueueRanked = "ranked"
+)
+
+// FavoriteSkin is one favorite skin or chroma of a champion.
+type FavoriteSkin struct {
+	SkinID     string `json:"skinId"`
+	BaseSkinID string `json:"baseSkinId,omitempty"` // parent skin, set for chromas
+	SkinName   string `json:"skinName,omitempty"`
+	ChromaName string `json:"chromaName,omitempty"`
+}
+
+// ChampionFavorites holds a champion's favorite skins, most preferred
+// first, and optional per-queue overrides keyed by queue type.
+type ChampionFavorites struct {
+	Skins  []FavoriteSkin          `json:"skins"`
+	Queues map[string]FavoriteSkin `json:"queues,omitempty"`
+}
+
+// ValidQueueType reports whether queue is a queue type favorites can target.
+func ValidQueueType(queue string) bool {
+	switch queue {
+	case QueueARAM, QueueArena, QueueRanked:
+		return true
+	}
+	return false
+}
+
+func (f ChampionFavorites) clone() ChampionFavorites {
+	cp := ChampionFavorites{Skins: make([]FavoriteSkin, len(f.Skins))}
+	copy(cp.Skins, f.Skins)
+	if len(f.Queues) > 0 {
+		cp.Queues = make(map[string]FavoriteSkin, len(f.Queues))
+		for k, v := range f.Queues {
+			cp.Queues[k] = v
+		}
+	}
+	return cp
+}
+
+func (f ChampionFavorites) empty() bool {
+	return len(f.Skins) == 0 && len(f.Queues) == 0
+}
+
+// Favorites returns a copy of the favorites of a champion.
+func Favorites(championID string) ChampionFavorites {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.Favorites[championID].clone()
+}
+
+// AllFavorites returns a copy of the favorites of every champion.
+func AllFavorites() map[string]ChampionFavorites {
+	mu.RLock()
+	defer mu.RUnlock()
+	cp := make(map[string]ChampionFavorites, len(settings.Favorites))
+	for k, v := range settings.Favorites {
+		cp[k] = v.clone()
+	}
+	return cp
+}
+
+// SetFavorites replaces and persists the favorites of a champion.
+func SetFavorites(championID string, favorites ChampionFavorites) error {
+	mu.Lock()
+	defer mu.Unlock()
+	setFavorites(championID, favorites.clone())
+	return save()
+}
+
+// AddFavorite adds a skin to a champion's favorites at index (clamped; a
+// negative index appends). A skin already in the list is moved.
+func AddFavorite(championID string, skin FavoriteSkin, index int) error {
+	mu.Lock()
+	defer mu.Unlock()
+	f := settings.Favorites[championID].clone()
+	f.Skins = removeFavorite(f.Skins, skin.SkinID)
+	if index < 0 || index > len(f.Skins) {
+		index = len(f.Skins)
+	}
+	f.Skins = append(f.Skins[:index], append([]FavoriteSkin{skin}, f.Skins[index:]...)...)
+	setFavorites(championID, f)
+	return save()
+}
+
+// RemoveFavorite removes a skin from a champion's favorites and from any
+// queue rule that uses it.
+func RemoveFavorite(championID, skinID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	f := settings.Favorites[championID].clone()
+	f.Skins = removeFavorite(f.Skins, skinID)
+	for queue, skin := range f.Queues {
+		if skin.SkinID == skinID {
+			delete(f.Queues, queue)
+		}
+	}
+	setFavorites(championID, f)
+	return save()
+}
+
+// SetQueueFavorite sets the skin used for a champion in a queue type, or
+// clears the rule when skin is nil.
+func SetQueueFavorite(championID, queue string, skin *FavoriteSkin) error {
+	mu.Lock()
+	defer mu.Unlock()
+	f := settings.Favorites[championID].clone()
+	if skin == nil {
+		delete(f.Queues, queue)
+	} else {
+		if f.Queues == nil {
+			f.Queues = make(map[string]FavoriteSkin)
+		}
+		f.Queues[queue] = *skin
+	}
+	setFavorites(championID, f)
+	return save()
+}
+
+// setFavorites stores f, dropping empty entries. Caller must hold mu.
+func setFavorites(championID string, f ChampionFavorites) {
+	if f.empty() {
+		delete(settings.Favorites, championID)
+		return
+	}
+	if settings.Favorites == nil {
+		settings.Favorites = make(map[string]ChampionFavorites)
+	}
+	settings.Favorites[championID] = f
+}
+
+func removeFavorite(skins []FavoriteSkin, skinID string) []FavoriteSkin {
+	out := skins[:0]
+	for _, s := range skins {
+		if s.SkinID != skinID {
+			out = append(out, s)
+		}
+	}
+	return out
+}
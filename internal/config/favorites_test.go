@@ -0,0 +1,179 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// tempSettings points the settings file at a temporary directory and starts
+// from empty settings, restoring both when the test ends.
+func tempSettings(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	oldDir, oldPath, oldSettings := AmeDir, settingsPath, settings
+	AmeDir = t.TempDir()
+	settingsPath = filepath.Join(AmeDir, "settings.json")
+	settings = Settings{}
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		AmeDir, settingsPath, settings = oldDir, oldPath, oldSettings
+		mu.Unlock()
+	})
+}
+
+func skinIDs(skins []FavoriteSkin) []string {
+	ids := make([]string, len(skins))
+	for i, s := range skins {
+		ids[i] = s.SkinID
+	}
+	return ids
+}
+
+func TestAddFavorite(t *testing.T) {
+	tempSettings(t)
+	steps := []struct {
+		name  string
+		skin  string
+		index int
+		want  []string
+	}{
+		{"first", "1", -1, []string{"1"}},
+		{"append", "2", -1, []string{"1", "2"}},
+		{"append past the end", "3", 10, []string{"1", "2", "3"}},
+		{"insert at the front", "4", 0, []string{"4", "1", "2", "3"}},
+		{"insert in the middle", "5", 2, []string{"4", "1", "5", "2", "3"}},
+		{"move forward", "3", 1, []string{"4", "3", "1", "5", "2"}},
+		{"move back", "4", 3, []string{"3", "1", "5", "4", "2"}},
+		{"move to the end", "1", -1, []string{"3", "5", "4", "2", "1"}},
+		{"re-add in place", "5", 1, []string{"3", "5", "4", "2", "1"}},
+	}
+	for _, s := range steps {
+		if err := AddFavorite("103", FavoriteSkin{SkinID: s.skin}, s.index); err != nil {
+			t.Fatalf("%s: AddFavorite: %v", s.name, err)
+		}
+		if got := skinIDs(Favorites("103").Skins); !reflect.DeepEqual(got, s.want) {
+			t.Errorf("%s: skins = %v, want %v", s.name, got, s.want)
+		}
+	}
+
+	// A returned copy does not share the stored list
+	f := Favorites("103")
+	f.Skins[0].SkinID = "changed"
+	if got := Favorites("103").Skins[0].SkinID; got != "3" {
+		t.Errorf("changing a returned copy changed the stored favorite to %q", got)
+	}
+}
+
+func TestRemoveFavorite(t *testing.T) {
+	a := FavoriteSkin{SkinID: "1"}
+	b := FavoriteSkin{SkinID: "2"}
+	c := FavoriteSkin{SkinID: "3"}
+	tests := []struct {
+		name       string
+		start      ChampionFavorites
+		remove     string
+		wantSkins  []string
+		wantQueues map[string]FavoriteSkin
+		wantGone   bool
+	}{
+		{
+			name:      "middle",
+			start:     ChampionFavorites{Skins: []FavoriteSkin{a, b, c}},
+			remove:    "2",
+			wantSkins: []string{"1", "3"},
+		},
+		{
+			name:      "not a favorite",
+			start:     ChampionFavorites{Skins: []FavoriteSkin{a, b}},
+			remove:    "9",
+			wantSkins: []string{"1", "2"},
+		},
+		{
+			name:       "clears the queue rules using it",
+			start:      ChampionFavorites{Skins: []FavoriteSkin{a, b}, Queues: map[string]FavoriteSkin{QueueARAM: b, QueueRanked: b, QueueArena: a}},
+			remove:     "2",
+			wantSkins:  []string{"1"},
+			wantQueues: map[string]FavoriteSkin{QueueArena: a},
+		},
+		{
+			name:       "last skin keeps other queue rules",
+			start:      ChampionFavorites{Skins: []FavoriteSkin{a}, Queues: map[string]FavoriteSkin{QueueArena: c}},
+			remove:     "1",
+			wantSkins:  []string{},
+			wantQueues: map[string]FavoriteSkin{QueueArena: c},
+		},
+		{
+			name:     "last skin and its rule drop the champion",
+			start:    ChampionFavorites{Skins: []FavoriteSkin{a}, Queues: map[string]FavoriteSkin{QueueARAM: a}},
+			remove:   "1",
+			wantGone: true,
+		},
+	}
+	for _, tt := range tests {
+		tempSettings(t)
+		if err := SetFavorites("103", tt.start); err != nil {
+			t.Fatalf("%s: SetFavorites: %v", tt.name, err)
+		}
+		if err := RemoveFavorite("103", tt.remove); err != nil {
+			t.Fatalf("%s: RemoveFavorite: %v", tt.name, err)
+		}
+		if _, ok := AllFavorites()["103"]; ok == tt.wantGone {
+			t.Errorf("%s: champion stored = %v, want %v", tt.name, ok, !tt.wantGone)
+		}
+		if tt.wantGone {
+			continue
+		}
+		got := Favorites("103")
+		if ids := skinIDs(got.Skins); !reflect.DeepEqual(ids, tt.wantSkins) {
+			t.Errorf("%s: skins = %v, want %v", tt.name, ids, tt.wantSkins)
+		}
+		if !reflect.DeepEqual(got.Queues, tt.wantQueues) {
+			t.Errorf("%s: queues = %v, want %v", tt.name, got.Queues, tt.wantQueues)
+		}
+	}
+}
+
+func TestSetQueueFavorite(t *testing.T) {
+	tempSettings(t)
+	skin := FavoriteSkin{SkinID: "103001"}
+	if err := SetQueueFavorite("103", QueueARAM, &skin); err != nil {
+		t.Fatal(err)
+	}
+	if got := Favorites("103").Queues[QueueARAM]; got != skin {
+		t.Errorf("ARAM rule = %+v, want %+v", got, skin)
+	}
+	if err := SetQueueFavorite("103", QueueARAM, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := AllFavorites()["103"]; ok {
+		t.Error("clearing the only rule left the champion stored")
+	}
+}
+
+func TestFavoritesPersist(t *testing.T) {
+	tempSettings(t)
+	AddFavorite("103", FavoriteSkin{SkinID: "1"}, -1)
+	AddFavorite("103", FavoriteSkin{SkinID: "2"}, 0)
+	SetQueueFavorite("103", QueueRanked, &FavoriteSkin{SkinID: "1"})
+	want := AllFavorites()
+
+	mu.Lock()
+	settings = Settings{}
+	mu.Unlock()
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+	if got := AllFavorites(); !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded favorites = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidQueueType(t *testing.T) {
+	for queue, want := range map[string]bool{QueueARAM: true, QueueArena: true, QueueRanked: true, "": false, "normal": false} {
+		if got := ValidQueueType(queue); got != want {
+			t.Errorf("ValidQueueType(%q) = %v, want %v", queue, got, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+// TeammatePolicy controls which room party teammate skins go into the overlay.
+type TeammatePolicy struct {
+	MaxSkins         int      `json:"maxSkins"` // teammate skins per build, 0 for no limit
+	BlockedPuuids    []string `json:"blockedPuuids,omitempty"`
+	BlockedChampions []string `json:"blockedChampions,omitempty"` // champion IDs
+	FriendsOnly      bool     `json:"friendsOnly"`                // only teammates on the friends list
+	MaxDownloadMB    int      `json:"maxDownloadMb"`              // per teammate skin, 0 for no limit
+}
+
+func (p TeammatePolicy) clone() TeammatePolicy {
+	p.BlockedPuuids = append([]string(nil), p.BlockedPuuids...)
+	p.BlockedChampions = append([]string(nil), p.BlockedChampions...)
+	return p
+}
+
+// Teammates returns a copy of the room party teammate skin policy.
+func Teammates() TeammatePolicy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings.Teammates.clone()
+}
+
+// SetTeammates replaces and persists the room party teammate skin policy.
+func SetTeammates(policy TeammatePolicy) error {
+	mu.Lock()
+	defer mu.Unlock()
+	settings.Teammates = policy.clone()
+	return save()
+}
@@ -1,5 +1,3 @@
-//go:build windows
-
 package display
 
 import (
@@ -7,10 +5,8 @@ import (
 	"os"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 	"unicode/utf8"
-	"unsafe"
 
 	"github.com/hoangvu12/ame/internal/i18n"
 )
@@ -30,27 +26,13 @@ var (
 	skin       string
 	overlay    string
 	party      string
+	gameVer    string
 	logs       []logEntry
 	exportLogs []logEntry // Larger buffer for export
 	paused     bool
 	started    bool
 )
 
-// enableVT enables Virtual Terminal Processing on the Windows console,
-// allowing ANSI escape codes to work.
-func enableVT() {
-	kernel32 := syscall.NewLazyDLL("kernel32.dll")
-	getConsoleMode := kernel32.NewProc("GetConsoleMode")
-	setConsoleMode := kernel32.NewProc("SetConsoleMode")
-
-	handle, _ := syscall.GetStdHandle(syscall.STD_OUTPUT_HANDLE)
-
-	var mode uint32
-	getConsoleMode.Call(uintptr(handle), uintptr(unsafe.Pointer(&mode)))
-	mode |= 0x0004 // ENABLE_VIRTUAL_TERMINAL_PROCESSING
-	setConsoleMode.Call(uintptr(handle), uintptr(mode))
-}
-
 // Init initializes the display with the given version and renders the initial view.
 func Init(ver string) {
 	mu.Lock()
@@ -63,6 +45,7 @@ func Init(ver string) {
 	skin = i18n.T("display.value.none", nil)
 	overlay = i18n.T("display.value.overlay_inactive", nil)
 	party = i18n.T("display.value.party_off", nil)
+	gameVer = i18n.T("display.value.unknown", nil)
 	logs = nil
 	exportLogs = nil
 	started = true
@@ -105,6 +88,20 @@ func SetSkin(name, chroma string) {
 	render()
 }
 
+// SetGame updates the displayed game patch and re-renders.
+// If version is empty, displays "Unknown".
+func SetGame(version string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if version == "" {
+		gameVer = i18n.T("display.value.unknown", nil)
+	} else {
+		gameVer = version
+	}
+	render()
+}
+
 // SetPartyKey updates the room party status and re-renders.
 func SetPartyKey(key string, vars map[string]interface{}) {
 	mu.Lock()
@@ -195,6 +192,7 @@ func render() {
 		i18n.T("display.label.skin", nil),
 		i18n.T("display.label.overlay", nil),
 		i18n.T("display.label.party", nil),
+		i18n.T("display.label.game", nil),
 	}
 	labelWidth := 12
 	for _, lbl := range labels {
@@ -218,6 +216,7 @@ func render() {
 	b.WriteString(fmt.Sprintf("  %-*s %s\n", labelWidth, labels[1], skin))
 	b.WriteString(fmt.Sprintf("  %-*s %s\n", labelWidth, labels[2], overlay))
 	b.WriteString(fmt.Sprintf("  %-*s %s\n", labelWidth, labels[3], party))
+	b.WriteString(fmt.Sprintf("  %-*s %s\n", labelWidth, labels[4], gameVer))
 	b.WriteString("\n")
 	b.WriteString(fmt.Sprintf("  %s\n", i18n.T("display.label.log", nil)))
 	b.WriteString("  ---\n")
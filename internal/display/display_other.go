@@ -0,0 +1,6 @@
+//go:build !windows
+
+package display
+
+// enableVT is a no-op: other terminals handle ANSI escape codes natively
+func enableVT() {}
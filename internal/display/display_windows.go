@@ -0,0 +1,23 @@
+//go:build windows
+
+package display
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// enableVT enables Virtual Terminal Processing on the Windows console,
+// allowing ANSI escape codes to work.
+func enableVT() {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	getConsoleMode := kernel32.NewProc("GetConsoleMode")
+	setConsoleMode := kernel32.NewProc("SetConsoleMode")
+
+	handle, _ := syscall.GetStdHandle(syscall.STD_OUTPUT_HANDLE)
+
+	var mode uint32
+	getConsoleMode.Call(uintptr(handle), uintptr(unsafe.Pointer(&mode)))
+	mode |= 0x0004 // ENABLE_VIRTUAL_TERMINAL_PROCESSING
+	setConsoleMode.Call(uintptr(handle), uintptr(mode))
+}
@@ -0,0 +1,74 @@
+// Package favorites resolves which skin to use for a champion from the
+// favorites and per-queue rules saved in config.
+package favorites
+
+import (
+	"github.com/hoangvu12/ame/internal/config"
+	"github.com/hoangvu12/ame/internal/lcu"
+)
+
+// Where a resolved skin came from.
+const (
+	SourceQueue    = "queue"    // a per-queue rule
+	SourceFavorite = "favorite" // the first favorite
+)
+
+// QueueType maps an LCU queue to a config queue type ("" for queues
+// without rules, e.g. normal draft).
+func QueueType(q lcu.Queue) string {
+	switch {
+	case q.GameMode == "ARAM":
+		return config.QueueARAM
+	case q.GameMode == "CHERRY":
+		return config.QueueArena
+	case q.IsRanked:
+		return config.QueueRanked
+	}
+	return ""
+}
+
+// CurrentQueueType returns the queue type of the current LCU session, or
+// "" if the client is unavailable.
+func CurrentQueueType() string {
+	q, err := lcu.Default().CurrentQueue()
+	if err != nil {
+		return ""
+	}
+	return QueueType(q)
+}
+
+// Resolve returns the skin to use for championID in a queue type. A queue
+// rule wins over the favorites list; ok is false if neither applies.
+func Resolve(championID, queue string) (skin config.FavoriteSkin, source string, ok bool) {
+	return resolve(config.Favorites(championID), queue)
+}
+
+func resolve(f config.ChampionFavorites, queue string) (skin config.FavoriteSkin, source string, ok bool) {
+	if queue != "" {
+		if skin, ok := f.Queues[queue]; ok {
+			return skin, SourceQueue, true
+		}
+	}
+	if len(f.Skins) > 0 {
+		return f.Skins[0], SourceFavorite, true
+	}
+	return config.FavoriteSkin{}, "", false
+}
+
+// SkinIDs lists a champion's favorite skin IDs, most preferred first.
+// Chromas are listed by their base skin.
+func SkinIDs(championID string) []string {
+	return skinIDs(config.Favorites(championID))
+}
+
+func skinIDs(f config.ChampionFavorites) []string {
+	ids := make([]string, 0, len(f.Skins))
+	for _, s := range f.Skins {
+		id := s.SkinID
+		if s.BaseSkinID != "" {
+			id = s.BaseSkinID
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,71 @@
+package favorites
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hoangvu12/ame/internal/config"
+	"github.com/hoangvu12/ame/internal/lcu"
+)
+
+var (
+	dynasty = config.FavoriteSkin{SkinID: "103001", SkinName: "Dynasty Ahri"}
+	ruby    = config.FavoriteSkin{SkinID: "103017", BaseSkinID: "103001", SkinName: "Dynasty Ahri", ChromaName: "Ruby"}
+	arcade  = config.FavoriteSkin{SkinID: "103027", SkinName: "Arcade Ahri"}
+)
+
+func TestResolve(t *testing.T) {
+	withQueues := config.ChampionFavorites{
+		Skins:  []config.FavoriteSkin{dynasty, ruby},
+		Queues: map[string]config.FavoriteSkin{config.QueueARAM: arcade},
+	}
+	tests := []struct {
+		name   string
+		f      config.ChampionFavorites
+		queue  string
+		want   config.FavoriteSkin
+		source string
+		ok     bool
+	}{
+		{"queue rule beats favorites", withQueues, config.QueueARAM, arcade, SourceQueue, true},
+		{"queue without a rule uses the first favorite", withQueues, config.QueueRanked, dynasty, SourceFavorite, true},
+		{"no queue uses the first favorite", withQueues, "", dynasty, SourceFavorite, true},
+		{"queue rule without favorites", config.ChampionFavorites{Queues: withQueues.Queues}, config.QueueARAM, arcade, SourceQueue, true},
+		{"queue rule for another queue only", config.ChampionFavorites{Queues: withQueues.Queues}, config.QueueArena, config.FavoriteSkin{}, "", false},
+		{"nothing saved", config.ChampionFavorites{}, config.QueueARAM, config.FavoriteSkin{}, "", false},
+	}
+	for _, tt := range tests {
+		skin, source, ok := resolve(tt.f, tt.queue)
+		if skin != tt.want || source != tt.source || ok != tt.ok {
+			t.Errorf("%s: got %+v, %q, %v; want %+v, %q, %v", tt.name, skin, source, ok, tt.want, tt.source, tt.ok)
+		}
+	}
+}
+
+func TestSkinIDs(t *testing.T) {
+	f := config.ChampionFavorites{Skins: []config.FavoriteSkin{ruby, arcade, dynasty}}
+	// Chromas are listed by their base skin
+	if got, want := skinIDs(f), []string{"103001", "103027", "103001"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("skinIDs = %v, want %v", got, want)
+	}
+	if got := skinIDs(config.ChampionFavorites{}); len(got) != 0 {
+		t.Errorf("skinIDs of nothing = %v", got)
+	}
+}
+
+func TestQueueType(t *testing.T) {
+	tests := []struct {
+		q    lcu.Queue
+		want string
+	}{
+		{lcu.Queue{GameMode: "ARAM"}, config.QueueARAM},
+		{lcu.Queue{GameMode: "CHERRY"}, config.QueueArena},
+		{lcu.Queue{GameMode: "CLASSIC", IsRanked: true}, config.QueueRanked},
+		{lcu.Queue{GameMode: "CLASSIC"}, ""},
+	}
+	for _, tt := range tests {
+		if got := QueueType(tt.q); got != tt.want {
+			t.Errorf("QueueType(%+v) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
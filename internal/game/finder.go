@@ -4,17 +4,119 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hoangvu12/ame/internal/config"
 )
 
-var cachedGameDir string
+// FileSystem is the read-only view of the disk the finder works through.
+// It mirrors fs.FS, fs.StatFS, fs.ReadFileFS and fs.ReadDirFS but takes host
+// paths, since installs live under absolute paths such as C:\ or a Wine prefix.
+type FileSystem interface {
+	Open(name string) (fs.File, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+}
+
+// CommandRunner runs an external command and returns its output, or "" on failure.
+type CommandRunner func(name string, args ...string) string
+
+// OSFileSystem is the FileSystem backed by the real disk.
+type OSFileSystem struct{}
+
+func (OSFileSystem) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (OSFileSystem) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (OSFileSystem) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (OSFileSystem) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+// PathStore remembers the chosen game directory between runs, and whether
+// the user selected it rather than detection.
+type PathStore interface {
+	GamePath() (dir string, userSelected bool)
+	SetGamePath(dir string, userSelected bool) error
+}
+
+// configStore is the PathStore backed by the settings file.
+type configStore struct{}
+
+func (configStore) GamePath() (string, bool) { return config.GamePath(), config.GamePathSelected() }
+
+func (configStore) SetGamePath(dir string, userSelected bool) error {
+	if userSelected {
+		return config.SelectGamePath(dir)
+	}
+	return config.SetGamePath(dir)
+}
+
+// Installation sources, in the order FindGameDir consults them.
+const (
+	SourceSaved              = "saved"
+	SourceRiotClientInstalls = "riotClientInstalls"
+	SourceCommonPath         = "commonPath"
+	SourceProcess            = "process"
+	SourceRegistry           = "registry"
+	SourceUser               = "user"
+)
+
+// Trace results recorded for each detection step.
+const (
+	TraceFound     = "found"     // valid install added as a candidate
+	TraceDuplicate = "duplicate" // valid install already found by an earlier source
+	TraceMissing   = "missing"   // path known but League of Legends.exe is not there
+	TraceNone      = "none"      // source yielded nothing
+	TraceChosen    = "chosen"    // the path FindGameDir returned
+)
+
+// TraceStep records one decision made while looking for the game directory.
+type TraceStep struct {
+	Source string `json:"source"`
+	Result string `json:"result"`
+	Path   string `json:"path,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Finder locates League of Legends installations. All disk access goes
+// through its FileSystem, all external commands through its CommandRunner
+// and the saved path through its PathStore, so it can run against a fake tree.
+type Finder struct {
+	fs     FileSystem
+	run    CommandRunner
+	getenv func(string) string
+	store  PathStore
+
+	mu     sync.Mutex
+	cached string
+	trace  []TraceStep
+
+	versionMu sync.Mutex
+	versions  map[string]versionEntry // game dir -> last version read
+}
+
+// NewFinder creates a finder. getenv may be nil to use the process
+// environment and store nil to use the settings file.
+func NewFinder(fsys FileSystem, run CommandRunner, getenv func(string) string, store PathStore) *Finder {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if store == nil {
+		store = configStore{}
+	}
+	return &Finder{fs: fsys, run: run, getenv: getenv, store: store, versions: make(map[string]versionEntry)}
+}
+
+var defaultFinder = NewFinder(OSFileSystem{}, runCommand, nil, nil)
+
+// Default returns the finder used by the package-level functions.
+func Default() *Finder {
+	return defaultFinder
+}
 
 // commonPathSuffixes lists directory patterns (relative to a drive root) where
 // League of Legends may be installed.
@@ -26,123 +128,286 @@ var commonPathSuffixes = []string{
 	filepath.Join("Program Files (x86)", "Riot Games", "League of Legends", "Game"),
 }
 
+// candidate is a valid game directory and the source that found it.
+type candidate struct {
+	dir    string
+	source string
+}
+
+// search accumulates the candidates and trace of one detection run.
+type search struct {
+	candidates []candidate
+	trace      []TraceStep
+}
+
+func (s *search) note(source, result, path, detail string) {
+	s.trace = append(s.trace, TraceStep{Source: source, Result: result, Path: path, Detail: detail})
+}
+
 // isValidGameDir checks if a directory contains League of Legends.exe
-func isValidGameDir(dir string) bool {
-	return fileExists(filepath.Join(dir, "League of Legends.exe"))
+func (f *Finder) isValidGameDir(dir string) bool {
+	return f.fileExists(filepath.Join(dir, "League of Legends.exe"))
 }
 
-// getSavedGameDir reads the saved game directory from config
-func getSavedGameDir() string {
-	dir := config.GamePath()
-	if dir != "" && isValidGameDir(dir) {
-		return dir
+// savedGameDir reads the saved game directory from the finder's store
+func (f *Finder) savedGameDir(s *search) string {
+	dir, _ := f.store.GamePath()
+	if dir == "" {
+		s.note(SourceSaved, TraceNone, "", "no saved path")
+		return ""
 	}
-	return ""
+	if !f.isValidGameDir(dir) {
+		s.note(SourceSaved, TraceMissing, dir, "League of Legends.exe not found")
+		return ""
+	}
+	return dir
 }
 
-// SaveGameDir persists the game directory to config
+// SaveGameDir persists a detected game directory to config
 func SaveGameDir(dir string) {
-	config.SetGamePath(dir)
+	defaultFinder.store.SetGamePath(dir, false)
 }
 
-// addCandidate appends dir to candidates if it's a valid game dir and not already present.
-func addCandidate(candidates []string, dir string) []string {
+// addCandidate adds dir to the search if it's a valid game dir and not already present.
+// Returns true if dir is a valid game dir.
+func (f *Finder) addCandidate(s *search, dir, source string) bool {
 	dir = filepath.Clean(dir)
-	if !isValidGameDir(dir) {
-		return candidates
+	if !f.isValidGameDir(dir) {
+		return false
+	}
+	for _, c := range s.candidates {
+		if strings.EqualFold(c.dir, dir) {
+			s.note(source, TraceDuplicate, dir, "already found via "+c.source)
+			return true
+		}
 	}
-	for _, c := range candidates {
-		if strings.EqualFold(c, dir) {
-			return candidates
+	s.candidates = append(s.candidates, candidate{dir: dir, source: source})
+	s.note(source, TraceFound, dir, "")
+	return true
+}
+
+// collectCandidates gathers game directories from the saved path,
+// RiotClientInstalls.json and common install paths.
+func (f *Finder) collectCandidates(s *search) {
+	// 1. Saved path from previous run (as candidate, not final answer)
+	if dir := f.savedGameDir(s); dir != "" {
+		f.addCandidate(s, dir, SourceSaved)
+	}
+
+	// 2. RiotClientInstalls.json (authoritative — checked early)
+	f.appendFromRiotClientInstalls(s)
+
+	// 3. Common paths on all fixed drives
+	drives := f.getFixedDrives()
+	found := false
+	for _, drive := range drives {
+		for _, suffix := range commonPathSuffixes {
+			if f.addCandidate(s, filepath.Join(drive, suffix), SourceCommonPath) {
+				found = true
+			}
 		}
 	}
-	return append(candidates, dir)
+	if !found {
+		s.note(SourceCommonPath, TraceNone, "", fmt.Sprintf("checked %d paths on %d drives", len(commonPathSuffixes)*len(drives), len(drives)))
+	}
 }
 
 // newestGameDir returns the candidate whose League of Legends.exe was most
 // recently modified. This picks the actively-patched installation over stale ones.
-func newestGameDir(candidates []string) string {
+func (f *Finder) newestGameDir(candidates []candidate) (string, string) {
 	if len(candidates) == 0 {
-		return ""
+		return "", ""
 	}
 	if len(candidates) == 1 {
-		return candidates[0]
+		return candidates[0].dir, "only installation found"
 	}
 
-	best := candidates[0]
-	bestTime := modTime(filepath.Join(best, "League of Legends.exe"))
+	best := candidates[0].dir
+	bestTime := f.modTime(filepath.Join(best, "League of Legends.exe"))
 
 	for _, c := range candidates[1:] {
-		t := modTime(filepath.Join(c, "League of Legends.exe"))
+		t := f.modTime(filepath.Join(c.dir, "League of Legends.exe"))
 		if t.After(bestTime) {
-			best = c
+			best = c.dir
 			bestTime = t
 		}
 	}
-	return best
+	reason := fmt.Sprintf("newest of %d installations, League of Legends.exe modified %s",
+		len(candidates), bestTime.Format("2006-01-02 15:04"))
+	return best, reason
 }
 
 // FindGameDir finds League of Legends Game directory using multiple detection methods.
-// When multiple installations exist it picks the one with the most recently
+// A directory the user selected is kept while it stays valid. Otherwise, when
+// multiple installations exist it picks the one with the most recently
 // modified League of Legends.exe (i.e. the actively-patched installation).
-func FindGameDir() string {
-	// 0. In-memory cache
-	if cachedGameDir != "" && isValidGameDir(cachedGameDir) {
-		return cachedGameDir
+func (f *Finder) FindGameDir() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// 0. In-memory cache, valid while the install exists and the saved path
+	// hasn't been changed behind our back
+	if f.cached != "" && f.isValidGameDir(f.cached) {
+		if saved, _ := f.store.GamePath(); saved == "" || strings.EqualFold(filepath.Clean(saved), f.cached) {
+			return f.cached
+		}
+	}
+
+	s := &search{}
+
+	// The user's own choice wins over newer installations
+	if dir, selected := f.store.GamePath(); selected {
+		if f.isValidGameDir(dir) {
+			dir = filepath.Clean(dir)
+			s.note(SourceUser, TraceChosen, dir, "selected by the user")
+			f.trace = s.trace
+			f.cached = dir
+			return dir
+		}
+		s.note(SourceUser, TraceMissing, dir, "selected path no longer has League of Legends.exe")
 	}
 
 	// Collect all candidate directories, then pick the newest.
-	var candidates []string
+	f.collectCandidates(s)
+	if best, reason := f.newestGameDir(s.candidates); best != "" {
+		return f.choose(s, best, reason)
+	}
 
-	// 1. Saved path from previous run (as candidate, not final answer)
-	if dir := getSavedGameDir(); dir != "" {
-		candidates = addCandidate(candidates, dir)
+	// 4. Running LeagueClientUx.exe process (expensive — only if nothing above matched)
+	if dir := f.findFromRunningProcess(); dir != "" {
+		f.addCandidate(s, dir, SourceProcess)
+		return f.choose(s, dir, "found from the running League client")
 	}
+	s.note(SourceProcess, TraceNone, "", "League client not running")
 
-	// 2. RiotClientInstalls.json (authoritative — checked early)
-	candidates = appendFromRiotClientInstalls(candidates)
+	// 5. Registry lookup
+	if dir := f.findFromRegistry(); dir != "" {
+		f.addCandidate(s, dir, SourceRegistry)
+		return f.choose(s, dir, "found from the registry install location")
+	}
+	s.note(SourceRegistry, TraceNone, "", "no install location")
 
-	// 3. Common paths on all fixed drives
-	for _, drive := range getFixedDrives() {
-		for _, suffix := range commonPathSuffixes {
-			candidates = addCandidate(candidates, filepath.Join(drive, suffix))
+	f.trace = s.trace
+	f.cached = ""
+	return ""
+}
+
+// choose caches and saves dir as the result of search s. Caller must hold mu.
+func (f *Finder) choose(s *search, dir, reason string) string {
+	source := ""
+	for _, c := range s.candidates {
+		if strings.EqualFold(c.dir, filepath.Clean(dir)) {
+			source = c.source
+			break
 		}
 	}
+	s.note(source, TraceChosen, dir, reason)
+	f.trace = s.trace
+	f.cached = dir
+	f.store.SetGamePath(dir, false)
+	return dir
+}
 
-	if best := newestGameDir(candidates); best != "" {
-		cachedGameDir = best
-		SaveGameDir(best)
-		return best
+// setChosen records a directory picked by the user, bypassing detection.
+func (f *Finder) setChosen(dir string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cached = dir
+	f.trace = []TraceStep{{Source: SourceUser, Result: TraceChosen, Path: dir, Detail: "selected by the user"}}
+}
+
+// Invalidate drops the cached game directory so the next FindGameDir rescans.
+func (f *Finder) Invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cached = ""
+}
+
+// commandValue runs a command through the finder's CommandRunner and returns
+// the first submatch of re in its output, trimmed, or "" if there is none.
+func (f *Finder) commandValue(re *regexp.Regexp, name string, args ...string) string {
+	match := re.FindStringSubmatch(f.run(name, args...))
+	if len(match) < 2 {
+		return ""
 	}
+	return strings.TrimSpace(match[1])
+}
 
-	// 4. Running LeagueClientUx.exe process (expensive — only if nothing above matched)
-	if dir := findFromRunningProcess(); dir != "" {
-		cachedGameDir = dir
-		SaveGameDir(dir)
-		return dir
+// Trace returns the steps of the last detection run.
+func (f *Finder) Trace() []TraceStep {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp := make([]TraceStep, len(f.trace))
+	copy(cp, f.trace)
+	return cp
+}
+
+// Explain describes, one step per line, why the current game directory was chosen.
+func (f *Finder) Explain() string {
+	trace := f.Trace()
+	if len(trace) == 0 {
+		return "no detection has run yet"
+	}
+	var b strings.Builder
+	for _, step := range trace {
+		fmt.Fprintf(&b, "%-18s %-9s %s", step.Source, step.Result, step.Path)
+		if step.Detail != "" {
+			if step.Path != "" {
+				b.WriteString(" ")
+			}
+			fmt.Fprintf(&b, "(%s)", step.Detail)
+		}
+		b.WriteString("\n")
 	}
+	return strings.TrimRight(b.String(), "\n")
+}
 
-	// 5. Registry lookup
-	if dir := findFromRegistry(); dir != "" {
-		cachedGameDir = dir
-		SaveGameDir(dir)
-		return dir
+// FindGameDir finds the game directory with the default finder.
+func FindGameDir() string {
+	return defaultFinder.FindGameDir()
+}
+
+// Invalidate drops the default finder's cached game directory.
+func Invalidate() {
+	defaultFinder.Invalidate()
+}
+
+// Explain describes why the default finder chose the current game directory.
+func Explain() string {
+	return defaultFinder.Explain()
+}
+
+// appendFromRiotClientInstalls parses RiotClientInstalls.json and adds any
+// valid League game directories it finds to the search.
+func (f *Finder) appendFromRiotClientInstalls(s *search) {
+	found := false
+	for _, file := range f.riotClientInstallsFiles() {
+		if f.appendFromInstallsFile(s, file) {
+			found = true
+		}
+	}
+	if !found {
+		s.note(SourceRiotClientInstalls, TraceNone, "", "no League install listed")
 	}
+}
 
-	return ""
+// riotInstallsFile is a RiotClientInstalls.json and how to turn the Windows
+// paths inside it into paths on this system.
+type riotInstallsFile struct {
+	path    string
+	resolve func(winPath string) string
 }
 
-// appendFromRiotClientInstalls parses RiotClientInstalls.json and appends any
-// valid League game directories it finds to candidates.
-func appendFromRiotClientInstalls(candidates []string) []string {
-	rcPath := `C:\ProgramData\Riot Games\RiotClientInstalls.json`
-	data, err := os.ReadFile(rcPath)
+// appendFromInstallsFile reads one RiotClientInstalls.json. Returns true if
+// it listed a valid install.
+func (f *Finder) appendFromInstallsFile(s *search, file riotInstallsFile) bool {
+	data, err := f.fs.ReadFile(file.path)
 	if err != nil {
-		return candidates
+		return false
 	}
 	var rc map[string]interface{}
 	if err := json.Unmarshal(data, &rc); err != nil {
-		return candidates
+		return false
 	}
 
 	var paths []string
@@ -158,50 +423,22 @@ func appendFromRiotClientInstalls(candidates []string) []string {
 		paths = append(paths, live)
 	}
 
+	found := false
 	leagueRe := regexp.MustCompile(`(?i)league`)
 	for _, p := range paths {
 		if leagueRe.MatchString(p) {
+			p = file.resolve(p)
 			// associated_client keys are League root dirs (e.g. "D:/Riot/Riot Games/League of Legends/")
 			// so "Game" is a direct child. Also try parent in case the path points to an exe.
-			candidates = addCandidate(candidates, filepath.Join(p, "Game"))
-			candidates = addCandidate(candidates, filepath.Join(p, "..", "Game"))
-		}
-	}
-	return candidates
-}
-
-// findFromRunningProcess tries to locate the game dir from a running LeagueClientUx.exe.
-func findFromRunningProcess() string {
-	output := runCommand("wmic", "process", "where", "name='LeagueClientUx.exe'", "get", "ExecutablePath", "/value")
-	if output == "" {
-		return ""
-	}
-	re := regexp.MustCompile(`ExecutablePath=(.+)`)
-	if match := re.FindStringSubmatch(output); len(match) > 1 {
-		exePath := strings.TrimSpace(match[1])
-		gameDir := filepath.Join(exePath, "..", "Game")
-		if isValidGameDir(gameDir) {
-			return filepath.Clean(gameDir)
-		}
-	}
-	return ""
-}
-
-// findFromRegistry tries to locate the game dir from the Windows registry.
-func findFromRegistry() string {
-	output := runCommand("reg", "query", `HKLM\SOFTWARE\WOW6432Node\Riot Games, Inc\League of Legends`, "/v", "Location")
-	if output == "" {
-		return ""
-	}
-	re := regexp.MustCompile(`Location\s+REG_SZ\s+(.+)`)
-	if match := re.FindStringSubmatch(output); len(match) > 1 {
-		loc := strings.TrimSpace(match[1])
-		gameDir := filepath.Join(loc, "Game")
-		if isValidGameDir(gameDir) {
-			return filepath.Clean(gameDir)
+			if f.addCandidate(s, filepath.Join(p, "Game"), SourceRiotClientInstalls) {
+				found = true
+			}
+			if f.addCandidate(s, filepath.Join(p, "..", "Game"), SourceRiotClientInstalls) {
+				found = true
+			}
 		}
 	}
-	return ""
+	return found
 }
 
 // PromptGameDir asks the user to manually enter the game directory.
@@ -225,9 +462,10 @@ func PromptGameDir() string {
 		// Remove surrounding quotes if user pasted a quoted path
 		input = strings.Trim(input, `"'`)
 
-		if isValidGameDir(input) {
-			cachedGameDir = input
-			SaveGameDir(input)
+		if defaultFinder.isValidGameDir(input) {
+			input = filepath.Clean(input)
+			defaultFinder.setChosen(input)
+			defaultFinder.store.SetGamePath(input, true)
 			fmt.Printf("  > %s\n", input)
 			return input
 		}
@@ -237,46 +475,17 @@ func PromptGameDir() string {
 	}
 }
 
-// getFixedDrives returns list of accessible drives (C:, D:, etc.)
-func getFixedDrives() []string {
-	var drives []string
-
-	// Try drives A-Z
-	for i := 'A'; i <= 'Z'; i++ {
-		drive := string(i) + `:\`
-		if info, err := os.Stat(drive); err == nil && info.IsDir() {
-			// Check if accessible by trying to read directory
-			if _, err := os.ReadDir(drive); err == nil {
-				drives = append(drives, drive)
-			}
-		}
-	}
-
-	return drives
-}
-
 // fileExists checks if a file exists
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
+func (f *Finder) fileExists(path string) bool {
+	_, err := f.fs.Stat(path)
 	return err == nil
 }
 
 // modTime returns the modification time of a file, or zero time on error.
-func modTime(path string) time.Time {
-	info, err := os.Stat(path)
+func (f *Finder) modTime(path string) time.Time {
+	info, err := f.fs.Stat(path)
 	if err != nil {
 		return time.Time{}
 	}
 	return info.ModTime()
 }
-
-// runCommand runs a command and returns its output
-func runCommand(name string, args ...string) string {
-	cmd := exec.Command(name, args...)
-	cmd.SysProcAttr = getSysProcAttr()
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return string(output)
-}
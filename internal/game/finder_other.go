@@ -0,0 +1,232 @@
+//go:build !windows
+
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// League runs under Wine here, so every Windows lookup is answered from
+// inside the Wine prefixes instead: drive_c holds the install, the prefix's
+// system.reg stands in for the registry and /proc for wmic.
+
+// winePrefixes returns every existing Wine prefix: $WINEPREFIX, ~/.wine and
+// the prefixes created by Lutris and Bottles.
+func (f *Finder) winePrefixes() []string {
+	var dirs []string
+	if p := f.getenv("WINEPREFIX"); p != "" {
+		dirs = append(dirs, p)
+	}
+
+	if home := f.getenv("HOME"); home != "" {
+		dirs = append(dirs, filepath.Join(home, ".wine"))
+
+		// Lutris installs each game into its own prefix under ~/Games
+		dirs = append(dirs, f.subdirs(filepath.Join(home, "Games"))...)
+
+		// Bottles, native and Flatpak
+		dirs = append(dirs, f.subdirs(filepath.Join(home, ".local", "share", "bottles", "bottles"))...)
+		dirs = append(dirs, f.subdirs(filepath.Join(home, ".var", "app", "com.usebottles.bottles", "data", "bottles", "bottles"))...)
+	}
+
+	var prefixes []string
+	seen := make(map[string]bool)
+	for _, d := range dirs {
+		d = filepath.Clean(d)
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		if info, err := f.fs.Stat(filepath.Join(d, "drive_c")); err == nil && info.IsDir() {
+			prefixes = append(prefixes, d)
+		}
+	}
+	return prefixes
+}
+
+// subdirs lists the directories directly inside dir.
+func (f *Finder) subdirs(dir string) []string {
+	entries, err := f.fs.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, filepath.Join(dir, e.Name()))
+		}
+	}
+	return dirs
+}
+
+var winDriveRe = regexp.MustCompile(`^([A-Za-z]):[\\/]*(.*)$`)
+
+// resolveInPrefix maps a Windows path (e.g. "C:/Riot Games/League of Legends")
+// to the host path inside prefix. C: is drive_c; other letters go through the
+// prefix's dosdevices symlinks.
+func resolveInPrefix(prefix, winPath string) string {
+	p := strings.ReplaceAll(winPath, `\`, "/")
+	m := winDriveRe.FindStringSubmatch(p)
+	if m == nil {
+		return p
+	}
+	letter := strings.ToLower(m[1])
+	if letter == "c" {
+		return filepath.Join(prefix, "drive_c", filepath.FromSlash(m[2]))
+	}
+	return filepath.Join(prefix, "dosdevices", letter+":", filepath.FromSlash(m[2]))
+}
+
+// getFixedDrives returns the drive roots of every Wine prefix. z: is skipped
+// since it maps the whole host filesystem.
+func (f *Finder) getFixedDrives() []string {
+	var drives []string
+	for _, prefix := range f.winePrefixes() {
+		drives = append(drives, filepath.Join(prefix, "drive_c"))
+
+		entries, err := f.fs.ReadDir(filepath.Join(prefix, "dosdevices"))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := strings.ToLower(e.Name())
+			if len(name) != 2 || name[1] != ':' || name == "c:" || name == "z:" {
+				continue
+			}
+			drive := filepath.Join(prefix, "dosdevices", name)
+			if info, err := f.fs.Stat(drive); err == nil && info.IsDir() {
+				drives = append(drives, drive)
+			}
+		}
+	}
+	return drives
+}
+
+// riotClientInstallsFiles returns the RiotClientInstalls.json of every Wine prefix.
+func (f *Finder) riotClientInstallsFiles() []riotInstallsFile {
+	var files []riotInstallsFile
+	for _, prefix := range f.winePrefixes() {
+		prefix := prefix
+		files = append(files, riotInstallsFile{
+			path:    filepath.Join(prefix, "drive_c", "ProgramData", "Riot Games", "RiotClientInstalls.json"),
+			resolve: func(p string) string { return resolveInPrefix(prefix, p) },
+		})
+	}
+	return files
+}
+
+// findFromRunningProcess looks for a running LeagueClientUx.exe in /proc and
+// maps its Windows path through the process's Wine prefix.
+func (f *Finder) findFromRunningProcess() string {
+	procs, err := f.fs.ReadDir("/proc")
+	if err != nil {
+		return ""
+	}
+	for _, p := range procs {
+		cmdline, err := f.fs.ReadFile(filepath.Join("/proc", p.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		for _, arg := range strings.Split(string(cmdline), "\x00") {
+			if !strings.HasSuffix(strings.ToLower(arg), "leagueclientux.exe") {
+				continue
+			}
+			for _, exePath := range f.processExePaths(p.Name(), arg) {
+				gameDir := filepath.Join(filepath.Dir(exePath), "Game")
+				if f.isValidGameDir(gameDir) {
+					return filepath.Clean(gameDir)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// processExePaths returns the host paths an exe argument may refer to:
+// itself if already a host path, otherwise resolved in the process's
+// WINEPREFIX or, failing that, in every known prefix.
+func (f *Finder) processExePaths(pid, arg string) []string {
+	arg = strings.ReplaceAll(arg, `\`, "/")
+	if strings.HasPrefix(arg, "/") {
+		return []string{arg}
+	}
+
+	var prefixes []string
+	if environ, err := f.fs.ReadFile(filepath.Join("/proc", pid, "environ")); err == nil {
+		for _, kv := range strings.Split(string(environ), "\x00") {
+			if v, ok := strings.CutPrefix(kv, "WINEPREFIX="); ok && v != "" {
+				prefixes = append(prefixes, v)
+			}
+		}
+	}
+	prefixes = append(prefixes, f.winePrefixes()...)
+
+	paths := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		paths = append(paths, resolveInPrefix(prefix, arg))
+	}
+	return paths
+}
+
+// findFromRegistry reads the League install location from each prefix's
+// system.reg, Wine's on-disk copy of HKLM.
+func (f *Finder) findFromRegistry() string {
+	for _, prefix := range f.winePrefixes() {
+		loc := f.readWineRegValue(filepath.Join(prefix, "system.reg"),
+			`Software\\Wow6432Node\\Riot Games, Inc\\League of Legends`, "Location")
+		if loc == "" {
+			continue
+		}
+		gameDir := filepath.Join(resolveInPrefix(prefix, loc), "Game")
+		if f.isValidGameDir(gameDir) {
+			return filepath.Clean(gameDir)
+		}
+	}
+	return ""
+}
+
+// readWineRegValue returns a string value from a Wine .reg file. key is
+// written as it appears in the file, with doubled backslashes.
+func (f *Finder) readWineRegValue(path, key, name string) string {
+	data, err := f.fs.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	header := "[" + strings.ToLower(key) + "]"
+	valuePrefix := `"` + name + `"="`
+	inKey := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			// Section headers carry a trailing timestamp: [Key\\Path] 1700000000
+			inKey = strings.HasPrefix(strings.ToLower(line), header)
+			continue
+		}
+		if !inKey {
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, valuePrefix); ok {
+			v = strings.TrimSuffix(v, `"`)
+			return strings.ReplaceAll(v, `\\`, `\`)
+		}
+	}
+	return ""
+}
+
+// runCommand runs a command and returns its output
+func runCommand(name string, args ...string) string {
+	output, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return string(output)
+}
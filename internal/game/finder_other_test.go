@@ -0,0 +1,292 @@
+//go:build !windows
+
+package game
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// procFS is the real disk with /proc redirected into a fake tree.
+type procFS struct {
+	OSFileSystem
+	proc string
+}
+
+func (p procFS) path(name string) string {
+	if rest, ok := strings.CutPrefix(name, "/proc"); ok {
+		return p.proc + rest
+	}
+	return name
+}
+
+func (p procFS) Open(name string) (fs.File, error)          { return os.Open(p.path(name)) }
+func (p procFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(p.path(name)) }
+func (p procFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(p.path(name)) }
+func (p procFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(p.path(name)) }
+
+// install creates a League root with a Game directory under dir.
+func install(t *testing.T, dir string) string {
+	t.Helper()
+	game := filepath.Join(dir, "Game")
+	if err := os.MkdirAll(game, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(game, "League of Legends.exe"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func write(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// prefix creates an empty Wine prefix at dir.
+func prefix(t *testing.T, dir string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "drive_c"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func riotInstalls(t *testing.T, prefix, json string) {
+	t.Helper()
+	write(t, filepath.Join(prefix, "drive_c", "ProgramData", "Riot Games", "RiotClientInstalls.json"), json)
+}
+
+func TestWineInstallations(t *testing.T) {
+	type found struct {
+		Path   string
+		Source string
+	}
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, home string) (env map[string]string, want []found)
+	}{
+		{
+			name: "no prefixes",
+			setup: func(t *testing.T, home string) (map[string]string, []found) {
+				os.MkdirAll(filepath.Join(home, ".wine"), os.ModePerm) // no drive_c
+				return nil, nil
+			},
+		},
+		{
+			name: "default prefix, common path",
+			setup: func(t *testing.T, home string) (map[string]string, []found) {
+				p := prefix(t, filepath.Join(home, ".wine"))
+				game := install(t, filepath.Join(p, "drive_c", "Riot Games", "League of Legends"))
+				return nil, []found{{game, SourceCommonPath}}
+			},
+		},
+		{
+			name: "lutris prefix, RiotClientInstalls.json",
+			setup: func(t *testing.T, home string) (map[string]string, []found) {
+				p := prefix(t, filepath.Join(home, "Games", "league-of-legends"))
+				game := install(t, filepath.Join(p, "drive_c", "Custom", "League of Legends"))
+				riotInstalls(t, p, `{"associated_client":{"C:/Custom/League of Legends/":"C:/Riot Games/Riot Client/RiotClientServices.exe"}}`)
+				return nil, []found{{game, SourceRiotClientInstalls}}
+			},
+		},
+		{
+			name: "bottles prefix, install on a mapped drive",
+			setup: func(t *testing.T, home string) (map[string]string, []found) {
+				p := prefix(t, filepath.Join(home, ".local", "share", "bottles", "bottles", "League"))
+				drive := t.TempDir()
+				install(t, filepath.Join(drive, "Riot Games", "League of Legends"))
+				os.MkdirAll(filepath.Join(p, "dosdevices"), os.ModePerm)
+				if err := os.Symlink(drive, filepath.Join(p, "dosdevices", "d:")); err != nil {
+					t.Fatal(err)
+				}
+				os.Symlink("/", filepath.Join(p, "dosdevices", "z:")) // whole host filesystem, skipped
+				riotInstalls(t, p, `{"rc_live":"D:\\Riot Games\\League of Legends\\LeagueClient.exe"}`)
+				return nil, []found{
+					{filepath.Join(p, "dosdevices", "d:", "Riot Games", "League of Legends", "Game"), SourceRiotClientInstalls},
+				}
+			},
+		},
+		{
+			name: "flatpak bottles and WINEPREFIX",
+			setup: func(t *testing.T, home string) (map[string]string, []found) {
+				flatpak := prefix(t, filepath.Join(home, ".var", "app", "com.usebottles.bottles", "data", "bottles", "bottles", "Games"))
+				first := install(t, filepath.Join(flatpak, "drive_c", "Program Files", "Riot Games", "League of Legends"))
+				custom := prefix(t, filepath.Join(t.TempDir(), "lol"))
+				second := install(t, filepath.Join(custom, "drive_c", "Games", "League of Legends"))
+				return map[string]string{"WINEPREFIX": custom}, []found{{second, SourceCommonPath}, {first, SourceCommonPath}}
+			},
+		},
+		{
+			name: "registry",
+			setup: func(t *testing.T, home string) (map[string]string, []found) {
+				p := prefix(t, filepath.Join(home, ".wine"))
+				game := install(t, filepath.Join(p, "drive_c", "Odd Place", "LoL"))
+				write(t, filepath.Join(p, "system.reg"), strings.Join([]string{
+					`WINE REGISTRY Version 2`,
+					`[Software\\Wow6432Node\\Other] 1700000000`,
+					`"Location"="C:\\Wrong"`,
+					`[Software\\Wow6432Node\\Riot Games, Inc\\League of Legends] 1700000000`,
+					`#time=1da1234567890ab`,
+					`"Location"="C:\\Odd Place\\LoL"`,
+				}, "\n"))
+				return nil, []found{{game, SourceRegistry}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		home := t.TempDir()
+		env, want := tt.setup(t, home)
+		getenv := func(key string) string {
+			if key == "HOME" {
+				return home
+			}
+			return env[key]
+		}
+		f := NewFinder(procFS{proc: t.TempDir()}, noCommands, getenv, &memStore{})
+
+		var got []found
+		for _, inst := range f.Installations() {
+			got = append(got, found{inst.Path, inst.Source})
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s:\n got %v\nwant %v", tt.name, got, want)
+		}
+	}
+}
+
+// twoInstalls creates a live install with region EUW found on a common path
+// and a newer PBE install listed in RiotClientInstalls.json, and returns a
+// finder over them.
+func twoInstalls(t *testing.T, store PathStore) (f *Finder, live, pbe string) {
+	t.Helper()
+	home := t.TempDir()
+	p := prefix(t, filepath.Join(home, ".wine"))
+	live = install(t, filepath.Join(p, "drive_c", "Riot Games", "League of Legends"))
+	write(t, filepath.Join(p, "drive_c", "Riot Games", "League of Legends", "Config", "LeagueClientSettings.yaml"), "install:\n    globals:\n        region: \"EUW\"\n")
+	write(t, filepath.Join(live, "content-metadata.json"), `{"version":"14.23.6406574+branch.releases-14-23"}`)
+	pbe = install(t, filepath.Join(p, "drive_c", "Riot Games", "League of Legends (PBE)"))
+	riotInstalls(t, p, `{"associated_client":{"C:/Riot Games/League of Legends (PBE)/":"C:/Riot Games/Riot Client/RiotClientServices.exe"}}`)
+
+	old := time.Now().Add(-24 * time.Hour)
+	os.Chtimes(filepath.Join(live, "League of Legends.exe"), old, old)
+
+	getenv := func(key string) string {
+		if key == "HOME" {
+			return home
+		}
+		return ""
+	}
+	return NewFinder(procFS{proc: t.TempDir()}, noCommands, getenv, store), live, pbe
+}
+
+func TestInstallations(t *testing.T) {
+	f, live, pbe := twoInstalls(t, &memStore{})
+	got := f.Installations()
+	if len(got) != 2 {
+		t.Fatalf("got %d installations, want 2: %+v", len(got), got)
+	}
+	want := []Installation{
+		{Path: pbe, Source: SourceRiotClientInstalls, Region: "PBE"},
+		{Path: live, Source: SourceCommonPath, Region: "EUW", Version: "14.23.6406574"},
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Path != w.Path || g.Source != w.Source || g.Region != w.Region || g.Version != w.Version {
+			t.Errorf("installation %d = %+v, want %+v", i, g, w)
+		}
+	}
+	if !got[0].ModTime.After(got[1].ModTime) {
+		t.Error("installations not sorted newest first")
+	}
+}
+
+func TestSelectInstallation(t *testing.T) {
+	store := &memStore{}
+	f, live, pbe := twoInstalls(t, store)
+
+	// Detection picks the newest install and saves it as detected
+	if got := f.FindGameDir(); got != pbe {
+		t.Fatalf("FindGameDir = %q, want newest %q", got, pbe)
+	}
+	if store.path != pbe || store.selected {
+		t.Errorf("detection saved %q, selected %v", store.path, store.selected)
+	}
+
+	if err := f.SelectInstallation(filepath.Join(t.TempDir(), "Game")); err == nil {
+		t.Error("SelectInstallation of an empty folder: want error")
+	}
+	if err := f.SelectInstallation(`"` + live + `"`); err != nil {
+		t.Fatal(err)
+	}
+	if store.path != live || !store.selected {
+		t.Errorf("selection saved %q, selected %v", store.path, store.selected)
+	}
+
+	// After a restart the selection still beats the newer install
+	restarted := NewFinder(f.fs, f.run, f.getenv, store)
+	if got := restarted.FindGameDir(); got != live {
+		t.Errorf("after restart: FindGameDir = %q, want selected %q", got, live)
+	}
+	want := []TraceStep{{Source: SourceUser, Result: TraceChosen, Path: live, Detail: "selected by the user"}}
+	if got := restarted.Trace(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after restart: trace = %+v", got)
+	}
+
+	// Once the selected install is gone, detection takes over again
+	os.Remove(filepath.Join(live, "League of Legends.exe"))
+	restarted = NewFinder(f.fs, f.run, f.getenv, store)
+	if got := restarted.FindGameDir(); got != pbe {
+		t.Errorf("selected install removed: FindGameDir = %q, want %q", got, pbe)
+	}
+	if store.selected {
+		t.Error("selection kept after the install was removed")
+	}
+	if trace := restarted.Trace(); len(trace) == 0 || trace[0].Source != SourceUser || trace[0].Result != TraceMissing {
+		t.Errorf("selected install removed: trace = %+v", trace)
+	}
+}
+
+func TestWineRunningProcess(t *testing.T) {
+	home := t.TempDir()
+	p := prefix(t, filepath.Join(home, "Games", "lol"))
+	game := install(t, filepath.Join(p, "drive_c", "Riot Games", "League of Legends"))
+
+	proc := t.TempDir()
+	write(t, filepath.Join(proc, "100", "cmdline"), "/usr/bin/wineserver\x00")
+	write(t, filepath.Join(proc, "200", "cmdline"), `C:\Riot Games\League of Legends\LeagueClientUx.exe`+"\x00--app-port=1234\x00")
+	write(t, filepath.Join(proc, "200", "environ"), "HOME="+home+"\x00WINEPREFIX="+p+"\x00")
+
+	// No HOME: the prefix is only known from the process environment
+	f := NewFinder(procFS{proc: proc}, nil, func(string) string { return "" }, &memStore{})
+	if got := f.findFromRunningProcess(); got != game {
+		t.Errorf("findFromRunningProcess = %q, want %q", got, game)
+	}
+}
+
+func TestResolveInPrefix(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`C:\Riot Games\League of Legends`, "/p/drive_c/Riot Games/League of Legends"},
+		{"c:/Riot Games/", "/p/drive_c/Riot Games"},
+		{`D:\Games\LoL`, "/p/dosdevices/d:/Games/LoL"},
+		{"/already/host", "/already/host"},
+	}
+	for _, tt := range tests {
+		if got := resolveInPrefix("/p", tt.in); got != filepath.FromSlash(tt.want) {
+			t.Errorf("resolveInPrefix(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
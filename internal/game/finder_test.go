@@ -0,0 +1,166 @@
+package game
+
+import (
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// mapFS is a FileSystem over an in-memory tree, keyed by host paths.
+type mapFS fstest.MapFS
+
+func (m mapFS) key(name string) string {
+	key := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "/")
+	if key == "" {
+		return "."
+	}
+	return key
+}
+
+func (m mapFS) Open(name string) (fs.File, error)     { return fstest.MapFS(m).Open(m.key(name)) }
+func (m mapFS) Stat(name string) (fs.FileInfo, error) { return fs.Stat(fstest.MapFS(m), m.key(name)) }
+func (m mapFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(fstest.MapFS(m), m.key(name))
+}
+func (m mapFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(fstest.MapFS(m), m.key(name))
+}
+
+// addExe writes League of Legends.exe into gameDir, modified at modTime.
+func (m mapFS) addExe(gameDir string, data []byte, modTime time.Time) {
+	m[m.key(filepath.Join(gameDir, "League of Legends.exe"))] = &fstest.MapFile{Data: data, ModTime: modTime}
+}
+
+// memStore is a PathStore kept in memory.
+type memStore struct {
+	path     string
+	selected bool
+	saves    int
+}
+
+func (s *memStore) GamePath() (string, bool) { return s.path, s.selected }
+func (s *memStore) SetGamePath(dir string, userSelected bool) error {
+	s.path = dir
+	s.selected = userSelected
+	s.saves++
+	return nil
+}
+
+func noCommands(string, ...string) string { return "" }
+
+// noEnv hides the process environment, so no Wine prefix is found.
+func noEnv(string) string { return "" }
+
+func TestExplain(t *testing.T) {
+	if got := NewFinder(mapFS{}, noCommands, noEnv, &memStore{}).Explain(); got != "no detection has run yet" {
+		t.Errorf("Explain before detection = %q", got)
+	}
+
+	gameDir := filepath.Join(string(filepath.Separator)+"games", "lol", "Game")
+	fsys := mapFS{}
+	fsys.addExe(gameDir, nil, time.Now())
+	f := NewFinder(fsys, noCommands, noEnv, &memStore{path: gameDir})
+	if got := f.FindGameDir(); got != gameDir {
+		t.Fatalf("FindGameDir = %q, want %q", got, gameDir)
+	}
+
+	want := []TraceStep{
+		{Source: SourceSaved, Result: TraceFound, Path: gameDir},
+		{Source: SourceRiotClientInstalls, Result: TraceNone, Detail: "no League install listed"},
+		{Source: SourceCommonPath, Result: TraceNone, Detail: "checked 0 paths on 0 drives"},
+		{Source: SourceSaved, Result: TraceChosen, Path: gameDir, Detail: "only installation found"},
+	}
+	if got := f.Trace(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Trace:\n got %+v\nwant %+v", got, want)
+	}
+	wantText := strings.Join([]string{
+		"saved              found     " + gameDir,
+		"riotClientInstalls none      (no League install listed)",
+		"commonPath         none      (checked 0 paths on 0 drives)",
+		"saved              chosen    " + gameDir + " (only installation found)",
+	}, "\n")
+	if got := f.Explain(); got != wantText {
+		t.Errorf("Explain:\n%s\nwant:\n%s", got, wantText)
+	}
+}
+
+func TestFindGameDirCache(t *testing.T) {
+	root := string(filepath.Separator) + "games"
+	a := filepath.Join(root, "a", "Game")
+	b := filepath.Join(root, "b", "Game")
+	fsys := mapFS{}
+	fsys.addExe(a, nil, time.Now())
+	fsys.addExe(b, nil, time.Now())
+	store := &memStore{path: a}
+	f := NewFinder(fsys, noCommands, noEnv, store)
+
+	if got := f.FindGameDir(); got != a {
+		t.Fatalf("FindGameDir = %q, want %q", got, a)
+	}
+
+	// A saved path changed behind the finder's back forces a rescan
+	store.path = b
+	if got := f.FindGameDir(); got != b {
+		t.Errorf("after the saved path changed: FindGameDir = %q, want %q", got, b)
+	}
+
+	// Without a saved path the cached directory is still used
+	store.path = ""
+	saves := store.saves
+	if got := f.FindGameDir(); got != b {
+		t.Errorf("cached: FindGameDir = %q, want %q", got, b)
+	}
+	if store.saves != saves {
+		t.Error("cached lookup saved the path again")
+	}
+
+	// Invalidate drops the cache, and with nothing saved nothing is found
+	f.Invalidate()
+	if got := f.FindGameDir(); got != "" {
+		t.Errorf("after Invalidate: FindGameDir = %q, want none", got)
+	}
+	if trace := f.Trace(); len(trace) == 0 || trace[0] != (TraceStep{Source: SourceSaved, Result: TraceNone, Detail: "no saved path"}) {
+		t.Errorf("after Invalidate: trace = %+v", trace)
+	}
+
+	// A cached directory that lost its exe is not returned
+	store.path = a
+	f.FindGameDir()
+	delete(fsys, fsys.key(filepath.Join(a, "League of Legends.exe")))
+	if got := f.FindGameDir(); got != "" {
+		t.Errorf("after the install was removed: FindGameDir = %q, want none", got)
+	}
+}
+
+func TestCommandValue(t *testing.T) {
+	type call struct {
+		name string
+		args []string
+	}
+	var calls []call
+	run := func(name string, args ...string) string {
+		calls = append(calls, call{name, args})
+		if name == "wmic" {
+			return "\r\n\r\nExecutablePath=C:\\Riot Games\\League of Legends\\LeagueClientUx.exe\r\n\r\n"
+		}
+		return ""
+	}
+	f := NewFinder(mapFS{}, run, noEnv, &memStore{})
+	re := regexp.MustCompile(`ExecutablePath=(.+)`)
+
+	if got := f.commandValue(re, "wmic", "process", "get", "ExecutablePath"); got != `C:\Riot Games\League of Legends\LeagueClientUx.exe` {
+		t.Errorf("commandValue = %q", got)
+	}
+	if got := f.commandValue(re, "reg", "query"); got != "" {
+		t.Errorf("commandValue without a match = %q, want empty", got)
+	}
+	want := []call{{"wmic", []string{"process", "get", "ExecutablePath"}}, {"reg", []string{"query"}}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("runner calls = %v, want %v", calls, want)
+	}
+}
@@ -3,12 +3,81 @@
 package game
 
 import (
+	"os/exec"
+	"path/filepath"
+	"regexp"
 	"syscall"
 )
 
+var (
+	wmicExePathRe = regexp.MustCompile(`ExecutablePath=(.+)`)
+	regLocationRe = regexp.MustCompile(`Location\s+REG_SZ\s+(.+)`)
+)
+
 // getSysProcAttr returns Windows-specific process attributes to hide console window
 func getSysProcAttr() *syscall.SysProcAttr {
 	return &syscall.SysProcAttr{
 		HideWindow: true,
 	}
 }
+
+// riotClientInstallsFiles returns the Riot Client's install registry.
+func (f *Finder) riotClientInstallsFiles() []riotInstallsFile {
+	return []riotInstallsFile{{
+		path:    `C:\ProgramData\Riot Games\RiotClientInstalls.json`,
+		resolve: func(p string) string { return p },
+	}}
+}
+
+// getFixedDrives returns list of accessible drives (C:, D:, etc.)
+func (f *Finder) getFixedDrives() []string {
+	var drives []string
+
+	// Try drives A-Z
+	for i := 'A'; i <= 'Z'; i++ {
+		drive := string(i) + `:\`
+		if info, err := f.fs.Stat(drive); err == nil && info.IsDir() {
+			// Check if accessible by trying to read directory
+			if _, err := f.fs.ReadDir(drive); err == nil {
+				drives = append(drives, drive)
+			}
+		}
+	}
+
+	return drives
+}
+
+// findFromRunningProcess tries to locate the game dir from a running LeagueClientUx.exe.
+func (f *Finder) findFromRunningProcess() string {
+	exePath := f.commandValue(wmicExePathRe, "wmic", "process", "where", "name='LeagueClientUx.exe'", "get", "ExecutablePath", "/value")
+	if exePath != "" {
+		gameDir := filepath.Join(exePath, "..", "Game")
+		if f.isValidGameDir(gameDir) {
+			return filepath.Clean(gameDir)
+		}
+	}
+	return ""
+}
+
+// findFromRegistry tries to locate the game dir from the Windows registry.
+func (f *Finder) findFromRegistry() string {
+	loc := f.commandValue(regLocationRe, "reg", "query", `HKLM\SOFTWARE\WOW6432Node\Riot Games, Inc\League of Legends`, "/v", "Location")
+	if loc != "" {
+		gameDir := filepath.Join(loc, "Game")
+		if f.isValidGameDir(gameDir) {
+			return filepath.Clean(gameDir)
+		}
+	}
+	return ""
+}
+
+// runCommand runs a command and returns its output
+func runCommand(name string, args ...string) string {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = getSysProcAttr()
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return string(output)
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Installation describes one League of Legends game directory found on disk.
+type Installation struct {
+	Path    string    `json:"path"`
+	Source  string    `json:"source"`
+	Version string    `json:"version"`
+	Region  string    `json:"region"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// Installations returns every valid game directory from all detection
+// sources, newest first. Unlike FindGameDir it always runs the process and
+// registry lookups, so it is slower and meant for user-facing selection.
+func (f *Finder) Installations() []Installation {
+	s := &search{}
+	f.collectCandidates(s)
+	if dir := f.findFromRunningProcess(); dir != "" {
+		f.addCandidate(s, dir, SourceProcess)
+	}
+	if dir := f.findFromRegistry(); dir != "" {
+		f.addCandidate(s, dir, SourceRegistry)
+	}
+
+	installs := make([]Installation, 0, len(s.candidates))
+	for _, c := range s.candidates {
+		installs = append(installs, Installation{
+			Path:    c.dir,
+			Source:  c.source,
+			Version: f.Version(c.dir),
+			Region:  f.regionHint(c.dir),
+			ModTime: f.modTime(filepath.Join(c.dir, "League of Legends.exe")),
+		})
+	}
+	sort.SliceStable(installs, func(i, j int) bool {
+		return installs[i].ModTime.After(installs[j].ModTime)
+	})
+	return installs
+}
+
+// SelectInstallation validates dir and makes it the game directory used from
+// now on, overriding automatic detection.
+func (f *Finder) SelectInstallation(dir string) error {
+	dir = filepath.Clean(strings.Trim(strings.TrimSpace(dir), `"'`))
+	if !f.isValidGameDir(dir) {
+		return fmt.Errorf("League of Legends.exe not found in %s", dir)
+	}
+	if err := f.store.SetGamePath(dir, true); err != nil {
+		return err
+	}
+	f.setChosen(dir)
+	return nil
+}
+
+// Installations lists installations with the default finder.
+func Installations() []Installation {
+	return defaultFinder.Installations()
+}
+
+// SelectInstallation selects an installation with the default finder.
+func SelectInstallation(dir string) error {
+	return defaultFinder.SelectInstallation(dir)
+}
+
+// IsValidGameDir reports whether dir contains League of Legends.exe.
+func IsValidGameDir(dir string) bool {
+	return dir != "" && defaultFinder.isValidGameDir(dir)
+}
+
+// regionHint guesses which shard an installation belongs to: "PBE" for
+// public beta installs, otherwise the region from the client settings next
+// to the Game folder. Returns "" if unknown.
+func (f *Finder) regionHint(gameDir string) string {
+	root := filepath.Dir(gameDir)
+	if strings.Contains(strings.ToUpper(root), "PBE") {
+		return "PBE"
+	}
+
+	data, err := f.fs.ReadFile(filepath.Join(root, "Config", "LeagueClientSettings.yaml"))
+	if err != nil {
+		return ""
+	}
+
+	// Only a single key is needed, so skip a full YAML parser
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, ok := strings.CutPrefix(line, "region:"); ok {
+			return strings.ToUpper(strings.Trim(strings.TrimSpace(value), `"'`))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRegionHint(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator)+"games", "League of Legends")
+	settings := filepath.Join("games", "League of Legends", "Config", "LeagueClientSettings.yaml")
+	tests := []struct {
+		name     string
+		gameDir  string
+		settings string
+		want     string
+	}{
+		{"quoted region", filepath.Join(root, "Game"), "install:\n    globals:\n        region: \"EUW\"\n", "EUW"},
+		{"bare lowercase region", filepath.Join(root, "Game"), "region: na\n", "NA"},
+		{"no region key", filepath.Join(root, "Game"), "install:\n    globals:\n        locale: \"en_GB\"\n", ""},
+		{"no settings file", filepath.Join(root, "Game"), "", ""},
+		{"PBE folder", filepath.Join(string(filepath.Separator)+"games", "League of Legends (PBE)", "Game"), "", "PBE"},
+	}
+	for _, tt := range tests {
+		fsys := mapFS{}
+		if tt.settings != "" {
+			fsys[filepath.ToSlash(settings)] = &fstest.MapFile{Data: []byte(tt.settings)}
+		}
+		f := NewFinder(fsys, noCommands, noEnv, &memStore{})
+		if got := f.regionHint(tt.gameDir); got != tt.want {
+			t.Errorf("%s: regionHint = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package game
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rtVersion        = 16         // RT_VERSION resource type
+	fixedInfoMagic   = 0xFEEF04BD // VS_FIXEDFILEINFO.dwSignature
+	maxResourceDepth = 3          // type -> name -> language
+)
+
+// versionEntry caches the version read for one game directory. The exe's
+// size and mtime are kept so a patch (which rewrites the exe) is noticed.
+type versionEntry struct {
+	version string
+	size    int64
+	modTime time.Time
+}
+
+var (
+	handlersMu      sync.Mutex
+	versionHandlers []func(dir, oldVersion, newVersion string)
+)
+
+// OnVersionChange registers a handler called when Version notices that the
+// installed patch in a directory differs from the one it last read.
+func OnVersionChange(fn func(dir, oldVersion, newVersion string)) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	versionHandlers = append(versionHandlers, fn)
+}
+
+// Version returns the installed game version with the default finder.
+func Version(dir string) string {
+	return defaultFinder.Version(dir)
+}
+
+// Version returns the installed game version for a Game directory, e.g.
+// "14.23.640.6574". It reads the version resource of League of Legends.exe
+// and falls back to content-metadata.json. Results are cached per path until
+// the exe changes. Returns "" if the version cannot be determined.
+func (f *Finder) Version(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	dir = filepath.Clean(dir)
+	exePath := filepath.Join(dir, "League of Legends.exe")
+	info, err := f.fs.Stat(exePath)
+	if err != nil {
+		return ""
+	}
+
+	f.versionMu.Lock()
+	prev, ok := f.versions[dir]
+	f.versionMu.Unlock()
+	if ok && prev.size == info.Size() && prev.modTime.Equal(info.ModTime()) {
+		return prev.version
+	}
+
+	version, err := f.readExeVersion(exePath)
+	if err != nil || version == "" {
+		version = f.readContentMetadataVersion(dir)
+	}
+
+	f.versionMu.Lock()
+	f.versions[dir] = versionEntry{version: version, size: info.Size(), modTime: info.ModTime()}
+	f.versionMu.Unlock()
+
+	handlersMu.Lock()
+	handlers := append([]func(string, string, string){}, versionHandlers...)
+	handlersMu.Unlock()
+
+	if ok && prev.version != version {
+		for _, fn := range handlers {
+			fn(dir, prev.version, version)
+		}
+	}
+	return version
+}
+
+// Patch shortens a full version to its patch number, e.g. "14.23.640.6574" -> "14.23".
+func Patch(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// readExeVersion parses the VS_FIXEDFILEINFO file version from a PE file's
+// resource section.
+func (f *Finder) readExeVersion(path string) (string, error) {
+	file, err := f.fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// debug/pe reads at offsets; only the sections it is asked for are
+	// loaded when the file supports that
+	r, ok := file.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", err
+		}
+		r = bytes.NewReader(data)
+	}
+	exe, err := pe.NewFile(r)
+	if err != nil {
+		return "", err
+	}
+	defer exe.Close()
+
+	rsrc := exe.Section(".rsrc")
+	if rsrc == nil {
+		return "", fmt.Errorf("no resource section")
+	}
+	data, err := rsrc.Data()
+	if err != nil {
+		return "", err
+	}
+
+	rva, size, err := findVersionResource(data)
+	if err != nil {
+		return "", err
+	}
+	start := int64(rva) - int64(rsrc.VirtualAddress)
+	end := start + int64(size)
+	if start < 0 || end > int64(len(data)) {
+		return "", fmt.Errorf("version resource out of bounds")
+	}
+	return parseFixedFileInfo(data[start:end])
+}
+
+// findVersionResource walks the resource directory tree to the first
+// RT_VERSION data entry and returns its RVA and size.
+func findVersionResource(data []byte) (uint32, uint32, error) {
+	offset := uint32(0)
+	for depth := 0; depth < maxResourceDepth; depth++ {
+		if int(offset)+16 > len(data) {
+			return 0, 0, fmt.Errorf("truncated resource directory")
+		}
+		named := binary.LittleEndian.Uint16(data[offset+12:])
+		ids := binary.LittleEndian.Uint16(data[offset+14:])
+		count := uint32(named) + uint32(ids)
+
+		next := uint32(0)
+		found := false
+		for i := uint32(0); i < count; i++ {
+			e := offset + 16 + i*8
+			if int(e)+8 > len(data) {
+				return 0, 0, fmt.Errorf("truncated resource entry")
+			}
+			name := binary.LittleEndian.Uint32(data[e:])
+			target := binary.LittleEndian.Uint32(data[e+4:])
+			// Only the top level is filtered by type; below it take the first entry
+			if depth == 0 && name != rtVersion {
+				continue
+			}
+			next = target
+			found = true
+			break
+		}
+		if !found {
+			return 0, 0, fmt.Errorf("no version resource")
+		}
+
+		if next&0x80000000 == 0 {
+			// Data entry: RVA, size, codepage, reserved
+			if int(next)+8 > len(data) {
+				return 0, 0, fmt.Errorf("truncated resource data entry")
+			}
+			return binary.LittleEndian.Uint32(data[next:]), binary.LittleEndian.Uint32(data[next+4:]), nil
+		}
+		offset = next &^ 0x80000000
+	}
+	return 0, 0, fmt.Errorf("resource tree too deep")
+}
+
+// parseFixedFileInfo extracts the file version from a VS_VERSIONINFO blob.
+func parseFixedFileInfo(blob []byte) (string, error) {
+	var magic [4]byte
+	binary.LittleEndian.PutUint32(magic[:], fixedInfoMagic)
+	i := bytes.Index(blob, magic[:])
+	if i < 0 || i+16 > len(blob) {
+		return "", fmt.Errorf("no fixed file info")
+	}
+	ms := binary.LittleEndian.Uint32(blob[i+8:])
+	ls := binary.LittleEndian.Uint32(blob[i+12:])
+	return fmt.Sprintf("%d.%d.%d.%d", ms>>16, ms&0xffff, ls>>16, ls&0xffff), nil
+}
+
+// readContentMetadataVersion reads the version from the game's
+// content-metadata.json, if present.
+func (f *Finder) readContentMetadataVersion(dir string) string {
+	data, err := f.fs.ReadFile(filepath.Join(dir, "content-metadata.json"))
+	if err != nil {
+		return ""
+	}
+	var meta struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return ""
+	}
+	// e.g. "14.23.6406574+branch.releases-14-23..." -> "14.23.6406574"
+	if i := strings.IndexAny(meta.Version, "+ "); i >= 0 {
+		return meta.Version[:i]
+	}
+	return meta.Version
+}
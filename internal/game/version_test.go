@@ -0,0 +1,205 @@
+package game
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// peFixture describes a synthetic PE file with a single .rsrc section.
+type peFixture struct {
+	section  string // section name, .rsrc unless changed
+	resType  uint32 // resource type of the only top-level entry
+	version  [4]uint16
+	truncate int // bytes cut from the end of the section data
+}
+
+// build lays out a DOS stub, PE headers, one section header and a resource
+// tree type -> name -> language -> data entry pointing at a VS_VERSIONINFO.
+func (p peFixture) build() []byte {
+	const (
+		peOffset   = 0x40
+		rawOffset  = 0x200
+		sectionRVA = 0x1000
+	)
+	le := binary.LittleEndian
+
+	rsrc := make([]byte, 0x58)
+	dir := func(off, name, target uint32) {
+		le.PutUint16(rsrc[off+14:], 1) // one ID entry
+		le.PutUint32(rsrc[off+16:], name)
+		le.PutUint32(rsrc[off+20:], target)
+	}
+	dir(0x00, p.resType, 0x80000000|0x18)
+	dir(0x18, 1, 0x80000000|0x30)
+	dir(0x30, 0x409, 0x48)
+
+	blob := make([]byte, 40, 40+52)
+	copy(blob[6:], "V\x00S\x00_\x00V\x00E\x00R\x00") // start of the UTF-16 key
+	fixed := make([]byte, 52)
+	le.PutUint32(fixed[0:], fixedInfoMagic)
+	le.PutUint32(fixed[4:], 0x10000)
+	le.PutUint32(fixed[8:], uint32(p.version[0])<<16|uint32(p.version[1]))
+	le.PutUint32(fixed[12:], uint32(p.version[2])<<16|uint32(p.version[3]))
+	blob = append(blob, fixed...)
+	le.PutUint16(blob[0:], uint16(len(blob)))
+
+	le.PutUint32(rsrc[0x48:], sectionRVA+uint32(len(rsrc)))
+	le.PutUint32(rsrc[0x4c:], uint32(len(blob)))
+	rsrc = append(rsrc, blob...)
+	rsrc = rsrc[:len(rsrc)-p.truncate]
+
+	out := make([]byte, rawOffset, rawOffset+len(rsrc))
+	copy(out, "MZ")
+	le.PutUint32(out[0x3c:], peOffset)
+	copy(out[peOffset:], "PE\x00\x00")
+	hdr := out[peOffset+4:]
+	le.PutUint16(hdr[0:], 0x14c) // i386
+	le.PutUint16(hdr[2:], 1)     // one section
+	sec := hdr[20:]
+	name := p.section
+	if name == "" {
+		name = ".rsrc"
+	}
+	copy(sec[0:8], name)
+	le.PutUint32(sec[8:], uint32(len(rsrc)))
+	le.PutUint32(sec[12:], sectionRVA)
+	le.PutUint32(sec[16:], uint32(len(rsrc)))
+	le.PutUint32(sec[20:], rawOffset)
+	return append(out, rsrc...)
+}
+
+func TestReadExeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{"version resource", peFixture{resType: rtVersion, version: [4]uint16{14, 23, 640, 6574}}.build(), "14.23.640.6574", false},
+		{"no resource section", peFixture{section: ".text", resType: rtVersion}.build(), "", true},
+		{"icons only", peFixture{resType: 3}.build(), "", true},
+		{"data entry past the section", peFixture{resType: rtVersion, truncate: 30}.build(), "", true},
+		{"not a PE file", []byte("MZ not really"), "", true},
+	}
+	for _, tt := range tests {
+		exe := filepath.Join(string(filepath.Separator)+"game", "League of Legends.exe")
+		fsys := mapFS{}
+		fsys[fsys.key(exe)] = &fstest.MapFile{Data: tt.data}
+		got, err := NewFinder(fsys, noCommands, noEnv, &memStore{}).readExeVersion(exe)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: readExeVersion = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFindVersionResource(t *testing.T) {
+	full := peFixture{resType: rtVersion}.build()[0x200:]
+	for _, n := range []int{0, 10, 20, 0x20, 0x40, 0x4c} {
+		if _, _, err := findVersionResource(full[:n]); err == nil {
+			t.Errorf("resource tree cut at %d bytes: want error", n)
+		}
+	}
+
+	// A directory that only points to further directories is too deep
+	deep := make([]byte, 0x18)
+	binary.LittleEndian.PutUint16(deep[14:], 1)
+	binary.LittleEndian.PutUint32(deep[16:], rtVersion)
+	binary.LittleEndian.PutUint32(deep[20:], 0x80000000)
+	if _, _, err := findVersionResource(deep); err == nil {
+		t.Error("self-referencing directory: want error")
+	}
+}
+
+func TestReadContentMetadataVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"branch suffix", `{"version":"14.23.6406574+branch.releases-14-23.content.release"}`, "14.23.6406574"},
+		{"space suffix", `{"version":"14.1.5 (beta)"}`, "14.1.5"},
+		{"plain", `{"version":"13.24.1"}`, "13.24.1"},
+		{"no version", `{"other":"x"}`, ""},
+		{"malformed", `{"version":`, ""},
+		{"missing file", "", ""},
+	}
+	dir := filepath.Join(string(filepath.Separator)+"games", "Game")
+	for _, tt := range tests {
+		fsys := mapFS{}
+		if tt.data != "" {
+			fsys[fsys.key(filepath.Join(dir, "content-metadata.json"))] = &fstest.MapFile{Data: []byte(tt.data)}
+		}
+		if got := NewFinder(fsys, noCommands, noEnv, &memStore{}).readContentMetadataVersion(dir); got != tt.want {
+			t.Errorf("%s: readContentMetadataVersion = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVersionChange(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator)+"versioned", "Game")
+	fsys := mapFS{}
+	f := NewFinder(fsys, noCommands, noEnv, &memStore{})
+
+	type change struct{ old, new string }
+	var changes []change
+	OnVersionChange(func(d, oldVersion, newVersion string) {
+		if d == dir {
+			changes = append(changes, change{oldVersion, newVersion})
+		}
+	})
+
+	if got := f.Version(dir); got != "" {
+		t.Errorf("no exe: Version = %q, want empty", got)
+	}
+
+	patched := time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+	fsys.addExe(dir, peFixture{resType: rtVersion, version: [4]uint16{14, 23, 640, 6574}}.build(), patched)
+	if got := f.Version(dir); got != "14.23.640.6574" {
+		t.Errorf("Version = %q, want 14.23.640.6574", got)
+	}
+
+	// Same size and mtime: the cached version is returned without rereading
+	fsys.addExe(dir, peFixture{resType: rtVersion, version: [4]uint16{99, 0, 0, 0}}.build(), patched)
+	if got := f.Version(dir); got != "14.23.640.6574" {
+		t.Errorf("unchanged exe: Version = %q, want the cached 14.23.640.6574", got)
+	}
+	if len(changes) != 0 {
+		t.Errorf("handler called before any change: %v", changes)
+	}
+
+	// A patch rewrites the exe
+	fsys.addExe(dir, peFixture{resType: rtVersion, version: [4]uint16{14, 24, 641, 1}}.build(), patched.Add(time.Hour))
+	if got := f.Version(dir); got != "14.24.641.1" {
+		t.Errorf("patched: Version = %q, want 14.24.641.1", got)
+	}
+
+	// An exe without a version resource falls back to content-metadata.json
+	fsys[fsys.key(filepath.Join(dir, "content-metadata.json"))] = &fstest.MapFile{Data: []byte(`{"version":"15.1.6500000+branch"}`)}
+	fsys.addExe(dir, []byte("stub"), patched.Add(2*time.Hour))
+	if got := f.Version(dir); got != "15.1.6500000" {
+		t.Errorf("fallback: Version = %q, want 15.1.6500000", got)
+	}
+
+	want := []change{{"14.23.640.6574", "14.24.641.1"}, {"14.24.641.1", "15.1.6500000"}}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"14.23.640.6574", "14.23"},
+		{"15.1.6500000", "15.1"},
+		{"14.23", "14.23"},
+		{"14", "14"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Patch(tt.in); got != tt.want {
+			t.Errorf("Patch(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
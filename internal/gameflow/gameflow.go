@@ -0,0 +1,202 @@
+// Package gameflow tracks where the player is in the League game loop
+// (lobby, champion select, in game, end of game) so the backend can react
+// to phase changes without waiting for the plugin.
+package gameflow
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/lcu"
+)
+
+// Phase is a simplified gameflow phase.
+type Phase string
+
+const (
+	PhaseNone        Phase = "None"
+	PhaseLobby       Phase = "Lobby"
+	PhaseChampSelect Phase = "ChampSelect"
+	PhaseInProgress  Phase = "InProgress"
+	PhaseEndOfGame   Phase = "EndOfGame"
+)
+
+// Where a phase change came from.
+const (
+	SourceLCU     = "lcu"
+	SourceProcess = "process"
+)
+
+// FromLCU maps an LCU gameflow phase to a Phase.
+func FromLCU(phase string) Phase {
+	switch phase {
+	case "Lobby", "Matchmaking", "CheckedIntoTournament", "ReadyCheck":
+		return PhaseLobby
+	case "ChampSelect":
+		return PhaseChampSelect
+	case "GameStart", "InProgress", "Reconnect":
+		return PhaseInProgress
+	case "WaitingForStats", "PreEndOfGame", "EndOfGame":
+		return PhaseEndOfGame
+	}
+	return PhaseNone
+}
+
+// Transition describes one phase change.
+type Transition struct {
+	From   Phase     `json:"from"`
+	To     Phase     `json:"to"`
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+}
+
+// Machine holds the current phase and notifies handlers on every change.
+// It can be fed by LCU events, a game process watcher, or both: the watcher
+// only acts while the LCU stream is unavailable.
+//
+// Handlers run on a goroutine of their own, one transition at a time and in
+// order, so a slow handler never holds up the LCU event reader or the
+// process watcher that reported the phase.
+type Machine struct {
+	mu         sync.Mutex
+	phase      Phase
+	since      time.Time
+	updates    uint64 // Set calls so far, to spot phases reported meanwhile
+	handlers   []func(Transition)
+	pending    []Transition
+	delivering bool
+}
+
+// NewMachine creates a machine in PhaseNone.
+func NewMachine() *Machine {
+	return &Machine{phase: PhaseNone, since: time.Now()}
+}
+
+// Phase returns the current phase and when it was entered.
+func (m *Machine) Phase() (Phase, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.phase, m.since
+}
+
+// OnChange registers a handler called after every phase change.
+func (m *Machine) OnChange(fn func(Transition)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, fn)
+}
+
+// Set moves to phase. Repeats of the current phase are ignored.
+func (m *Machine) Set(phase Phase, source string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(phase, source)
+}
+
+// setIfUnchanged moves to phase unless Set was called since updates was read.
+func (m *Machine) setIfUnchanged(phase Phase, source string, updates uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.updates == updates {
+		m.set(phase, source)
+	}
+}
+
+// set records the change and queues it for the handlers. Caller must hold mu.
+func (m *Machine) set(phase Phase, source string) {
+	m.updates++
+	if phase == m.phase {
+		return
+	}
+	t := Transition{From: m.phase, To: phase, Source: source, Time: time.Now()}
+	m.phase = phase
+	m.since = t.Time
+	m.pending = append(m.pending, t)
+	if !m.delivering {
+		m.delivering = true
+		go m.deliver()
+	}
+}
+
+// deliver runs the handlers for queued transitions until the queue is empty.
+func (m *Machine) deliver() {
+	for {
+		m.mu.Lock()
+		if len(m.pending) == 0 {
+			m.delivering = false
+			m.mu.Unlock()
+			return
+		}
+		t := m.pending[0]
+		m.pending = m.pending[1:]
+		handlers := append([]func(Transition){}, m.handlers...)
+		m.mu.Unlock()
+
+		for _, fn := range handlers {
+			fn(t)
+		}
+	}
+}
+
+// EventSource is the part of lcu.EventStream FollowLCU uses.
+type EventSource interface {
+	OnConnect(fn func())
+	SubscribeGameflowPhase(fn func(phase string)) func()
+}
+
+// PhaseReader is the part of lcu.Client FollowLCU uses.
+type PhaseReader interface {
+	Get(path string, out interface{}) error
+}
+
+// FollowLCU feeds the machine from the gameflow-phase event stream, and
+// re-reads the phase through client after every (re)connect so changes
+// missed while disconnected are not lost. The read runs off the event
+// reader and is dropped if an event reports a phase first. Returns an
+// unsubscribe function.
+func (m *Machine) FollowLCU(events EventSource, client PhaseReader) func() {
+	events.OnConnect(func() {
+		m.mu.Lock()
+		updates := m.updates
+		m.mu.Unlock()
+		go func() {
+			var phase string
+			if err := client.Get(lcu.EndpointGameflowPhase, &phase); err == nil {
+				m.setIfUnchanged(FromLCU(phase), SourceLCU, updates)
+			}
+		}()
+	})
+	return events.SubscribeGameflowPhase(func(phase string) {
+		m.Set(FromLCU(phase), SourceLCU)
+	})
+}
+
+// WatchProcess polls gameRunning every interval until ctx is done. While
+// lcuConnected reports false it derives the phase from the game process:
+// the process appearing means InProgress, and disappearing afterwards means
+// EndOfGame.
+func (m *Machine) WatchProcess(ctx context.Context, gameRunning, lcuConnected func() bool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if lcuConnected() {
+			continue
+		}
+		phase, _ := m.Phase()
+		running := gameRunning()
+		switch {
+		case running && phase != PhaseInProgress:
+			m.Set(PhaseInProgress, SourceProcess)
+		case !running && phase == PhaseInProgress:
+			m.Set(PhaseEndOfGame, SourceProcess)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package gameflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recorder collects transitions delivered to a handler.
+type recorder struct {
+	ch chan Transition
+}
+
+func record(m *Machine) *recorder {
+	r := &recorder{ch: make(chan Transition, 16)}
+	m.OnChange(func(t Transition) { r.ch <- t })
+	return r
+}
+
+// next waits for the next transition.
+func (r *recorder) next(t *testing.T) Transition {
+	t.Helper()
+	select {
+	case tr := <-r.ch:
+		return tr
+	case <-time.After(2 * time.Second):
+		t.Fatal("no transition delivered")
+		return Transition{}
+	}
+}
+
+// none checks that no transition is delivered for a short while.
+func (r *recorder) none(t *testing.T) {
+	t.Helper()
+	select {
+	case tr := <-r.ch:
+		t.Errorf("unexpected transition %s -> %s", tr.From, tr.To)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFromLCU(t *testing.T) {
+	tests := map[string]Phase{
+		"None":              PhaseNone,
+		"Lobby":             PhaseLobby,
+		"Matchmaking":       PhaseLobby,
+		"ReadyCheck":        PhaseLobby,
+		"ChampSelect":       PhaseChampSelect,
+		"GameStart":         PhaseInProgress,
+		"InProgress":        PhaseInProgress,
+		"Reconnect":         PhaseInProgress,
+		"WaitingForStats":   PhaseEndOfGame,
+		"PreEndOfGame":      PhaseEndOfGame,
+		"EndOfGame":         PhaseEndOfGame,
+		"TerminatedInError": PhaseNone,
+	}
+	for in, want := range tests {
+		if got := FromLCU(in); got != want {
+			t.Errorf("FromLCU(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestMachine(t *testing.T) {
+	m := NewMachine()
+	r := record(m)
+
+	steps := []struct {
+		phase Phase
+		from  Phase
+	}{
+		{PhaseLobby, PhaseNone},
+		{PhaseChampSelect, PhaseLobby},
+		{PhaseInProgress, PhaseChampSelect},
+		{PhaseEndOfGame, PhaseInProgress},
+	}
+	for _, s := range steps {
+		m.Set(s.phase, SourceLCU)
+		m.Set(s.phase, SourceLCU) // repeats are ignored
+	}
+	for _, s := range steps {
+		tr := r.next(t)
+		if tr.From != s.from || tr.To != s.phase || tr.Source != SourceLCU {
+			t.Errorf("transition = %s -> %s (%s), want %s -> %s", tr.From, tr.To, tr.Source, s.from, s.phase)
+		}
+	}
+	r.none(t)
+
+	if phase, since := m.Phase(); phase != PhaseEndOfGame || since.IsZero() {
+		t.Errorf("Phase = %s, %v", phase, since)
+	}
+}
+
+func TestMachineSlowHandler(t *testing.T) {
+	m := NewMachine()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var seen []Phase
+	m.OnChange(func(tr Transition) {
+		<-release
+		mu.Lock()
+		seen = append(seen, tr.To)
+		mu.Unlock()
+	})
+	r := record(m)
+
+	// Set returns while the handler is still blocked
+	done := make(chan struct{})
+	go func() {
+		m.Set(PhaseChampSelect, SourceLCU)
+		m.Set(PhaseNone, SourceLCU)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Set blocked on a slow handler")
+	}
+
+	close(release)
+	r.next(t)
+	r.next(t)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 2 || seen[0] != PhaseChampSelect || seen[1] != PhaseNone {
+		t.Errorf("handler saw %v, want [ChampSelect None] in order", seen)
+	}
+}
+
+// fakeSource is an EventSource and PhaseReader driven by the test.
+type fakeSource struct {
+	mu        sync.Mutex
+	onConnect []func()
+	onPhase   func(string)
+
+	phase   string
+	err     error
+	reading chan struct{} // closed by the test to let a Get finish
+}
+
+func (f *fakeSource) OnConnect(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onConnect = append(f.onConnect, fn)
+}
+
+func (f *fakeSource) SubscribeGameflowPhase(fn func(string)) func() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onPhase = fn
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.onPhase = nil
+	}
+}
+
+func (f *fakeSource) Get(path string, out interface{}) error {
+	f.mu.Lock()
+	reading := f.reading
+	f.mu.Unlock()
+	if reading != nil {
+		<-reading
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	*out.(*string) = f.phase
+	return nil
+}
+
+// answer sets what the next Get returns and, if reading is non-nil, makes
+// it wait until reading is closed.
+func (f *fakeSource) answer(phase string, err error, reading chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.phase, f.err, f.reading = phase, err, reading
+}
+
+func (f *fakeSource) connect() {
+	f.mu.Lock()
+	handlers := append([]func(){}, f.onConnect...)
+	f.mu.Unlock()
+	for _, fn := range handlers {
+		fn()
+	}
+}
+
+func (f *fakeSource) event(phase string) {
+	f.mu.Lock()
+	fn := f.onPhase
+	f.mu.Unlock()
+	if fn != nil {
+		fn(phase)
+	}
+}
+
+func TestFollowLCU(t *testing.T) {
+	m := NewMachine()
+	r := record(m)
+	src := &fakeSource{}
+	src.answer("ChampSelect", nil, nil)
+	unsubscribe := m.FollowLCU(src, src)
+
+	// A connect reads the phase missed while disconnected
+	src.connect()
+	if tr := r.next(t); tr.To != PhaseChampSelect || tr.Source != SourceLCU {
+		t.Errorf("connect: transition to %s (%s)", tr.To, tr.Source)
+	}
+
+	src.event("InProgress")
+	if tr := r.next(t); tr.From != PhaseChampSelect || tr.To != PhaseInProgress {
+		t.Errorf("event: transition %s -> %s", tr.From, tr.To)
+	}
+
+	// A slow read is dropped when an event reports a newer phase first
+	reading := make(chan struct{})
+	src.answer("InProgress", nil, reading)
+	src.connect() // returns at once, the read waits
+	src.event("EndOfGame")
+	if tr := r.next(t); tr.To != PhaseEndOfGame {
+		t.Errorf("event during read: transition to %s", tr.To)
+	}
+	close(reading)
+	r.none(t)
+
+	// A failed read changes nothing
+	src.answer("Lobby", errors.New("not running"), nil)
+	src.connect()
+	r.none(t)
+
+	unsubscribe()
+	src.event("Lobby")
+	r.none(t)
+	if phase, _ := m.Phase(); phase != PhaseEndOfGame {
+		t.Errorf("Phase = %s, want EndOfGame", phase)
+	}
+}
+
+func TestWatchProcess(t *testing.T) {
+	m := NewMachine()
+	r := record(m)
+	var running, connected atomic.Bool
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		m.WatchProcess(ctx, running.Load, connected.Load, time.Millisecond)
+		close(stopped)
+	}()
+
+	running.Store(true)
+	if tr := r.next(t); tr.To != PhaseInProgress || tr.Source != SourceProcess {
+		t.Errorf("game started: transition to %s (%s)", tr.To, tr.Source)
+	}
+	running.Store(false)
+	if tr := r.next(t); tr.From != PhaseInProgress || tr.To != PhaseEndOfGame {
+		t.Errorf("game exited: transition %s -> %s", tr.From, tr.To)
+	}
+
+	// While the LCU stream is up the watcher leaves the phase alone
+	connected.Store(true)
+	time.Sleep(10 * time.Millisecond)
+	running.Store(true)
+	r.none(t)
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("WatchProcess did not stop with its context")
+	}
+}
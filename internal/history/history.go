@@ -0,0 +1,307 @@
+// Package history keeps a persistent log of skin applies and answers usage
+// and failure queries over it.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries is how many applies are kept before the oldest are dropped.
+const DefaultMaxEntries = 5000
+
+// Entry is one apply attempt.
+type Entry struct {
+	Time       time.Time     `json:"time"`
+	ChampionID string        `json:"championId"`
+	SkinID     string        `json:"skinId"`
+	BaseSkinID string        `json:"baseSkinId,omitempty"` // parent skin, set for chromas
+	SkinName   string        `json:"skinName,omitempty"`
+	ChromaName string        `json:"chromaName,omitempty"`
+	Queue      string        `json:"queue,omitempty"`     // LCU queue type, e.g. RANKED_SOLO_5x5
+	Teammates  []string      `json:"teammates,omitempty"` // room party teammate skin IDs
+	Success    bool          `json:"success"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Skin is the skin an entry counts towards: chromas count as their base skin.
+func (e Entry) Skin() string {
+	if e.BaseSkinID != "" {
+		return e.BaseSkinID
+	}
+	return e.SkinID
+}
+
+// record is the on-disk form of an Entry: one JSON line with short keys.
+type record struct {
+	T  int64    `json:"t"` // unix seconds
+	C  string   `json:"c"`
+	S  string   `json:"s"`
+	B  string   `json:"b,omitempty"`
+	N  string   `json:"n,omitempty"`
+	CN string   `json:"cn,omitempty"`
+	Q  string   `json:"q,omitempty"`
+	TM []string `json:"tm,omitempty"`
+	OK bool     `json:"ok,omitempty"`
+	E  string   `json:"e,omitempty"`
+	D  int64    `json:"d,omitempty"` // milliseconds
+}
+
+func toRecord(e Entry) record {
+	return record{
+		T: e.Time.Unix(), C: e.ChampionID, S: e.SkinID, B: e.BaseSkinID,
+		N: e.SkinName, CN: e.ChromaName, Q: e.Queue, TM: e.Teammates,
+		OK: e.Success, E: e.Error, D: e.Duration.Milliseconds(),
+	}
+}
+
+func (r record) entry() Entry {
+	return Entry{
+		Time: time.Unix(r.T, 0), ChampionID: r.C, SkinID: r.S, BaseSkinID: r.B,
+		SkinName: r.N, ChromaName: r.CN, Queue: r.Q, Teammates: r.TM,
+		Success: r.OK, Error: r.E, Duration: time.Duration(r.D) * time.Millisecond,
+	}
+}
+
+// Log is an append-only JSON Lines file of applies, mirrored in memory.
+type Log struct {
+	path string
+	max  int
+
+	mu      sync.Mutex
+	loaded  bool
+	entries []Entry // oldest first
+}
+
+// NewLog creates a log backed by path keeping at most max entries
+// (DefaultMaxEntries if max <= 0). The file is read lazily on first use.
+func NewLog(path string, max int) *Log {
+	if max <= 0 {
+		max = DefaultMaxEntries
+	}
+	return &Log{path: path, max: max}
+}
+
+// load reads the file once, skipping malformed lines. Caller must hold mu.
+func (l *Log) load() {
+	if l.loaded {
+		return
+	}
+	l.loaded = true
+	f, err := os.Open(l.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r record
+		if json.Unmarshal(scanner.Bytes(), &r) == nil && r.C != "" {
+			l.entries = append(l.entries, r.entry())
+		}
+	}
+	if len(l.entries) > l.max {
+		l.entries = l.entries[len(l.entries)-l.max:]
+	}
+}
+
+// Add appends an entry and persists it. Once the log grows a quarter past
+// its limit the file is rewritten with only the newest entries.
+func (l *Log) Add(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.load()
+	l.entries = append(l.entries, e)
+	if len(l.entries) > l.max+l.max/4 {
+		l.entries = append([]Entry(nil), l.entries[len(l.entries)-l.max:]...)
+		return l.rewrite()
+	}
+
+	line, err := json.Marshal(toRecord(e))
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(l.path), os.ModePerm)
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Import merges entries recorded elsewhere into the log in time order,
+// keeping only the newest entries past the limit, and rewrites the file.
+func (l *Log) Import(entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.load()
+	l.entries = append(l.entries, entries...)
+	sort.SliceStable(l.entries, func(i, j int) bool { return l.entries[i].Time.Before(l.entries[j].Time) })
+	if len(l.entries) > l.max {
+		l.entries = append([]Entry(nil), l.entries[len(l.entries)-l.max:]...)
+	}
+	return l.rewrite()
+}
+
+// rewrite replaces the file with the in-memory entries. Caller must hold mu.
+func (l *Log) rewrite() error {
+	os.MkdirAll(filepath.Dir(l.path), os.ModePerm)
+	tmp := l.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, e := range l.entries {
+		line, _ := json.Marshal(toRecord(e))
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+	return os.Rename(tmp, l.path)
+}
+
+// Clear forgets every entry and removes the file.
+func (l *Log) Clear() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loaded = true
+	l.entries = nil
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Entries returns entries matching keep, newest first, at most limit (all if
+// limit <= 0). A nil keep matches everything.
+func (l *Log) Entries(keep func(Entry) bool, limit int) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.load()
+	var out []Entry
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		e := l.entries[i]
+		if keep != nil && !keep(e) {
+			continue
+		}
+		out = append(out, e)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// Champion returns a champion's applies, newest first.
+func (l *Log) Champion(championID string, limit int) []Entry {
+	return l.Entries(func(e Entry) bool { return e.ChampionID == championID }, limit)
+}
+
+// SkinStats aggregates the applies of one skin (chromas included).
+type SkinStats struct {
+	ChampionID  string    `json:"championId"`
+	SkinID      string    `json:"skinId"`
+	SkinName    string    `json:"skinName,omitempty"`
+	Uses        int       `json:"uses"` // successful applies
+	Failures    int       `json:"failures"`
+	FailureRate float64   `json:"failureRate"` // failures / attempts
+	LastUsed    time.Time `json:"lastUsed"`    // last successful apply
+	LastFailed  time.Time `json:"lastFailed"`
+}
+
+// Stats aggregates applies per skin, optionally for one champion ("" for all).
+func (l *Log) Stats(championID string) []SkinStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.load()
+
+	byKey := make(map[string]*SkinStats)
+	var order []string
+	for _, e := range l.entries {
+		if championID != "" && e.ChampionID != championID {
+			continue
+		}
+		key := e.ChampionID + "/" + e.Skin()
+		s := byKey[key]
+		if s == nil {
+			s = &SkinStats{ChampionID: e.ChampionID, SkinID: e.Skin()}
+			byKey[key] = s
+			order = append(order, key)
+		}
+		if e.SkinName != "" {
+			s.SkinName = e.SkinName
+		}
+		if e.Success {
+			s.Uses++
+			s.LastUsed = e.Time
+		} else {
+			s.Failures++
+			s.LastFailed = e.Time
+		}
+	}
+
+	out := make([]SkinStats, 0, len(order))
+	for _, key := range order {
+		s := byKey[key]
+		s.FailureRate = float64(s.Failures) / float64(s.Uses+s.Failures)
+		out = append(out, *s)
+	}
+	return out
+}
+
+// MostUsed returns the skins with the most successful applies, optionally
+// for one champion ("" for all), at most limit (all if limit <= 0).
+func (l *Log) MostUsed(championID string, limit int) []SkinStats {
+	stats := l.Stats(championID)
+	out := stats[:0]
+	for _, s := range stats {
+		if s.Uses > 0 {
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Uses != out[j].Uses {
+			return out[i].Uses > out[j].Uses
+		}
+		return out[i].LastUsed.After(out[j].LastUsed)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// FailureRates returns skins with at least one failure and at least
+// minAttempts applies, most failing first.
+func (l *Log) FailureRates(minAttempts int) []SkinStats {
+	stats := l.Stats("")
+	out := stats[:0]
+	for _, s := range stats {
+		if s.Failures > 0 && s.Uses+s.Failures >= minAttempts {
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].FailureRate != out[j].FailureRate {
+			return out[i].FailureRate > out[j].FailureRate
+		}
+		return out[i].Failures > out[j].Failures
+	})
+	return out
+}
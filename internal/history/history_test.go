@@ -0,0 +1,165 @@
+package history
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func apply(champion, skin string, minute int, ok bool) Entry {
+	return Entry{Time: t0.Add(time.Duration(minute) * time.Minute), ChampionID: champion, SkinID: skin, Success: ok}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n := 0
+	for s := bufio.NewScanner(f); s.Scan(); {
+		n++
+	}
+	return n
+}
+
+func TestTrim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	l := NewLog(path, 8)
+
+	// The file grows past the limit until it is a quarter over, then shrinks
+	tests := []struct {
+		adds      int
+		fileLines int
+		kept      int
+	}{
+		{8, 8, 8},
+		{2, 10, 10}, // 10 = limit + limit/4, not trimmed yet
+		{1, 8, 8},   // 11 entries: rewritten with the newest 8
+		{2, 10, 10},
+	}
+	n := 0
+	for _, tt := range tests {
+		for i := 0; i < tt.adds; i++ {
+			if err := l.Add(apply("1", "1001", n, true)); err != nil {
+				t.Fatal(err)
+			}
+			n++
+		}
+		if got := countLines(t, path); got != tt.fileLines {
+			t.Errorf("after %d adds: file has %d lines, want %d", n, got, tt.fileLines)
+		}
+		entries := l.Entries(nil, 0)
+		if len(entries) != tt.kept {
+			t.Errorf("after %d adds: %d entries, want %d", n, len(entries), tt.kept)
+		}
+		if newest := entries[0].Time; !newest.Equal(t0.Add(time.Duration(n-1) * time.Minute)) {
+			t.Errorf("after %d adds: newest entry at %v", n, newest)
+		}
+	}
+
+	// Reloading an untrimmed file keeps only the newest entries
+	if got := len(NewLog(path, 8).Entries(nil, 0)); got != 8 {
+		t.Errorf("reloaded log has %d entries, want 8", got)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	want := Entry{
+		Time: t0, ChampionID: "103", SkinID: "103015", BaseSkinID: "103001",
+		SkinName: "Ahri", Queue: "ARAM", Teammates: []string{"1001"},
+		Error: "boom", Duration: 1500 * time.Millisecond,
+	}
+	l := NewLog(path, 0)
+	l.Add(want)
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("not json\n{\"t\":1}\n")
+	f.Close()
+
+	got := NewLog(path, 0).Entries(nil, 0)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want malformed lines skipped", len(got))
+	}
+	e := got[0]
+	if !e.Time.Equal(want.Time) || e.SkinID != want.SkinID || e.BaseSkinID != want.BaseSkinID ||
+		e.Queue != want.Queue || len(e.Teammates) != 1 || e.Error != want.Error || e.Duration != want.Duration {
+		t.Errorf("reloaded %+v, want %+v", e, want)
+	}
+
+	if err := l.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Clear left the file")
+	}
+}
+
+func TestStats(t *testing.T) {
+	l := NewLog(filepath.Join(t.TempDir(), "history.jsonl"), 0)
+	chroma := apply("1", "1002", 3, true)
+	chroma.BaseSkinID = "1001"
+	for _, e := range []Entry{
+		apply("1", "1001", 1, true),
+		apply("1", "1001", 2, false),
+		chroma,
+		apply("1", "1005", 4, true),
+		apply("2", "2001", 5, false),
+		apply("2", "2001", 6, false),
+	} {
+		l.Add(e)
+	}
+
+	most := l.MostUsed("", 0)
+	if len(most) != 2 || most[0].SkinID != "1001" || most[0].Uses != 2 || most[0].Failures != 1 {
+		t.Errorf("MostUsed = %+v, want 1001 first with the chroma counted", most)
+	}
+	if !most[0].LastUsed.Equal(chroma.Time) {
+		t.Errorf("LastUsed = %v, want the chroma apply", most[0].LastUsed)
+	}
+	if got := l.MostUsed("1", 1); len(got) != 1 || got[0].SkinID != "1001" {
+		t.Errorf("MostUsed limit 1 = %+v", got)
+	}
+
+	rates := l.FailureRates(2)
+	if len(rates) != 2 || rates[0].SkinID != "2001" || rates[0].FailureRate != 1 {
+		t.Errorf("FailureRates = %+v, want 2001 first at 100%%", rates)
+	}
+	if got := l.FailureRates(3); len(got) != 1 {
+		t.Errorf("FailureRates(3) = %+v, want only 1001", got)
+	}
+
+	if got := l.Champion("2", 0); len(got) != 2 || !got[0].Time.After(got[1].Time) {
+		t.Errorf("Champion = %+v, want newest first", got)
+	}
+}
+
+func TestImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	l := NewLog(path, 4)
+	l.Add(apply("1", "1001", 10, true))
+	l.Add(apply("1", "1001", 30, true))
+
+	if err := l.Import([]Entry{apply("1", "1002", 20, true), apply("1", "1003", 0, true), apply("1", "1004", 5, true)}); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range NewLog(path, 4).Entries(nil, 0) {
+		got = append(got, e.SkinID)
+	}
+	want := []string{"1001", "1002", "1001", "1004"} // merged by time, oldest dropped
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -18,34 +18,50 @@ var messages = map[string]map[string]string{
 		"display.label.skin":    "Skin",
 		"display.label.overlay": "Overlay",
 		"display.label.party":   "Party",
+		"display.label.game":    "Game",
 		"display.label.log":     "Log",
 
 		"display.value.waiting_client":       "Waiting for client",
 		"display.value.connected":            "Connected",
 		"display.value.none":                 "None",
+		"display.value.unknown":              "Unknown",
 		"display.value.overlay_active":       "Active",
 		"display.value.overlay_inactive":     "Inactive",
+		"display.value.overlay_waiting":      "Waiting for game",
+		"display.value.overlay_hooked":       "Hooked",
+		"display.value.overlay_restarting":   "Restarting",
+		"display.value.overlay_error":        "Error",
 		"display.value.party_off":            "Off",
 		"display.value.party_in_room_waiting": "In room (waiting)",
 		"display.value.party_in_room_teammates": "In room ({count} teammates)",
 		"display.value.party_active_users":     "Active ({count} Ame users)",
+		"display.value.party_reconnecting":     "Reconnecting (attempt {attempt})",
+		"display.value.party_failed":           "Connection failed",
 	},
 	"vi_VN": {
 		"display.label.status":  "Trạng thái",
 		"display.label.skin":    "Trang phục",
 		"display.label.overlay": "Lớp phủ",
 		"display.label.party":   "Nhóm",
+		"display.label.game":    "Phiên bản game",
 		"display.label.log":     "Nhật ký",
 
 		"display.value.waiting_client":       "Đang chờ client",
 		"display.value.connected":            "Đã kết nối",
 		"display.value.none":                 "Không có",
+		"display.value.unknown":              "Không rõ",
 		"display.value.overlay_active":       "Hoạt động",
 		"display.value.overlay_inactive":     "Tắt",
+		"display.value.overlay_waiting":      "Đang chờ trận",
+		"display.value.overlay_hooked":       "Đã gắn vào game",
+		"display.value.overlay_restarting":   "Đang khởi động lại",
+		"display.value.overlay_error":        "Lỗi",
 		"display.value.party_off":            "Tắt",
 		"display.value.party_in_room_waiting": "Trong phòng (đang chờ)",
 		"display.value.party_in_room_teammates": "Trong phòng ({count} đồng đội)",
 		"display.value.party_active_users":     "Hoạt động ({count} người dùng Ame)",
+		"display.value.party_reconnecting":     "Đang kết nối lại (lần {attempt})",
+		"display.value.party_failed":           "Kết nối thất bại",
 	},
 }
 
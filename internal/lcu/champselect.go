@@ -0,0 +1,73 @@
+package lcu
+
+// ChampSelectSession is the subset of /lol-champ-select/v1/session used by the backend.
+type ChampSelectSession struct {
+	GameID            int64                 `json:"gameId"`
+	LocalPlayerCellID int                   `json:"localPlayerCellId"`
+	IsSpectating      bool                  `json:"isSpectating"`
+	Actions           [][]ChampSelectAction `json:"actions"`
+	MyTeam            []ChampSelectPlayer   `json:"myTeam"`
+	TheirTeam         []ChampSelectPlayer   `json:"theirTeam"`
+	Bans              ChampSelectBans       `json:"bans"`
+	Timer             ChampSelectTimer      `json:"timer"`
+	BenchEnabled      bool                  `json:"benchEnabled"`
+	BenchChampions    []BenchChampion       `json:"benchChampions"`
+	ChatDetails       ChampSelectChat       `json:"chatDetails"`
+}
+
+// ChampSelectChat identifies the team's champ select chat room. Only players
+// in the champ select receive the password.
+type ChampSelectChat struct {
+	MultiUserChatID       string `json:"multiUserChatId"`
+	MultiUserChatPassword string `json:"multiUserChatPassword"`
+}
+
+// ChampSelectAction is one pick or ban turn.
+type ChampSelectAction struct {
+	ID           int    `json:"id"`
+	ActorCellID  int    `json:"actorCellId"`
+	ChampionID   int    `json:"championId"`
+	Type         string `json:"type"` // "pick", "ban", "ten_bans_reveal"
+	Completed    bool   `json:"completed"`
+	IsInProgress bool   `json:"isInProgress"`
+	IsAllyAction bool   `json:"isAllyAction"`
+}
+
+// ChampSelectPlayer is a player slot on either team.
+type ChampSelectPlayer struct {
+	CellID             int    `json:"cellId"`
+	ChampionID         int    `json:"championId"`
+	ChampionPickIntent int    `json:"championPickIntent"`
+	SelectedSkinID     int    `json:"selectedSkinId"`
+	AssignedPosition   string `json:"assignedPosition"` // "top", "jungle", "middle", "bottom", "utility" or ""
+	SummonerID         int64  `json:"summonerId"`
+	PUUID              string `json:"puuid"`
+	Team               int    `json:"team"`
+}
+
+// ChampSelectBans lists champions banned by each team.
+type ChampSelectBans struct {
+	MyTeamBans    []int `json:"myTeamBans"`
+	TheirTeamBans []int `json:"theirTeamBans"`
+}
+
+// ChampSelectTimer is the current champ select phase timer.
+type ChampSelectTimer struct {
+	Phase string `json:"phase"` // "PLANNING", "BAN_PICK", "FINALIZATION", "GAME_STARTING"
+}
+
+// BenchChampion is a champion on the ARAM bench.
+type BenchChampion struct {
+	ChampionID int  `json:"championId"`
+	IsPriority bool `json:"isPriority"`
+}
+
+// LocalPlayer returns the local player's slot, or nil if spectating.
+func (s *ChampSelectSession) LocalPlayer() *ChampSelectPlayer {
+	for i := range s.MyTeam {
+		if s.MyTeam[i].CellID == s.LocalPlayerCellID {
+			return &s.MyTeam[i]
+		}
+	}
+	return nil
+}
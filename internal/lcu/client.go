@@ -0,0 +1,303 @@
+package lcu
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/config"
+)
+
+// ErrNotRunning is returned when no League client can be found or reached.
+var ErrNotRunning = errors.New("league client is not running")
+
+// APIError is a non-2xx response from the LCU.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	ErrorCode  string // LCU errorCode, e.g. "RPC_ERROR"
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("LCU %s %s returned status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("LCU %s %s returned status %d", e.Method, e.Path, e.StatusCode)
+}
+
+// IsNotFound reports whether err is an LCU 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Credentials locate and authenticate against a running League client.
+type Credentials struct {
+	Port     string
+	Token    string
+	Protocol string // "https" unless the lockfile says otherwise
+}
+
+// BaseURL returns the client's REST root, e.g. https://127.0.0.1:54321.
+func (c Credentials) BaseURL() string {
+	return fmt.Sprintf("%s://127.0.0.1:%s", c.Protocol, c.Port)
+}
+
+// AuthHeader returns the Basic Authorization header value.
+func (c Credentials) AuthHeader() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte("riot:"+c.Token))
+}
+
+// Client talks to the League Client Update (LCU) REST API. Credentials come
+// from the lockfile in the install directory, falling back to the process
+// command line, and are cached until the lockfile changes or a request shows
+// the client has restarted.
+type Client struct {
+	lockfileDir func() string
+	commandLine func() string // LeagueClientUx.exe command line, "" if not running
+	http        *http.Client
+
+	mu       sync.Mutex
+	creds    *Credentials
+	lockMod  time.Time // mtime of the lockfile creds came from; zero if from the process
+	fromLock bool
+
+	skins skinCache
+}
+
+// NewClient creates a client. lockfileDir returns the League install
+// directory holding the lockfile, or "" if unknown.
+func NewClient(lockfileDir func() string) *Client {
+	return &Client{
+		lockfileDir: lockfileDir,
+		commandLine: processCommandLine,
+		http: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				// The LCU uses a self-signed certificate
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// defaultLockfileDir is the League root next to the saved Game directory.
+func defaultLockfileDir() string {
+	if dir := config.GamePath(); dir != "" {
+		return filepath.Dir(filepath.Clean(dir))
+	}
+	return ""
+}
+
+var defaultClient = NewClient(defaultLockfileDir)
+
+// Default returns the shared client for the local League installation.
+func Default() *Client {
+	return defaultClient
+}
+
+// Credentials returns the current client credentials, re-reading the
+// lockfile if it changed since the last call.
+func (c *Client) Credentials() (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if dir := c.lockfileDir(); dir != "" {
+		path := filepath.Join(dir, "lockfile")
+		if info, err := os.Stat(path); err == nil {
+			if c.creds != nil && c.fromLock && c.lockMod.Equal(info.ModTime()) {
+				return *c.creds, nil
+			}
+			if creds, err := readLockfile(path); err == nil {
+				c.creds = &creds
+				c.lockMod = info.ModTime()
+				c.fromLock = true
+				return creds, nil
+			}
+		}
+	}
+
+	if c.creds != nil && !c.fromLock {
+		return *c.creds, nil
+	}
+
+	creds, err := credentialsFromProcess(c.commandLine())
+	if err != nil {
+		c.creds = nil
+		return Credentials{}, err
+	}
+	c.creds = &creds
+	c.lockMod = time.Time{}
+	c.fromLock = false
+	return creds, nil
+}
+
+// Reset drops cached credentials so the next request rediscovers them.
+func (c *Client) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.creds = nil
+}
+
+// Get sends a GET and decodes the JSON response into out (which may be nil).
+func (c *Client) Get(path string, out interface{}) error {
+	return c.Do(http.MethodGet, path, nil, out)
+}
+
+// Post sends body as JSON and decodes the response into out.
+func (c *Client) Post(path string, body, out interface{}) error {
+	return c.Do(http.MethodPost, path, body, out)
+}
+
+// Put sends body as JSON and decodes the response into out.
+func (c *Client) Put(path string, body, out interface{}) error {
+	return c.Do(http.MethodPut, path, body, out)
+}
+
+// Patch sends body as JSON and decodes the response into out.
+func (c *Client) Patch(path string, body, out interface{}) error {
+	return c.Do(http.MethodPatch, path, body, out)
+}
+
+// Delete sends a DELETE and decodes the response into out.
+func (c *Client) Delete(path string, out interface{}) error {
+	return c.Do(http.MethodDelete, path, nil, out)
+}
+
+// Do sends a request with an optional JSON body and decodes the JSON
+// response into out. If the client cannot be reached or rejects the token,
+// credentials are rediscovered and the request is retried once, since that
+// usually means the client restarted.
+func (c *Client) Do(method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	creds, err := c.Credentials()
+	if err != nil {
+		return err
+	}
+
+	err = c.send(creds, method, path, payload, out)
+	if !shouldRetry(err) {
+		return err
+	}
+
+	c.Reset()
+	fresh, credErr := c.Credentials()
+	if credErr != nil {
+		return credErr
+	}
+	if fresh == creds {
+		return err
+	}
+	return c.send(fresh, method, path, payload, out)
+}
+
+// shouldRetry reports whether err suggests stale credentials.
+func shouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrNotRunning) {
+		return true
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// send performs one request with creds.
+func (c *Client) send(creds Credentials, method, path string, payload []byte, out interface{}) error {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, creds.BaseURL()+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", creds.AuthHeader())
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{Method: method, Path: path, StatusCode: resp.StatusCode}
+		var lcuErr struct {
+			ErrorCode string `json:"errorCode"`
+			Message   string `json:"message"`
+		}
+		if json.Unmarshal(data, &lcuErr) == nil {
+			apiErr.ErrorCode = lcuErr.ErrorCode
+			apiErr.Message = lcuErr.Message
+		}
+		return apiErr
+	}
+
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// readLockfile parses "LeagueClient:<pid>:<port>:<token>:<protocol>".
+func readLockfile(path string) (Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	parts := strings.Split(strings.TrimSpace(string(data)), ":")
+	if len(parts) < 5 || parts[2] == "" || parts[3] == "" {
+		return Credentials{}, fmt.Errorf("malformed lockfile")
+	}
+	return Credentials{Port: parts[2], Token: parts[3], Protocol: parts[4]}, nil
+}
+
+// credentialsFromProcess extracts the auth token and port from the
+// LeagueClientUx.exe command line.
+func credentialsFromProcess(line string) (Credentials, error) {
+	if line == "" {
+		return Credentials{}, ErrNotRunning
+	}
+
+	var creds Credentials
+	if m := tokenRe.FindStringSubmatch(line); len(m) > 1 {
+		creds.Token = strings.Trim(m[1], `"`)
+	}
+	if m := portRe.FindStringSubmatch(line); len(m) > 1 {
+		creds.Port = strings.Trim(m[1], `"`)
+	}
+	if creds.Token == "" || creds.Port == "" {
+		return Credentials{}, fmt.Errorf("could not find LCU credentials from process")
+	}
+	creds.Protocol = "https"
+	return creds, nil
+}
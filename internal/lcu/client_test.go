@@ -0,0 +1,276 @@
+package lcu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeLCU is a TLS server that only accepts requests authenticated with token.
+type fakeLCU struct {
+	*httptest.Server
+	token    atomic.Value // string
+	requests int32
+
+	mu     sync.Mutex
+	routes map[string]interface{} // GET path -> JSON response, set with respond
+	hits   map[string]int
+}
+
+// respond makes GET path return v as JSON.
+func (f *fakeLCU) respond(path string, v interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[path] = v
+}
+
+// hitCount returns how many authenticated requests reached path.
+func (f *fakeLCU) hitCount(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hits[path]
+}
+
+func newFakeLCU(t *testing.T, token string) *fakeLCU {
+	t.Helper()
+	f := &fakeLCU{routes: make(map[string]interface{}), hits: make(map[string]int)}
+	f.token.Store(token)
+	f.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&f.requests, 1)
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "riot" || pass != f.token.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		f.hits[r.URL.Path]++
+		route, ok := f.routes[r.URL.Path]
+		f.mu.Unlock()
+		if ok {
+			json.NewEncoder(w).Encode(route)
+			return
+		}
+		switch r.URL.Path {
+		case "/echo":
+			var body interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			json.NewEncoder(w).Encode(map[string]interface{}{"method": r.Method, "body": body})
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errorCode":"RESOURCE_NOT_FOUND","message":"Invalid URI format"}`)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeLCU) port() string {
+	u, _ := url.Parse(f.URL)
+	return u.Port()
+}
+
+func writeLockfile(t *testing.T, dir, port, token string) {
+	t.Helper()
+	data := fmt.Sprintf("LeagueClient:1234:%s:%s:https", port, token)
+	if err := os.WriteFile(filepath.Join(dir, "lockfile"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testClient returns a client reading the lockfile from dir and the command
+// line from *cmdline.
+func testClient(dir string, cmdline *string) *Client {
+	c := NewClient(func() string { return dir })
+	c.commandLine = func() string { return *cmdline }
+	return c
+}
+
+func TestCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		lockfile string // contents, "" for none
+		cmdline  string
+		want     Credentials
+		wantErr  error
+	}{
+		{
+			name:     "lockfile",
+			lockfile: "LeagueClient:1234:50000:lock-token:https",
+			cmdline:  "LeagueClientUx.exe --app-port=60000 --remoting-auth-token=proc-token",
+			want:     Credentials{Port: "50000", Token: "lock-token", Protocol: "https"},
+		},
+		{
+			name:     "malformed lockfile falls back to process",
+			lockfile: "LeagueClient:1234",
+			cmdline:  `LeagueClientUx.exe "--remoting-auth-token=proc-token" "--app-port=60000"`,
+			want:     Credentials{Port: "60000", Token: "proc-token", Protocol: "https"},
+		},
+		{
+			name:    "no lockfile falls back to process",
+			cmdline: "LeagueClientUx.exe --app-port=60000 --remoting-auth-token=proc-token",
+			want:    Credentials{Port: "60000", Token: "proc-token", Protocol: "https"},
+		},
+		{
+			name:    "nothing running",
+			wantErr: ErrNotRunning,
+		},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if tt.lockfile != "" {
+			os.WriteFile(filepath.Join(dir, "lockfile"), []byte(tt.lockfile), 0644)
+		}
+		cmdline := tt.cmdline
+		got, err := testClient(dir, &cmdline).Credentials()
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("%s: got %+v, %v; want %+v, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	incomplete := "LeagueClientUx.exe --app-port=60000"
+	if _, err := testClient("", &incomplete).Credentials(); err == nil || errors.Is(err, ErrNotRunning) {
+		t.Errorf("command line without token: got %v, want a credentials error", err)
+	}
+}
+
+func TestCredentialsCache(t *testing.T) {
+	dir := t.TempDir()
+	cmdline := "LeagueClientUx.exe --app-port=60000 --remoting-auth-token=first"
+	c := testClient(dir, &cmdline)
+
+	// Process credentials are cached while there is no lockfile
+	c.Credentials()
+	cmdline = "LeagueClientUx.exe --app-port=60000 --remoting-auth-token=second"
+	if got, _ := c.Credentials(); got.Token != "first" {
+		t.Errorf("process creds: got token %q, want cached %q", got.Token, "first")
+	}
+
+	// A lockfile takes over as soon as it appears, and is re-read when it changes
+	writeLockfile(t, dir, "50000", "lock-1")
+	if got, _ := c.Credentials(); got.Token != "lock-1" {
+		t.Errorf("new lockfile: got token %q, want %q", got.Token, "lock-1")
+	}
+	writeLockfile(t, dir, "50001", "lock-2")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "lockfile"), later, later)
+	if got, _ := c.Credentials(); got.Token != "lock-2" || got.Port != "50001" {
+		t.Errorf("changed lockfile: got %+v, want lock-2 on 50001", got)
+	}
+
+	// Once the lockfile is gone the process is asked again
+	os.Remove(filepath.Join(dir, "lockfile"))
+	if got, _ := c.Credentials(); got.Token != "second" {
+		t.Errorf("removed lockfile: got token %q, want %q", got.Token, "second")
+	}
+}
+
+func TestClientRequests(t *testing.T) {
+	srv := newFakeLCU(t, "secret")
+	dir := t.TempDir()
+	writeLockfile(t, dir, srv.port(), "secret")
+	cmdline := ""
+	c := testClient(dir, &cmdline)
+
+	var out struct {
+		Method string            `json:"method"`
+		Body   map[string]string `json:"body"`
+	}
+	methods := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{"GET", func() error { return c.Get("/echo", &out) }, http.MethodGet},
+		{"POST", func() error { return c.Post("/echo", map[string]string{"k": "v"}, &out) }, http.MethodPost},
+		{"PUT", func() error { return c.Put("/echo", map[string]string{"k": "v"}, &out) }, http.MethodPut},
+		{"PATCH", func() error { return c.Patch("/echo", map[string]string{"k": "v"}, &out) }, http.MethodPatch},
+		{"DELETE", func() error { return c.Delete("/echo", &out) }, http.MethodDelete},
+	}
+	for _, m := range methods {
+		out.Method, out.Body = "", nil
+		if err := m.call(); err != nil || out.Method != m.want {
+			t.Errorf("%s: got %q, %v", m.name, out.Method, err)
+		}
+		if m.want != http.MethodGet && m.want != http.MethodDelete && out.Body["k"] != "v" {
+			t.Errorf("%s: server got body %v", m.name, out.Body)
+		}
+	}
+
+	if err := c.Post("/empty", nil, &out); err != nil {
+		t.Errorf("empty response: %v", err)
+	}
+
+	err := c.Get("/missing", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !IsNotFound(err) || apiErr.ErrorCode != "RESOURCE_NOT_FOUND" || apiErr.Message != "Invalid URI format" {
+		t.Errorf("404: got %#v", err)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		serverAt string // token the server accepts
+		before   string // token in the cached lockfile
+		after    string // token in the lockfile once the client restarted
+		wantErr  int    // expected status, 0 for success
+		requests int32
+	}{
+		{"restarted client", "new", "old", "new", 0, 2},
+		{"token still rejected", "new", "old", "old", http.StatusUnauthorized, 1},
+		{"no restart needed", "old", "old", "old", 0, 1},
+	}
+	for _, tt := range tests {
+		srv := newFakeLCU(t, tt.serverAt)
+		dir := t.TempDir()
+		writeLockfile(t, dir, srv.port(), tt.before)
+		cmdline := ""
+		c := testClient(dir, &cmdline)
+		if _, err := c.Credentials(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Rewrite the lockfile without changing its mtime, so only the
+		// retry after a 401 can pick up the new token
+		path := filepath.Join(dir, "lockfile")
+		info, _ := os.Stat(path)
+		writeLockfile(t, dir, srv.port(), tt.after)
+		os.Chtimes(path, info.ModTime(), info.ModTime())
+
+		err := c.Get("/echo", nil)
+		var apiErr *APIError
+		switch {
+		case tt.wantErr == 0 && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantErr):
+			t.Errorf("%s: got %v, want status %d", tt.name, err, tt.wantErr)
+		}
+		if n := atomic.LoadInt32(&srv.requests); n != tt.requests {
+			t.Errorf("%s: server saw %d requests, want %d", tt.name, n, tt.requests)
+		}
+	}
+}
+
+func TestClientNotRunning(t *testing.T) {
+	srv := newFakeLCU(t, "secret")
+	dir := t.TempDir()
+	writeLockfile(t, dir, srv.port(), "secret")
+	cmdline := ""
+	c := testClient(dir, &cmdline)
+	srv.Close()
+
+	if err := c.Get("/echo", nil); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("closed server: got %v, want ErrNotRunning", err)
+	}
+}
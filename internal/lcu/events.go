@@ -0,0 +1,314 @@
+package lcu
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WAMP 1.0 message types used by the LCU WebSocket.
+const (
+	wampSubscribe   = 5
+	wampUnsubscribe = 6
+	wampEvent       = 8
+)
+
+// Endpoints with typed subscription helpers.
+const (
+	EndpointGameflowPhase      = "/lol-gameflow/v1/gameflow-phase"
+	EndpointChampSelectSession = "/lol-champ-select/v1/session"
+)
+
+const (
+	eventsMinBackoff = 1 * time.Second
+	eventsMaxBackoff = 30 * time.Second
+)
+
+// Event is one JSON API change pushed by the LCU.
+type Event struct {
+	URI       string          `json:"uri"`
+	EventType string          `json:"eventType"` // Create, Update or Delete
+	Data      json.RawMessage `json:"data"`
+}
+
+// eventName maps an endpoint to its WAMP topic,
+// e.g. /lol-gameflow/v1/gameflow-phase -> OnJsonApiEvent_lol-gameflow_v1_gameflow-phase.
+func eventName(endpoint string) string {
+	return "OnJsonApiEvent" + strings.ReplaceAll(endpoint, "/", "_")
+}
+
+type eventHandler struct {
+	fn func(Event)
+}
+
+// EventStream keeps a WebSocket open to the LCU, subscribes to endpoints and
+// fans their events out to Go handlers. It reconnects with exponential
+// backoff whenever the client goes away, re-subscribing every endpoint.
+type EventStream struct {
+	client     *Client
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu        sync.Mutex
+	writeMu   sync.Mutex
+	conn      *websocket.Conn
+	handlers  map[string][]*eventHandler // by WAMP topic
+	onConnect []func()
+}
+
+// NewEventStream creates an event stream using c's credentials.
+func NewEventStream(c *Client) *EventStream {
+	return &EventStream{
+		client:     c,
+		minBackoff: eventsMinBackoff,
+		maxBackoff: eventsMaxBackoff,
+		handlers:   make(map[string][]*eventHandler),
+	}
+}
+
+var defaultEvents = NewEventStream(defaultClient)
+
+// Events returns the shared event stream for the default client.
+func Events() *EventStream {
+	return defaultEvents
+}
+
+// Subscribe calls fn for every event on endpoint. Handlers run on the read
+// goroutine and should not block. The returned function unsubscribes.
+func (s *EventStream) Subscribe(endpoint string, fn func(Event)) func() {
+	topic := eventName(endpoint)
+	h := &eventHandler{fn: fn}
+
+	s.mu.Lock()
+	first := len(s.handlers[topic]) == 0
+	s.handlers[topic] = append(s.handlers[topic], h)
+	conn := s.conn
+	s.mu.Unlock()
+
+	if first && conn != nil {
+		s.send(conn, wampSubscribe, topic)
+	}
+
+	return func() {
+		s.mu.Lock()
+		list := s.handlers[topic]
+		for i, other := range list {
+			if other == h {
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		s.handlers[topic] = list
+		last := len(list) == 0
+		conn := s.conn
+		s.mu.Unlock()
+
+		if last && conn != nil {
+			s.send(conn, wampUnsubscribe, topic)
+		}
+	}
+}
+
+// OnConnect registers fn to run after every (re)connect, so subscribers can
+// fetch current state they may have missed while disconnected.
+func (s *EventStream) OnConnect(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onConnect = append(s.onConnect, fn)
+}
+
+// Connected reports whether the stream currently has a live connection.
+func (s *EventStream) Connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn != nil
+}
+
+// Run connects and dispatches events until ctx is cancelled, reconnecting
+// with backoff (1s doubling to 30s) after every failure.
+func (s *EventStream) Run(ctx context.Context) {
+	backoff := s.minBackoff
+	for {
+		connected, _ := s.runOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = s.minBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = nextBackoff(backoff, s.maxBackoff)
+	}
+}
+
+// nextBackoff doubles d, capped at max.
+func nextBackoff(d, max time.Duration) time.Duration {
+	d *= 2
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// runOnce holds one connection until it drops. Returns whether it connected.
+func (s *EventStream) runOnce(ctx context.Context) (bool, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		// Credentials may belong to a client that has since restarted
+		s.client.Reset()
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.conn = conn
+	topics := make([]string, 0, len(s.handlers))
+	for topic, list := range s.handlers {
+		if len(list) > 0 {
+			topics = append(topics, topic)
+		}
+	}
+	onConnect := append([]func(){}, s.onConnect...)
+	s.mu.Unlock()
+
+	// Close the connection when ctx is cancelled to unblock ReadMessage
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	for _, topic := range topics {
+		s.send(conn, wampSubscribe, topic)
+	}
+	for _, fn := range onConnect {
+		fn()
+	}
+
+	err = s.readLoop(conn)
+
+	s.mu.Lock()
+	s.conn = nil
+	s.mu.Unlock()
+	conn.Close()
+	return true, err
+}
+
+// dial opens the WebSocket with the current client credentials.
+func (s *EventStream) dial(ctx context.Context) (*websocket.Conn, error) {
+	creds, err := s.client.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	scheme := "wss"
+	if creds.Protocol == "http" {
+		scheme = "ws"
+	}
+
+	dialer := websocket.Dialer{
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: true},
+		HandshakeTimeout: 10 * time.Second,
+	}
+	header := http.Header{}
+	header.Set("Authorization", creds.AuthHeader())
+
+	conn, _, err := dialer.DialContext(ctx, scheme+"://127.0.0.1:"+creds.Port+"/", header)
+	return conn, err
+}
+
+// readLoop decodes WAMP event frames and dispatches them until the connection fails.
+func (s *EventStream) readLoop(conn *websocket.Conn) error {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if len(message) == 0 {
+			continue
+		}
+
+		var frame []json.RawMessage
+		if err := json.Unmarshal(message, &frame); err != nil || len(frame) < 3 {
+			continue
+		}
+		var kind int
+		var topic string
+		if json.Unmarshal(frame[0], &kind) != nil || kind != wampEvent {
+			continue
+		}
+		if json.Unmarshal(frame[1], &topic) != nil {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(frame[2], &ev); err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		list := append([]*eventHandler{}, s.handlers[topic]...)
+		s.mu.Unlock()
+		for _, h := range list {
+			h.fn(ev)
+		}
+	}
+}
+
+// send writes a WAMP [kind, topic] frame.
+func (s *EventStream) send(conn *websocket.Conn, kind int, topic string) error {
+	data, err := json.Marshal([]interface{}{kind, topic})
+	if err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if conn == nil {
+		return errors.New("not connected")
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// SubscribeGameflowPhase calls fn with the new phase (e.g. "ChampSelect",
+// "InProgress", "EndOfGame") whenever it changes.
+func (s *EventStream) SubscribeGameflowPhase(fn func(phase string)) func() {
+	return s.Subscribe(EndpointGameflowPhase, func(ev Event) {
+		var phase string
+		if ev.EventType == "Delete" || json.Unmarshal(ev.Data, &phase) != nil {
+			phase = "None"
+		}
+		fn(phase)
+	})
+}
+
+// SubscribeChampSelect calls fn with the champion select session on every
+// change, or nil when champion select ends.
+func (s *EventStream) SubscribeChampSelect(fn func(session *ChampSelectSession)) func() {
+	return s.Subscribe(EndpointChampSelectSession, func(ev Event) {
+		if ev.URI != EndpointChampSelectSession {
+			return
+		}
+		if ev.EventType == "Delete" {
+			fn(nil)
+			return
+		}
+		var session ChampSelectSession
+		if err := json.Unmarshal(ev.Data, &session); err != nil {
+			return
+		}
+		fn(&session)
+	})
+}
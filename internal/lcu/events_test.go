@@ -0,0 +1,247 @@
+package lcu
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeWAMP is a TLS WebSocket server speaking the LCU's WAMP 1.0 subset.
+type fakeWAMP struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	token   string
+	conn    *websocket.Conn
+	frames  chan []interface{} // frames received from the client
+	accepts int
+}
+
+func newFakeWAMP(t *testing.T, token string) *fakeWAMP {
+	t.Helper()
+	f := &fakeWAMP{token: token, frames: make(chan []interface{}, 64)}
+	upgrader := websocket.Upgrader{}
+	f.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		token := f.token
+		f.mu.Unlock()
+		if user, pass, ok := r.BasicAuth(); !ok || user != "riot" || pass != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conn = conn
+		f.accepts++
+		f.mu.Unlock()
+		for {
+			var frame []interface{}
+			if err := conn.ReadJSON(&frame); err != nil {
+				return
+			}
+			f.frames <- frame
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// push sends raw to the connected client.
+func (f *fakeWAMP) push(t *testing.T, raw string) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.conn.WriteMessage(websocket.TextMessage, []byte(raw)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// event pushes a WAMP event frame for endpoint.
+func (f *fakeWAMP) event(t *testing.T, endpoint, uri, eventType, data string) {
+	t.Helper()
+	f.push(t, fmt.Sprintf(`[8,%q,{"uri":%q,"eventType":%q,"data":%s}]`, eventName(endpoint), uri, eventType, data))
+}
+
+// drop closes the current connection from the server side.
+func (f *fakeWAMP) drop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.conn.Close()
+}
+
+// expect waits for the client to send [kind, topic] frames in any order.
+func (f *fakeWAMP) expect(t *testing.T, kind int, endpoints ...string) {
+	t.Helper()
+	want := make(map[string]bool)
+	for _, e := range endpoints {
+		want[eventName(e)] = true
+	}
+	for len(want) > 0 {
+		select {
+		case frame := <-f.frames:
+			if len(frame) != 2 || frame[0] != float64(kind) {
+				t.Fatalf("unexpected frame %v", frame)
+			}
+			topic, _ := frame[1].(string)
+			if !want[topic] {
+				t.Fatalf("unexpected frame %v", frame)
+			}
+			delete(want, topic)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %d frames for %v", kind, want)
+		}
+	}
+}
+
+func startEvents(t *testing.T, srv *fakeWAMP, token string) *EventStream {
+	t.Helper()
+	dir := t.TempDir()
+	u, _ := url.Parse(srv.URL)
+	writeLockfile(t, dir, u.Port(), token)
+	cmdline := ""
+	s := NewEventStream(testClient(dir, &cmdline))
+	s.minBackoff = 10 * time.Millisecond
+	s.maxBackoff = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return s
+}
+
+func TestEventName(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{EndpointGameflowPhase, "OnJsonApiEvent_lol-gameflow_v1_gameflow-phase"},
+		{EndpointChampSelectSession, "OnJsonApiEvent_lol-champ-select_v1_session"},
+		{"", "OnJsonApiEvent"},
+	}
+	for _, tt := range tests {
+		if got := eventName(tt.endpoint); got != tt.want {
+			t.Errorf("eventName(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	tests := []struct {
+		in, want time.Duration
+	}{
+		{eventsMinBackoff, 2 * time.Second},
+		{8 * time.Second, 16 * time.Second},
+		{16 * time.Second, eventsMaxBackoff},
+		{eventsMaxBackoff, eventsMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := nextBackoff(tt.in, eventsMaxBackoff); got != tt.want {
+			t.Errorf("nextBackoff(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	srv := newFakeWAMP(t, "secret")
+
+	phases := make(chan string, 8)
+	sessions := make(chan *ChampSelectSession, 8)
+	s := startEvents(t, srv, "secret")
+	s.SubscribeGameflowPhase(func(phase string) { phases <- phase })
+	unsubscribe := s.SubscribeChampSelect(func(session *ChampSelectSession) { sessions <- session })
+	srv.expect(t, wampSubscribe, EndpointGameflowPhase, EndpointChampSelectSession)
+
+	srv.push(t, `not json`)
+	srv.push(t, `[8]`)
+	srv.push(t, `[0,"session","1.0","server"]`)
+	srv.event(t, EndpointGameflowPhase, EndpointGameflowPhase, "Update", `"ChampSelect"`)
+	srv.event(t, EndpointChampSelectSession, EndpointChampSelectSession+"/timer", "Update", `{}`)
+	srv.event(t, EndpointChampSelectSession, EndpointChampSelectSession, "Update", `{"gameId":42,"localPlayerCellId":3}`)
+	srv.event(t, EndpointChampSelectSession, EndpointChampSelectSession, "Delete", `null`)
+	srv.event(t, EndpointGameflowPhase, EndpointGameflowPhase, "Delete", `null`)
+
+	for _, want := range []string{"ChampSelect", "None"} {
+		select {
+		case got := <-phases:
+			if got != want {
+				t.Errorf("phase = %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for phase %q", want)
+		}
+	}
+	for _, want := range []*ChampSelectSession{{GameID: 42, LocalPlayerCellID: 3}, nil} {
+		select {
+		case got := <-sessions:
+			if (got == nil) != (want == nil) || (got != nil && (got.GameID != want.GameID || got.LocalPlayerCellID != want.LocalPlayerCellID)) {
+				t.Errorf("session = %+v, want %+v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a session")
+		}
+	}
+	select {
+	case got := <-sessions:
+		t.Errorf("unexpected session %+v (sub-resource events should be ignored)", got)
+	default:
+	}
+
+	unsubscribe()
+	srv.expect(t, wampUnsubscribe, EndpointChampSelectSession)
+}
+
+func TestEventStreamReconnect(t *testing.T) {
+	srv := newFakeWAMP(t, "secret")
+	s := startEvents(t, srv, "secret")
+
+	connects := make(chan struct{}, 4)
+	s.OnConnect(func() { connects <- struct{}{} })
+	s.SubscribeGameflowPhase(func(string) {})
+	srv.expect(t, wampSubscribe, EndpointGameflowPhase)
+
+	srv.drop()
+	srv.expect(t, wampSubscribe, EndpointGameflowPhase)
+	select {
+	case <-connects:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnConnect not called after reconnecting")
+	}
+	if !s.Connected() {
+		t.Error("Connected() = false after reconnecting")
+	}
+}
+
+func TestEventStreamRejectedToken(t *testing.T) {
+	srv := newFakeWAMP(t, "other")
+	s := startEvents(t, srv, "secret")
+	s.SubscribeGameflowPhase(func(string) {})
+
+	time.Sleep(100 * time.Millisecond)
+	srv.mu.Lock()
+	accepts := srv.accepts
+	srv.token = "secret" // the client restarted with the token we hold
+	srv.mu.Unlock()
+	if accepts != 0 || s.Connected() {
+		t.Errorf("connected with a rejected token (%d accepts)", accepts)
+	}
+
+	// Dialing keeps retrying with backoff until the token is accepted
+	srv.expect(t, wampSubscribe, EndpointGameflowPhase)
+}
@@ -0,0 +1,17 @@
+package lcu
+
+// Friend is an entry of the logged-in player's friends list.
+type Friend struct {
+	PUUID    string `json:"puuid"`
+	GameName string `json:"gameName"`
+	GameTag  string `json:"gameTag"`
+}
+
+// Friends returns the logged-in player's friends list.
+func (c *Client) Friends() ([]Friend, error) {
+	var friends []Friend
+	if err := c.Get("/lol-chat/v1/friends", &friends); err != nil {
+		return nil, err
+	}
+	return friends, nil
+}
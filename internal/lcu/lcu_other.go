@@ -0,0 +1,29 @@
+//go:build !windows
+
+package lcu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// processCommandLine returns the LeagueClientUx.exe command line from /proc
+// (the client runs under Wine), or "" if it is not running.
+func processCommandLine() string {
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return ""
+	}
+	for _, p := range procs {
+		data, err := os.ReadFile(filepath.Join("/proc", p.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+		if len(args) > 0 && strings.HasSuffix(strings.ToLower(args[0]), "leagueclientux.exe") {
+			return strings.Join(args, " ")
+		}
+	}
+	return ""
+}
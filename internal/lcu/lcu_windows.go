@@ -3,6 +3,8 @@
 package lcu
 
 import (
+	"os/exec"
+	"strings"
 	"syscall"
 )
 
@@ -11,3 +13,26 @@ func getSysProcAttr() *syscall.SysProcAttr {
 		HideWindow: true,
 	}
 }
+
+// processCommandLine returns the LeagueClientUx.exe command line, or "" if
+// it is not running. wmic is tried first and PowerShell's CIM cmdlets are
+// used on Windows builds where wmic has been removed.
+func processCommandLine() string {
+	cmd := exec.Command("wmic", "process", "where", "name='LeagueClientUx.exe'", "get", "CommandLine", "/value")
+	cmd.SysProcAttr = getSysProcAttr()
+	if output, err := cmd.Output(); err == nil {
+		if line := strings.TrimSpace(string(output)); strings.Contains(line, "CommandLine=") {
+			return strings.TrimPrefix(line, "CommandLine=")
+		}
+		return ""
+	}
+
+	cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		`(Get-CimInstance Win32_Process -Filter "Name='LeagueClientUx.exe'" | Select-Object -First 1).CommandLine`)
+	cmd.SysProcAttr = getSysProcAttr()
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
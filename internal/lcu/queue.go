@@ -0,0 +1,24 @@
+package lcu
+
+// Queue is the queue of the current gameflow session.
+type Queue struct {
+	ID       int    `json:"id"`
+	GameMode string `json:"gameMode"` // e.g. "CLASSIC", "ARAM", "CHERRY" (Arena)
+	Type     string `json:"type"`     // e.g. "RANKED_SOLO_5x5", "ARAM_UNRANKED_5x5"
+	IsRanked bool   `json:"isRanked"`
+}
+
+type gameflowSession struct {
+	GameData struct {
+		Queue Queue `json:"queue"`
+	} `json:"gameData"`
+}
+
+// CurrentQueue returns the queue of the current lobby, champ select or game.
+func (c *Client) CurrentQueue() (Queue, error) {
+	var session gameflowSession
+	if err := c.Get("/lol-gameflow/v1/session", &session); err != nil {
+		return Queue{}, err
+	}
+	return session.GameData.Queue, nil
+}
@@ -0,0 +1,121 @@
+package lcu
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Ownership is the ownership block on LCU inventory items.
+type Ownership struct {
+	Owned bool `json:"owned"`
+}
+
+// Chroma is a chroma of a champion skin.
+type Chroma struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Disabled  bool      `json:"disabled"`
+	Ownership Ownership `json:"ownership"`
+}
+
+// Skin is a champion skin as listed in the player's inventory.
+type Skin struct {
+	ID         int       `json:"id"`
+	ChampionID int       `json:"championId"`
+	Name       string    `json:"name"`
+	IsBase     bool      `json:"isBase"`
+	Disabled   bool      `json:"disabled"`
+	Ownership  Ownership `json:"ownership"`
+	Chromas    []Chroma  `json:"chromas"`
+}
+
+// Summoner is the logged-in player.
+type Summoner struct {
+	SummonerID  int64  `json:"summonerId"`
+	PUUID       string `json:"puuid"`
+	GameName    string `json:"gameName"`
+	DisplayName string `json:"displayName"`
+}
+
+// skinCache holds inventory lookups for one logged-in summoner. It is
+// dropped whenever the client credentials change (restart or relog).
+type skinCache struct {
+	mu         sync.Mutex
+	token      string
+	summoner   *Summoner
+	byChampion map[int][]Skin
+}
+
+// useToken resets the cache if the credentials changed. Caller must hold mu.
+func (sc *skinCache) useToken(token string) {
+	if sc.token != token {
+		sc.token = token
+		sc.summoner = nil
+		sc.byChampion = nil
+	}
+}
+
+// CurrentSummoner returns the logged-in summoner, cached until the client restarts.
+func (c *Client) CurrentSummoner() (Summoner, error) {
+	creds, err := c.Credentials()
+	if err != nil {
+		return Summoner{}, err
+	}
+	sc := &c.skins
+	sc.mu.Lock()
+	sc.useToken(creds.Token)
+	if sc.summoner != nil {
+		s := *sc.summoner
+		sc.mu.Unlock()
+		return s, nil
+	}
+	sc.mu.Unlock()
+
+	var s Summoner
+	if err := c.Get("/lol-summoner/v1/current-summoner", &s); err != nil {
+		return Summoner{}, err
+	}
+
+	sc.mu.Lock()
+	sc.useToken(creds.Token)
+	sc.summoner = &s
+	sc.mu.Unlock()
+	return s, nil
+}
+
+// ChampionSkins returns every skin (with chromas and ownership) of a
+// champion for the logged-in summoner. Results are cached per summoner.
+func (c *Client) ChampionSkins(championID int) ([]Skin, error) {
+	summoner, err := c.CurrentSummoner()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := c.Credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &c.skins
+	sc.mu.Lock()
+	sc.useToken(creds.Token)
+	if skins, ok := sc.byChampion[championID]; ok {
+		sc.mu.Unlock()
+		return skins, nil
+	}
+	sc.mu.Unlock()
+
+	var skins []Skin
+	path := fmt.Sprintf("/lol-champions/v1/inventories/%d/champions/%d/skins", summoner.SummonerID, championID)
+	if err := c.Get(path, &skins); err != nil {
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	sc.useToken(creds.Token)
+	if sc.byChampion == nil {
+		sc.byChampion = make(map[int][]Skin)
+	}
+	sc.byChampion[championID] = skins
+	sc.mu.Unlock()
+	return skins, nil
+}
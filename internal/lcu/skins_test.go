@@ -0,0 +1,92 @@
+package lcu
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	summonerPath = "/lol-summoner/v1/current-summoner"
+	ahriSkins42  = "/lol-champions/v1/inventories/42/champions/103/skins"
+)
+
+func TestChampionSkins(t *testing.T) {
+	server := newFakeLCU(t, "token-1")
+	server.respond(summonerPath, Summoner{SummonerID: 42, GameName: "first"})
+	ahri := []Skin{
+		{ID: 103000, ChampionID: 103, Name: "Ahri", IsBase: true, Ownership: Ownership{Owned: true}},
+		{ID: 103001, ChampionID: 103, Name: "Dynasty Ahri", Chromas: []Chroma{{ID: 103017, Name: "Ruby", Ownership: Ownership{Owned: true}}}},
+	}
+	server.respond(ahriSkins42, ahri)
+	server.respond("/lol-champions/v1/inventories/42/champions/7/skins", []Skin{{ID: 7000, ChampionID: 7, IsBase: true}})
+
+	dir := t.TempDir()
+	writeLockfile(t, dir, server.port(), "token-1")
+	cmdline := ""
+	c := testClient(dir, &cmdline)
+
+	got, err := c.ChampionSkins(103)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, ahri) {
+		t.Errorf("ChampionSkins(103) = %+v, want %+v", got, ahri)
+	}
+
+	// Repeats are served from the cache; another champion only fetches its skins
+	c.ChampionSkins(103)
+	if _, err := c.ChampionSkins(7); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.hitCount(summonerPath); n != 1 {
+		t.Errorf("summoner fetched %d times, want 1", n)
+	}
+	if n := server.hitCount(ahriSkins42); n != 1 {
+		t.Errorf("Ahri skins fetched %d times, want 1", n)
+	}
+
+	// A relog (new credentials) drops the cache, including the summoner
+	server.respond(summonerPath, Summoner{SummonerID: 43, GameName: "second"})
+	server.respond("/lol-champions/v1/inventories/43/champions/103/skins", ahri[:1])
+	server.token.Store("token-2")
+	writeLockfile(t, dir, server.port(), "token-2")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "lockfile"), later, later)
+
+	if s, err := c.CurrentSummoner(); err != nil || s.SummonerID != 43 {
+		t.Errorf("CurrentSummoner after relog = %+v, %v; want summoner 43", s, err)
+	}
+	if got, err := c.ChampionSkins(103); err != nil || len(got) != 1 {
+		t.Errorf("ChampionSkins after relog = %+v, %v; want the new summoner's inventory", got, err)
+	}
+	if n := server.hitCount(ahriSkins42); n != 1 {
+		t.Errorf("old summoner's inventory refetched %d times", n-1)
+	}
+}
+
+func TestChampionSkinsError(t *testing.T) {
+	server := newFakeLCU(t, "token")
+	server.respond(summonerPath, Summoner{SummonerID: 42})
+	dir := t.TempDir()
+	writeLockfile(t, dir, server.port(), "token")
+	cmdline := ""
+	c := testClient(dir, &cmdline)
+
+	// A failed lookup is not cached
+	if _, err := c.ChampionSkins(103); err == nil {
+		t.Fatal("missing inventory: want error")
+	}
+	server.respond(ahriSkins42, []Skin{{ID: 103000, IsBase: true}})
+	if got, err := c.ChampionSkins(103); err != nil || len(got) != 1 {
+		t.Errorf("retry: got %+v, %v", got, err)
+	}
+
+	// Without a client nothing is fetched
+	empty := ""
+	if _, err := testClient(t.TempDir(), &empty).ChampionSkins(103); err == nil {
+		t.Error("client not running: want error")
+	}
+}
@@ -1,34 +1,29 @@
 package modtools
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/hoangvu12/ame/internal/config"
 )
 
-// overlayStatus tracks the latest status line from runoverlay stdout.
-var overlayStatus string
-var overlayStatusMu sync.Mutex
+// supervisor parses runoverlay output into events and restarts it on crash.
+var supervisor = NewSupervisor()
 
-func setOverlayStatus(s string) {
-	overlayStatusMu.Lock()
-	overlayStatus = s
-	overlayStatusMu.Unlock()
+// Overlay returns the runoverlay supervisor, used to subscribe to events
+// and configure automatic restarts.
+func Overlay() *Supervisor {
+	return supervisor
 }
 
 // IsHooked returns true if runoverlay has already installed the hook.
 func IsHooked() bool {
-	overlayStatusMu.Lock()
-	defer overlayStatusMu.Unlock()
-	return overlayStatus == "Waiting for exit"
+	return supervisor.Hooked()
 }
 
 // WaitForHook blocks until runoverlay reports the hook is installed
@@ -36,10 +31,7 @@ func IsHooked() bool {
 func WaitForHook(timeout time.Duration) bool {
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
-		overlayStatusMu.Lock()
-		status := overlayStatus
-		overlayStatusMu.Unlock()
-		if status == "Waiting for exit" {
+		if supervisor.Hooked() {
 			return true
 		}
 		time.Sleep(50 * time.Millisecond)
@@ -47,73 +39,84 @@ func WaitForHook(timeout time.Duration) bool {
 	return false
 }
 
-// Running process reference (like bocchi's this.runningProcess)
-var runningProcess *exec.Cmd
+// overlayProc is the running runoverlay process, if any. generation is bumped
+// by every RunOverlay and KillModTools so a pending automatic restart can tell
+// that it has been superseded.
+var (
+	overlayProc   *OverlayProcess
+	generation    int
+	overlayProcMu sync.Mutex
+)
 
-// KillModTools kills any running mod-tools processes
+// KillModTools stops the running runoverlay (gracefully, then forcibly) and
+// kills any other mod-tools processes
 func KillModTools() {
-	// First try to gracefully stop by writing to stdin (like bocchi)
-	if stdinPipe != nil {
-		stdinPipe.Write([]byte("\n"))
-		stdinPipe.Close()
-		stdinPipe = nil
-	}
-
-	// Give it a moment to exit gracefully
-	if runningProcess != nil && runningProcess.Process != nil {
-		// Wait a bit for graceful exit
-		done := make(chan struct{})
-		go func() {
-			runningProcess.Wait()
-			close(done)
-		}()
-
-		select {
-		case <-done:
-			// Process exited gracefully
-		case <-time.After(1 * time.Second):
-			// Force kill if still running
-			runningProcess.Process.Kill()
-		}
-		runningProcess = nil
+	overlayProcMu.Lock()
+	generation++
+	p := overlayProc
+	overlayProc = nil
+	overlayProcMu.Unlock()
+
+	if p != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		p.Stop(ctx)
+		cancel()
 	}
 
 	// Then force kill any remaining mod-tools
-	cmd := exec.Command("taskkill", "/F", "/IM", "mod-tools.exe")
-	cmd.SysProcAttr = getSysProcAttr()
-	cmd.Run()
+	killStrayModTools()
 }
 
-// RunMkOverlay runs mod-tools mkoverlay command
-func RunMkOverlay(modsDir, overlayDir, gameDir, modName string) (bool, int) {
-	modTools := filepath.Join(config.ToolsDir, "mod-tools.exe")
+// RunMkOverlay runs mod-tools mkoverlay command.
+// Conflicts between mods are resolved beforehand (see overlay.ResolveConflicts),
+// so modName must already be in load-priority order.
+// The process is killed when ctx is done; the returned error is then ctx.Err().
+// Otherwise a failed build returns the tool's exit code and a non-nil error.
+func RunMkOverlay(ctx context.Context, modsDir, overlayDir, gameDir, modName string) (int, error) {
+	return runMkOverlay(ctx, filepath.Join(config.ToolsDir, "mod-tools.exe"), modsDir, overlayDir, gameDir, modName)
+}
 
+// runMkOverlay runs mkoverlay using the mod-tools binary at modTools.
+func runMkOverlay(ctx context.Context, modTools, modsDir, overlayDir, gameDir, modName string) (int, error) {
 	if _, err := os.Stat(modTools); os.IsNotExist(err) {
-		return false, 1
+		return 1, fmt.Errorf("mod-tools.exe not found")
 	}
 
-	cmd := exec.Command(modTools, "mkoverlay", modsDir, overlayDir,
+	cmd := exec.CommandContext(ctx, modTools, "mkoverlay", modsDir, overlayDir,
 		fmt.Sprintf("--game:%s", gameDir),
 		fmt.Sprintf("--mods:%s", modName),
-		"--noTFT", "--ignoreConflict")
-	cmd.Dir = config.ToolsDir
+		"--noTFT")
+	cmd.Dir = filepath.Dir(modTools)
+	// Don't let a grandchild holding the output open keep us past cancellation
+	cmd.WaitDelay = 2 * time.Second
 
 	err := cmd.Run()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return -1, ctxErr
+	}
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return false, exitErr.ExitCode()
+			return exitErr.ExitCode(), err
 		}
-		return false, 1
+		return 1, err
 	}
 
-	return true, 0
+	return 0, nil
 }
 
-// stdinPipe holds the stdin writer for runoverlay (to keep it alive and stop gracefully)
-var stdinPipe io.WriteCloser
-
-// RunOverlay runs mod-tools runoverlay command (NOT detached, like bocchi)
+// RunOverlay runs mod-tools runoverlay command (NOT detached, like bocchi).
+// Every output line is reported as an Event through the supervisor.
 func RunOverlay(overlayDir, configPath, gameDir string) error {
+	overlayProcMu.Lock()
+	generation++
+	gen := generation
+	overlayProcMu.Unlock()
+	return startOverlay(overlayDir, configPath, gameDir, gen, 0)
+}
+
+// startOverlay starts runoverlay for generation gen; restarts counts previous
+// automatic restarts.
+func startOverlay(overlayDir, configPath, gameDir string, gen, restarts int) error {
 	modTools := filepath.Join(config.ToolsDir, "mod-tools.exe")
 
 	if _, err := os.Stat(modTools); os.IsNotExist(err) {
@@ -127,55 +130,73 @@ func RunOverlay(overlayDir, configPath, gameDir string) error {
 
 	cmd.Dir = config.ToolsDir
 
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdin pipe: %v", err)
-	}
-	stdinPipe = stdin
+	// Reset overlay status for this run
+	supervisor.reset()
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %v", err)
-	}
-	stderr, err := cmd.StderrPipe()
+	p, err := StartProcess(cmd, func(stream, line string) {
+		supervisor.emit(ParseLine(stream, line))
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %v", err)
+		return err
 	}
 
-	err = cmd.Start()
-	if err != nil {
-		return err
+	overlayProcMu.Lock()
+	if gen != generation {
+		// Superseded (killed or restarted) while starting
+		overlayProcMu.Unlock()
+		p.Stop(context.Background())
+		return nil
 	}
+	overlayProc = p
+	overlayProcMu.Unlock()
+
+	go monitorOverlay(p, overlayDir, configPath, gameDir, gen, restarts)
+	return nil
+}
 
-	runningProcess = cmd
+// monitorOverlay reports the exit of p and restarts runoverlay if it crashed
+// while the game is still running.
+func monitorOverlay(p *OverlayProcess, overlayDir, configPath, gameDir string, gen, restarts int) {
+	<-p.Done()
 
-	// Reset overlay status for this run
-	setOverlayStatus("")
-
-	// Read stdout lines and track runoverlay status
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.HasPrefix(line, "Status: ") {
-				setOverlayStatus(strings.TrimPrefix(line, "Status: "))
-			}
-		}
-	}()
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-		}
-	}()
+	overlayProcMu.Lock()
+	if overlayProc == p {
+		overlayProc = nil
+	}
+	current := gen == generation
+	overlayProcMu.Unlock()
 
-	// Monitor process exit
-	go func() {
-		cmd.Wait()
-		runningProcess = nil
-		stdinPipe = nil
-	}()
+	if p.Stopping() || !current {
+		supervisor.emit(Event{Kind: EventExited, Stream: "supervisor", Message: "stopped", Time: time.Now()})
+		return
+	}
 
-	return nil
+	msg := "exited"
+	if err := p.Err(); err != nil {
+		msg = fmt.Sprintf("exited unexpectedly: %v", err)
+	}
+	supervisor.emit(Event{Kind: EventExited, Stream: "supervisor", Message: msg, Time: time.Now()})
+
+	if restarts >= maxRestarts || !supervisor.shouldRestart() {
+		return
+	}
+	supervisor.emit(Event{
+		Kind:    EventRestarting,
+		Stream:  "supervisor",
+		Message: fmt.Sprintf("restarting runoverlay (attempt %d/%d)", restarts+1, maxRestarts),
+		Time:    time.Now(),
+	})
+	time.Sleep(restartDelay)
+
+	overlayProcMu.Lock()
+	current = gen == generation
+	overlayProcMu.Unlock()
+	if !current {
+		return
+	}
+	if err := startOverlay(overlayDir, configPath, gameDir, gen, restarts+1); err != nil {
+		supervisor.emit(Event{Kind: EventError, Stream: "supervisor", Message: err.Error(), Time: time.Now()})
+	}
 }
 
 // Exists checks if mod-tools.exe exists
@@ -187,5 +208,8 @@ func Exists() bool {
 
 // IsRunning checks if runoverlay is currently running
 func IsRunning() bool {
-	return runningProcess != nil && runningProcess.Process != nil
+	overlayProcMu.Lock()
+	p := overlayProc
+	overlayProcMu.Unlock()
+	return p != nil && p.Running()
 }
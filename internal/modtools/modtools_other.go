@@ -0,0 +1,20 @@
+//go:build !windows
+
+package modtools
+
+import (
+	"syscall"
+)
+
+// getSysProcAttr returns default process attributes on non-Windows platforms
+func getSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{}
+}
+
+// getDetachedSysProcAttr returns default process attributes on non-Windows platforms
+func getDetachedSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{}
+}
+
+// killStrayModTools is a no-op: mod-tools only runs on Windows
+func killStrayModTools() {}
@@ -0,0 +1,63 @@
+package modtools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The test binary stands in for mod-tools.exe, running the behaviour named
+// by AME_FAKE_CHILD (see TestMain).
+func TestRunMkOverlay(t *testing.T) {
+	t.Setenv("GORACE", "atexit_sleep_ms=0")
+
+	tests := []struct {
+		name      string
+		behaviour string
+		tool      string // "" for the test binary
+		timeout   time.Duration
+		cancel    time.Duration // cancel the build after this long, 0 for never
+		code      int
+		err       error // expected error, matched with errors.Is; nil for success
+		wantErr   bool
+	}{
+		{name: "success", behaviour: "mkoverlay", timeout: 10 * time.Second},
+		{name: "tool failure", behaviour: "fail", timeout: 10 * time.Second, code: 3, wantErr: true},
+		{name: "timeout", behaviour: "stubborn", timeout: 200 * time.Millisecond, code: -1, err: context.DeadlineExceeded},
+		{name: "cancel", behaviour: "stubborn", timeout: 10 * time.Second, cancel: 200 * time.Millisecond, code: -1, err: context.Canceled},
+		{name: "missing tool", tool: filepath.Join(t.TempDir(), "mod-tools.exe"), timeout: 10 * time.Second, code: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv("AME_FAKE_CHILD", tt.behaviour)
+		tool := tt.tool
+		if tool == "" {
+			tool = os.Args[0]
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+		if tt.cancel > 0 {
+			time.AfterFunc(tt.cancel, cancel)
+		}
+		begin := time.Now()
+		code, err := runMkOverlay(ctx, tool, "mods", "overlay", "game", "skin_1/global_ui")
+		elapsed := time.Since(begin)
+		cancel()
+
+		if code != tt.code {
+			t.Errorf("%s: exit code %d, want %d", tt.name, code, tt.code)
+		}
+		switch {
+		case tt.err != nil && !errors.Is(err, tt.err):
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		case tt.err == nil && (err != nil) != tt.wantErr:
+			t.Errorf("%s: got %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		// A killed build returns promptly, well before the stubborn child would exit
+		if tt.err != nil && elapsed > 5*time.Second {
+			t.Errorf("%s: returned after %v", tt.name, elapsed)
+		}
+	}
+}
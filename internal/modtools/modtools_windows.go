@@ -3,6 +3,7 @@
 package modtools
 
 import (
+	"os/exec"
 	"syscall"
 )
 
@@ -19,3 +20,11 @@ func getDetachedSysProcAttr() *syscall.SysProcAttr {
 		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
 	}
 }
+
+// killStrayModTools force kills every mod-tools.exe, including ones left
+// behind by a previous run
+func killStrayModTools() {
+	cmd := exec.Command("taskkill", "/F", "/IM", "mod-tools.exe")
+	cmd.SysProcAttr = getSysProcAttr()
+	cmd.Run()
+}
@@ -0,0 +1,185 @@
+package modtools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stopGracePeriod is how long Stop waits for a graceful exit before killing.
+const stopGracePeriod = 1 * time.Second
+
+// outputWaitDelay bounds how long the monitor waits for output after the
+// process exits, in case a grandchild inherited and still holds the pipes.
+const outputWaitDelay = 2 * time.Second
+
+// maxLineLength is the longest output line buffered before it is reported.
+const maxLineLength = 64 * 1024
+
+// OverlayProcess owns a running mod-tools child process: its command, stdin
+// pipe and exit state. All fields are guarded by mu, and the process is
+// waited on exactly once, by the monitor goroutine started in StartProcess.
+type OverlayProcess struct {
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	done     chan struct{}
+	err      error
+	exited   bool
+	stopping bool
+}
+
+// StartProcess starts cmd with piped stdin/stdout/stderr. Every non-empty
+// output line is passed to onLine with its stream name ("stdout" or "stderr").
+// onLine runs on output goroutines and may be nil.
+func StartProcess(cmd *exec.Cmd, onLine func(stream, line string)) (*OverlayProcess, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %v", err)
+	}
+	stdout := &lineWriter{stream: "stdout", onLine: onLine}
+	stderr := &lineWriter{stream: "stderr", onLine: onLine}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = outputWaitDelay
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &OverlayProcess{
+		cmd:   cmd,
+		stdin: stdin,
+		done:  make(chan struct{}),
+	}
+
+	// Monitor: the only caller of cmd.Wait. Wait returns once the output
+	// has been copied, or WaitDelay after exit if the pipes are held open.
+	go func() {
+		err := cmd.Wait()
+		if errors.Is(err, exec.ErrWaitDelay) {
+			err = nil // exited cleanly, only the output was cut short
+		}
+		stdout.flush()
+		stderr.flush()
+
+		p.mu.Lock()
+		p.err = err
+		p.exited = true
+		p.stdin = nil
+		p.mu.Unlock()
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+// lineWriter splits process output into lines and forwards non-empty ones
+// to onLine. exec.Cmd copies each stream from a single goroutine, so writes
+// never overlap.
+type lineWriter struct {
+	stream string
+	onLine func(stream, line string)
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > maxLineLength {
+		w.flush()
+	}
+	return len(p), nil
+}
+
+// flush reports any unterminated last line.
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+	}
+	w.buf = nil
+}
+
+func (w *lineWriter) emit(b []byte) {
+	line := strings.TrimSpace(string(b))
+	if line == "" || w.onLine == nil {
+		return
+	}
+	w.onLine(w.stream, line)
+}
+
+// Done returns a channel that is closed once the process has exited.
+func (p *OverlayProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the exit error once Done is closed (nil for a clean exit).
+func (p *OverlayProcess) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Running returns true until the process has exited.
+func (p *OverlayProcess) Running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.exited
+}
+
+// Stopping returns true once Stop has been called, so an exit can be told
+// apart from a crash.
+func (p *OverlayProcess) Stopping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopping
+}
+
+// Stop asks the process to exit by writing a newline to stdin (like bocchi),
+// then kills it if it is still running after the grace period or when ctx
+// is cancelled. It returns once the process has exited or ctx is done.
+func (p *OverlayProcess) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	p.stopping = true
+	stdin := p.stdin
+	p.stdin = nil
+	p.mu.Unlock()
+
+	if stdin != nil {
+		stdin.Write([]byte("\n"))
+		stdin.Close()
+	}
+
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(stopGracePeriod):
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	if !p.exited && p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
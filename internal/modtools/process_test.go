@@ -0,0 +1,194 @@
+package modtools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The test binary doubles as the fake mod-tools child: with AME_FAKE_CHILD
+// set, TestMain runs the named behaviour instead of the tests.
+func TestMain(m *testing.M) {
+	switch os.Getenv("AME_FAKE_CHILD") {
+	case "":
+		os.Exit(m.Run())
+	case "echo":
+		// Print to both streams, then exit on the first stdin line
+		fmt.Println("Status: Waiting for league")
+		fmt.Fprintln(os.Stderr, "warning: something")
+		fmt.Print("\n   \nno newline")
+		bufio.NewReader(os.Stdin).ReadString('\n')
+	case "stubborn":
+		// Ignore stdin and run until killed
+		fmt.Println("ready")
+		time.Sleep(time.Minute)
+	case "orphan":
+		// Leave a grandchild holding stdout open, then exit
+		cmd := fakeChild("sleep")
+		cmd.Stdout = os.Stdout
+		cmd.Start()
+		fmt.Println("orphaned")
+	case "sleep":
+		time.Sleep(5 * time.Second)
+	case "fail":
+		os.Exit(3)
+	case "mkoverlay":
+		// Succeed only for the command line runMkOverlay should build
+		want := "mkoverlay mods overlay --game:game --mods:skin_1/global_ui --noTFT"
+		if strings.Join(os.Args[1:], " ") != want {
+			os.Exit(4)
+		}
+	}
+	os.Exit(0)
+}
+
+func fakeChild(behaviour string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0])
+	// The race runtime otherwise lingers for a second after exit
+	cmd.Env = append(os.Environ(), "AME_FAKE_CHILD="+behaviour, "GORACE=atexit_sleep_ms=0")
+	return cmd
+}
+
+// recorder collects output lines from the reader goroutines.
+type recorder struct {
+	mu    sync.Mutex
+	lines []string
+	ready chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{ready: make(chan struct{}, 16)}
+}
+
+func (r *recorder) onLine(stream, line string) {
+	r.mu.Lock()
+	r.lines = append(r.lines, stream+": "+line)
+	r.mu.Unlock()
+	r.ready <- struct{}{}
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+func start(t *testing.T, behaviour string, rec *recorder) *OverlayProcess {
+	t.Helper()
+	p, err := StartProcess(fakeChild(behaviour), rec.onLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func waitDone(t *testing.T, p *OverlayProcess, timeout time.Duration) {
+	t.Helper()
+	select {
+	case <-p.Done():
+	case <-time.After(timeout):
+		t.Fatalf("process still running after %v", timeout)
+	}
+}
+
+func TestProcessStopGraceful(t *testing.T) {
+	rec := newRecorder()
+	p := start(t, "echo", rec)
+	<-rec.ready
+	if !p.Running() || p.Stopping() {
+		t.Fatal("want running and not stopping before Stop")
+	}
+
+	begin := time.Now()
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed >= stopGracePeriod {
+		t.Errorf("graceful stop took %v, want it before the kill", elapsed)
+	}
+	if p.Running() || !p.Stopping() || p.Err() != nil {
+		t.Errorf("after Stop: running=%v stopping=%v err=%v", p.Running(), p.Stopping(), p.Err())
+	}
+
+	got := strings.Join(rec.get(), "\n")
+	for _, want := range []string{"stdout: Status: Waiting for league", "stderr: warning: something", "stdout: no newline"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %q missing %q", got, want)
+		}
+	}
+	if n := len(rec.get()); n != 3 {
+		t.Errorf("got %d lines, want 3 (blank lines dropped)", n)
+	}
+}
+
+func TestProcessStopKills(t *testing.T) {
+	rec := newRecorder()
+	p := start(t, "stubborn", rec)
+	<-rec.ready
+
+	begin := time.Now()
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed < stopGracePeriod {
+		t.Errorf("killed after %v, want the grace period first", elapsed)
+	}
+	if p.Err() == nil {
+		t.Error("killed process: want exit error")
+	}
+}
+
+func TestProcessStopContext(t *testing.T) {
+	rec := newRecorder()
+	p := start(t, "stubborn", rec)
+	<-rec.ready
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.Stop(ctx) // kills right away instead of waiting out the grace period
+	waitDone(t, p, stopGracePeriod)
+}
+
+func TestProcessOrphanedOutput(t *testing.T) {
+	rec := newRecorder()
+	p := start(t, "orphan", rec)
+	waitDone(t, p, outputWaitDelay+2*time.Second)
+	if p.Err() != nil {
+		t.Errorf("clean exit reported %v", p.Err())
+	}
+	if got := rec.get(); len(got) != 1 || got[0] != "stdout: orphaned" {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func TestProcessExitCode(t *testing.T) {
+	p := start(t, "fail", newRecorder())
+	waitDone(t, p, 5*time.Second)
+	exitErr, ok := p.Err().(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("Err = %v, want exit status 3", p.Err())
+	}
+	// Stop after exit returns immediately
+	if err := p.Stop(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var got []string
+	w := &lineWriter{stream: "stdout", onLine: func(stream, line string) { got = append(got, line) }}
+	for _, chunk := range []string{"Sta", "tus: a\r\n", "\n  \nb\nc", strings.Repeat("x", maxLineLength)} {
+		w.Write([]byte(chunk))
+	}
+	w.flush()
+	want := []string{"Status: a", "b", "c" + strings.Repeat("x", maxLineLength)}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %d lines %.40q, want %.40q", len(got), got, want)
+	}
+}
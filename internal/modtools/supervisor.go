@@ -0,0 +1,177 @@
+package modtools
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventKind classifies a line of runoverlay output.
+type EventKind string
+
+const (
+	EventWaiting     EventKind = "waiting"      // waiting for the game process to start
+	EventFoundGame   EventKind = "found_game"   // game process found, preparing to hook
+	EventHooked      EventKind = "hooked"       // hook installed into the game
+	EventWaitingExit EventKind = "waiting_exit" // hooked and waiting for the game to close
+	EventStatus      EventKind = "status"       // any other status line
+	EventLog         EventKind = "log"          // unclassified output
+	EventError       EventKind = "error"        // error reported by runoverlay
+	EventExited      EventKind = "exited"       // runoverlay process exited
+	EventRestarting  EventKind = "restarting"   // supervisor is restarting a crashed runoverlay
+)
+
+const maxEventLog = 200
+
+// maxRestarts caps automatic restarts for a single RunOverlay call.
+const maxRestarts = 3
+
+const restartDelay = 2 * time.Second
+
+// Event is a single structured runoverlay event.
+type Event struct {
+	Kind    EventKind `json:"kind"`
+	Stream  string    `json:"stream"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// ParseLine classifies a line of runoverlay output from stream ("stdout" or "stderr").
+// The stream alone does not make an error: runoverlay also writes warnings
+// and progress to stderr, so lines are classified by their content.
+func ParseLine(stream, line string) Event {
+	ev := Event{Kind: EventLog, Stream: stream, Message: line, Time: time.Now()}
+	lower := strings.ToLower(line)
+
+	if strings.HasPrefix(line, "Status: ") {
+		status := strings.TrimPrefix(line, "Status: ")
+		ev.Message = status
+		s := strings.ToLower(status)
+		switch {
+		case s == "waiting for exit":
+			ev.Kind = EventWaitingExit
+		case strings.HasPrefix(s, "waiting for league"), strings.HasPrefix(s, "waiting for game"):
+			ev.Kind = EventWaiting
+		case strings.HasPrefix(s, "found"):
+			ev.Kind = EventFoundGame
+		case strings.Contains(s, "patched"), strings.Contains(s, "hooked"):
+			ev.Kind = EventHooked
+		default:
+			ev.Kind = EventStatus
+		}
+		return ev
+	}
+
+	for _, marker := range errorMarkers {
+		if strings.Contains(lower, marker) {
+			ev.Kind = EventError
+			break
+		}
+	}
+	if strings.HasPrefix(lower, "error") {
+		ev.Kind = EventError
+	}
+	return ev
+}
+
+// errorMarkers are lowercase substrings that mark a runoverlay line as an error.
+var errorMarkers = []string{"[error]", "error:", "failed", "fatal", "exception"}
+
+// Supervisor tracks runoverlay output as structured events, keeps a bounded
+// log of them and can restart runoverlay when it exits while the game is
+// still running.
+type Supervisor struct {
+	mu          sync.Mutex
+	status      string
+	hooked      bool
+	events      []Event
+	onEvent     func(Event)
+	autoRestart bool
+	gameRunning func() bool
+}
+
+// NewSupervisor creates an empty supervisor.
+func NewSupervisor() *Supervisor {
+	return &Supervisor{}
+}
+
+// SetEventHandler registers fn to receive every event. fn runs on the
+// output reader goroutines and must not block.
+func (s *Supervisor) SetEventHandler(fn func(Event)) {
+	s.mu.Lock()
+	s.onEvent = fn
+	s.mu.Unlock()
+}
+
+// SetAutoRestart enables restarting a runoverlay that exits unexpectedly
+// while gameRunning reports the game is still up.
+func (s *Supervisor) SetAutoRestart(enabled bool, gameRunning func() bool) {
+	s.mu.Lock()
+	s.autoRestart = enabled
+	s.gameRunning = gameRunning
+	s.mu.Unlock()
+}
+
+// Events returns a copy of the bounded event log, oldest first.
+func (s *Supervisor) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := make([]Event, len(s.events))
+	copy(cp, s.events)
+	return cp
+}
+
+// Status returns the latest runoverlay status line.
+func (s *Supervisor) Status() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Hooked returns true once runoverlay reported the hook is installed.
+func (s *Supervisor) Hooked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hooked
+}
+
+// reset clears the per-run status before a new runoverlay starts.
+func (s *Supervisor) reset() {
+	s.mu.Lock()
+	s.status = ""
+	s.hooked = false
+	s.mu.Unlock()
+}
+
+// shouldRestart reports whether a crashed runoverlay should be restarted.
+func (s *Supervisor) shouldRestart() bool {
+	s.mu.Lock()
+	enabled, gameRunning := s.autoRestart, s.gameRunning
+	s.mu.Unlock()
+	return enabled && gameRunning != nil && gameRunning()
+}
+
+// emit records ev and forwards it to the event handler.
+func (s *Supervisor) emit(ev Event) {
+	s.mu.Lock()
+	switch ev.Kind {
+	case EventWaiting, EventFoundGame, EventHooked, EventWaitingExit, EventStatus:
+		s.status = ev.Message
+	}
+	switch ev.Kind {
+	case EventWaitingExit:
+		s.hooked = true
+	case EventExited:
+		s.hooked = false
+	}
+	s.events = append(s.events, ev)
+	if len(s.events) > maxEventLog {
+		s.events = s.events[len(s.events)-maxEventLog:]
+	}
+	fn := s.onEvent
+	s.mu.Unlock()
+
+	if fn != nil {
+		fn(ev)
+	}
+}
@@ -0,0 +1,32 @@
+package modtools
+
+import "testing"
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		stream string
+		line   string
+		kind   EventKind
+		msg    string
+	}{
+		{"stdout", "Status: Waiting for league", EventWaiting, "Waiting for league"},
+		{"stdout", "Status: Found League", EventFoundGame, "Found League"},
+		{"stdout", "Status: Hooked", EventHooked, "Hooked"},
+		{"stdout", "Status: Waiting for exit", EventWaitingExit, "Waiting for exit"},
+		{"stdout", "Status: Scanning", EventStatus, "Scanning"},
+		{"stdout", "loading profile", EventLog, "loading profile"},
+		{"stdout", "Error: bad wad", EventError, "Error: bad wad"},
+		{"stderr", "warning: something", EventLog, "warning: something"},
+		{"stderr", "progress 50%", EventLog, "progress 50%"},
+		{"stderr", "[ERROR] hook failed", EventError, "[ERROR] hook failed"},
+		{"stderr", "Failed to open process", EventError, "Failed to open process"},
+		{"stderr", "terminate called after throwing an exception", EventError, "terminate called after throwing an exception"},
+		{"stderr", "Fatal: out of memory", EventError, "Fatal: out of memory"},
+	}
+	for _, tt := range tests {
+		ev := ParseLine(tt.stream, tt.line)
+		if ev.Kind != tt.kind || ev.Message != tt.msg || ev.Stream != tt.stream {
+			t.Errorf("ParseLine(%q, %q) = %s %q, want %s %q", tt.stream, tt.line, ev.Kind, ev.Message, tt.kind, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package overlay
+
+import (
+	"context"
+
+	"github.com/hoangvu12/ame/internal/modtools"
+)
+
+// Overlay builder backends, selectable in settings.
+const (
+	BackendModTools = "modtools"
+	BackendNative   = "native"
+)
+
+// Builder turns a set of extracted mods into an overlay directory that
+// runoverlay can hook into the game.
+type Builder interface {
+	// Name returns the backend identifier.
+	Name() string
+	// Build writes the overlay for mods (in priority order, highest first)
+	// into overlayDir, using the game's base WADs from gameDir. It stops
+	// when ctx is done. Errors are *BuildError.
+	Build(ctx context.Context, modsDir, overlayDir, gameDir string, mods []Mod) error
+}
+
+// NewBuilder returns the builder for backend, defaulting to mod-tools.
+func NewBuilder(backend string) Builder {
+	if backend == BackendNative {
+		return NativeBuilder{}
+	}
+	return ModToolsBuilder{}
+}
+
+// ValidBackend reports whether backend names a known builder.
+func ValidBackend(backend string) bool {
+	return backend == BackendModTools || backend == BackendNative
+}
+
+// ModToolsBuilder builds overlays with mod-tools.exe mkoverlay.
+type ModToolsBuilder struct{}
+
+// Name returns the backend identifier.
+func (ModToolsBuilder) Name() string { return BackendModTools }
+
+// Build runs mkoverlay with the mods in load-priority order.
+func (ModToolsBuilder) Build(ctx context.Context, modsDir, overlayDir, gameDir string, mods []Mod) error {
+	exitCode, err := modtools.RunMkOverlay(ctx, modsDir, overlayDir, gameDir, Names(mods))
+	if err != nil {
+		return newBuildError(BackendModTools, exitCode, err)
+	}
+	return nil
+}
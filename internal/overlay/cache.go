@@ -0,0 +1,315 @@
+package overlay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the number of built overlays kept when no limit is configured.
+const DefaultCacheSize = 5
+
+const cacheIndexFile = "index.json"
+
+// replacedSuffix marks an active overlay moved aside by Store, removed once
+// runoverlay no longer holds its files.
+const replacedSuffix = ".replaced"
+
+// CacheEntry describes one built overlay in the cache.
+type CacheEntry struct {
+	Key      string    `json:"key"`
+	ModKey   string    `json:"modKey"`
+	LastUsed time.Time `json:"lastUsed"`
+	Stale    bool      `json:"stale,omitempty"` // built for an older game version, kept only while in use
+}
+
+type cacheIndex struct {
+	GameVersion string       `json:"gameVersion"`
+	Entries     []CacheEntry `json:"entries"`
+}
+
+// Cache keeps recently built overlays on disk, keyed by the content of the
+// mods they contain and the game version they were built against. The least
+// recently used entries are evicted once the limit is exceeded, and every
+// entry is dropped when the game version changes.
+type Cache struct {
+	mu     sync.Mutex
+	dir    string
+	limit  int
+	active string
+	index  cacheIndex
+	loaded bool
+}
+
+// NewCache creates a cache rooted at dir holding at most limit overlays.
+func NewCache(dir string, limit int) *Cache {
+	return &Cache{dir: dir, limit: limit}
+}
+
+// CacheKey derives a cache key from mod content hashes (order-independent),
+// the game version and any build options that change the output.
+func CacheKey(modHashes []string, gameVersion string, opts ...string) string {
+	sorted := make([]string, len(modHashes))
+	copy(sorted, modHashes)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	io.WriteString(h, strings.Join(sorted, ","))
+	io.WriteString(h, "|"+gameVersion)
+	io.WriteString(h, "|"+strings.Join(opts, ","))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// HashFile returns the SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SetLimit changes the maximum number of cached overlays.
+func (c *Cache) SetLimit(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+	c.load()
+	c.evict()
+	c.save()
+}
+
+// SetGameVersion records the installed game version. If it differs from the
+// version the cached overlays were built against, every entry is removed.
+// The active overlay is kept as a stale entry until it is no longer in use.
+// Returns true if the cache was invalidated.
+func (c *Cache) SetGameVersion(version string) bool {
+	if version == "" {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	if c.index.GameVersion == version {
+		return false
+	}
+	invalidated := len(c.index.Entries) > 0
+	var kept []CacheEntry
+	for _, e := range c.index.Entries {
+		if e.Key == c.active {
+			e.Stale = true
+			kept = append(kept, e)
+			continue
+		}
+		os.RemoveAll(filepath.Join(c.dir, e.Key))
+	}
+	c.index = cacheIndex{GameVersion: version, Entries: kept}
+	c.save()
+	return invalidated
+}
+
+// Lookup returns the overlay directory for key if it is cached.
+func (c *Cache) Lookup(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	for i, e := range c.index.Entries {
+		if e.Key != key || e.Stale {
+			continue
+		}
+		dir := filepath.Join(c.dir, key)
+		if !hasWad(dir) {
+			c.index.Entries = append(c.index.Entries[:i], c.index.Entries[i+1:]...)
+			os.RemoveAll(dir)
+			c.save()
+			return "", false
+		}
+		c.index.Entries[i].LastUsed = time.Now()
+		c.save()
+		return dir, true
+	}
+	return "", false
+}
+
+// Store moves a freshly built overlay from srcDir into the cache under key
+// and returns its new location. Older entries are evicted beyond the limit.
+func (c *Cache) Store(key, modKey, srcDir string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(c.dir, key)
+	if _, err := os.Stat(dest); err == nil && key == c.active {
+		// runoverlay may hold files in the active copy, so move it aside
+		// instead of deleting it. If even that fails, the active copy was
+		// built from the same key and stays in place.
+		aside := dest + replacedSuffix
+		os.RemoveAll(aside)
+		if err := os.Rename(dest, aside); err != nil {
+			os.RemoveAll(srcDir)
+			return c.touch(key, modKey, dest), nil
+		}
+		os.RemoveAll(aside)
+	} else {
+		os.RemoveAll(dest)
+	}
+	if err := os.Rename(srcDir, dest); err != nil {
+		return "", fmt.Errorf("failed to move overlay into cache: %w", err)
+	}
+	return c.touch(key, modKey, dest), nil
+}
+
+// touch records key as the most recently used entry, evicts beyond the limit
+// and returns dir. Caller must hold mu.
+func (c *Cache) touch(key, modKey, dir string) string {
+	entries := c.index.Entries[:0]
+	for _, e := range c.index.Entries {
+		if e.Key != key {
+			entries = append(entries, e)
+		}
+	}
+	c.index.Entries = append(entries, CacheEntry{Key: key, ModKey: modKey, LastUsed: time.Now()})
+	c.dropStale()
+	c.evict()
+	c.save()
+	return dir
+}
+
+// SetActive marks key as the overlay runoverlay is using, so it is never
+// evicted while in use. Pass "" when no overlay is running. A stale overlay
+// that stops being active is removed.
+func (c *Cache) SetActive(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active = key
+	c.load()
+	if c.dropStale() {
+		c.save()
+	}
+}
+
+// Entries returns the cached overlays, most recently used first.
+func (c *Cache) Entries() []CacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	cp := make([]CacheEntry, len(c.index.Entries))
+	copy(cp, c.index.Entries)
+	sort.Slice(cp, func(i, j int) bool { return cp[i].LastUsed.After(cp[j].LastUsed) })
+	return cp
+}
+
+// Clear removes every cached overlay except the active one.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	var kept []CacheEntry
+	for _, e := range c.index.Entries {
+		if e.Key == c.active {
+			kept = append(kept, e)
+			continue
+		}
+		os.RemoveAll(filepath.Join(c.dir, e.Key))
+	}
+	c.index.Entries = kept
+	c.save()
+}
+
+// dropStale removes stale entries that are not active and reports whether
+// any were removed. Caller must hold mu.
+func (c *Cache) dropStale() bool {
+	dropped := false
+	kept := c.index.Entries[:0]
+	for _, e := range c.index.Entries {
+		if e.Stale && e.Key != c.active {
+			os.RemoveAll(filepath.Join(c.dir, e.Key))
+			dropped = true
+			continue
+		}
+		kept = append(kept, e)
+	}
+	c.index.Entries = kept
+	return dropped
+}
+
+// evict removes least recently used entries beyond the limit. Caller must hold mu.
+func (c *Cache) evict() {
+	limit := c.limit
+	if limit <= 0 {
+		limit = DefaultCacheSize
+	}
+	sort.Slice(c.index.Entries, func(i, j int) bool {
+		return c.index.Entries[i].LastUsed.After(c.index.Entries[j].LastUsed)
+	})
+	var kept []CacheEntry
+	for _, e := range c.index.Entries {
+		if len(kept) < limit || e.Key == c.active {
+			kept = append(kept, e)
+			continue
+		}
+		os.RemoveAll(filepath.Join(c.dir, e.Key))
+	}
+	c.index.Entries = kept
+}
+
+// load reads the index from disk once. Caller must hold mu.
+func (c *Cache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	aside, _ := filepath.Glob(filepath.Join(c.dir, "*"+replacedSuffix))
+	for _, dir := range aside {
+		os.RemoveAll(dir)
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, cacheIndexFile))
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &c.index)
+}
+
+// save writes the index to disk. Caller must hold mu.
+func (c *Cache) save() {
+	os.MkdirAll(c.dir, os.ModePerm)
+	data, err := json.MarshalIndent(c.index, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(filepath.Join(c.dir, cacheIndexFile), data, 0644)
+}
+
+// hasWad reports whether dir contains at least one .wad.client file.
+func hasWad(dir string) bool {
+	found := false
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || found {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".wad.client") {
+			found = true
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return found
+}
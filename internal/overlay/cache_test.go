@@ -0,0 +1,165 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// build writes a fake overlay build into a fresh directory and returns it.
+func build(t *testing.T, name string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	if err := os.MkdirAll(filepath.Join(dir, "DATA", "FINAL"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "DATA", "FINAL", "Champions.wad.client"), []byte(name), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestCacheEviction(t *testing.T) {
+	root := t.TempDir()
+	c := NewCache(root, 2)
+	c.SetGameVersion("14.1")
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := c.Store(key, key, build(t, key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := c.Lookup("a"); ok {
+		t.Error("a: want evicted")
+	}
+	if exists(filepath.Join(root, "a")) {
+		t.Error("a: directory left on disk")
+	}
+	if dir, ok := c.Lookup("b"); !ok || !hasWad(dir) {
+		t.Errorf("b: Lookup = %q, %v", dir, ok)
+	}
+
+	// The active overlay survives eviction
+	c.SetActive("c")
+	c.Store("d", "d", build(t, "d")) // b was used more recently than c
+	if _, ok := c.Lookup("c"); !ok {
+		t.Error("c: active entry evicted")
+	}
+
+	// The index survives a restart
+	reopened := NewCache(root, 2)
+	if got := len(reopened.Entries()); got != 3 {
+		t.Errorf("reopened cache has %d entries, want 3", got)
+	}
+}
+
+func TestCacheGameVersionChange(t *testing.T) {
+	root := t.TempDir()
+	c := NewCache(root, 5)
+	c.SetGameVersion("14.1")
+	c.Store("old", "old", build(t, "old"))
+	c.Store("running", "running", build(t, "running"))
+	c.SetActive("running")
+
+	if c.SetGameVersion("14.1") {
+		t.Error("same version: want no invalidation")
+	}
+	if !c.SetGameVersion("14.2") {
+		t.Error("new version: want invalidation")
+	}
+	if exists(filepath.Join(root, "old")) {
+		t.Error("old: directory left on disk")
+	}
+	if !exists(filepath.Join(root, "running")) {
+		t.Fatal("running: active overlay deleted while in use")
+	}
+	if _, ok := c.Lookup("running"); ok {
+		t.Error("running: stale entry returned by Lookup")
+	}
+	entries := c.Entries()
+	if len(entries) != 1 || !entries[0].Stale {
+		t.Fatalf("entries = %+v, want the running overlay marked stale", entries)
+	}
+
+	// Once something else is running, the stale overlay is removed
+	c.Store("fresh", "fresh", build(t, "fresh"))
+	if !exists(filepath.Join(root, "running")) {
+		t.Error("running: removed by Store while still active")
+	}
+	c.SetActive("fresh")
+	if exists(filepath.Join(root, "running")) {
+		t.Error("running: stale overlay left on disk")
+	}
+	if entries := c.Entries(); len(entries) != 1 || entries[0].Key != "fresh" {
+		t.Errorf("entries = %+v, want only fresh", entries)
+	}
+}
+
+func TestCacheLookupMissingFiles(t *testing.T) {
+	root := t.TempDir()
+	c := NewCache(root, 5)
+	c.Store("a", "a", build(t, "a"))
+	os.RemoveAll(filepath.Join(root, "a", "DATA"))
+	if _, ok := c.Lookup("a"); ok {
+		t.Error("Lookup returned an overlay without WADs")
+	}
+	if len(c.Entries()) != 0 {
+		t.Error("broken entry kept in the index")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := CacheKey([]string{"h1", "h2"}, "14.1")
+	tests := []struct {
+		name string
+		key  string
+		same bool
+	}{
+		{"order independent", CacheKey([]string{"h2", "h1"}, "14.1"), true},
+		{"different mods", CacheKey([]string{"h1", "h3"}, "14.1"), false},
+		{"different version", CacheKey([]string{"h1", "h2"}, "14.2"), false},
+		{"different options", CacheKey([]string{"h1", "h2"}, "14.1", "skipConflicts"), false},
+	}
+	for _, tt := range tests {
+		if (tt.key == base) != tt.same {
+			t.Errorf("%s: key %s vs %s", tt.name, tt.key, base)
+		}
+	}
+}
+
+func TestCacheStoreActive(t *testing.T) {
+	root := t.TempDir()
+	c := NewCache(root, 2)
+	c.SetGameVersion("14.1")
+	if _, err := c.Store("a", "a", build(t, "first")); err != nil {
+		t.Fatal(err)
+	}
+	c.SetActive("a")
+
+	// Rebuilding the overlay that is in use replaces it in place
+	dir, err := c.Store("a", "a", build(t, "second"))
+	if err != nil {
+		t.Fatalf("Store over the active entry: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "DATA", "FINAL", "Champions.wad.client"))
+	if string(data) != "second" {
+		t.Errorf("active entry holds %q, want the new build", data)
+	}
+	if exists(filepath.Join(root, "a"+replacedSuffix)) {
+		t.Error("replaced copy left on disk")
+	}
+	if got := len(c.Entries()); got != 1 {
+		t.Errorf("cache has %d entries, want 1", got)
+	}
+
+	// A copy left aside by an earlier run is removed on load
+	os.MkdirAll(filepath.Join(root, "b"+replacedSuffix), os.ModePerm)
+	NewCache(root, 2).Entries()
+	if exists(filepath.Join(root, "b"+replacedSuffix)) {
+		t.Error("leftover replaced copy not removed")
+	}
+}
@@ -0,0 +1,248 @@
+package overlay
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hoangvu12/ame/internal/wad"
+)
+
+// Mod categories, used to rank mods when they touch the same WAD entries.
+const (
+	CategoryOwn      = "own"
+	CategoryGlobal   = "global"
+	CategoryTeammate = "teammate"
+)
+
+// DefaultPriority is the load order used when no priority is configured:
+// the user's own skin beats global mods, which beat teammate skins.
+var DefaultPriority = []string{CategoryOwn, CategoryGlobal, CategoryTeammate}
+
+// Mod is a single mod directory under the mods dir that is fed to mkoverlay.
+type Mod struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// Conflict describes one entry claimed by more than one mod.
+// Winner keeps the entry; every mod in Dropped had its copy removed.
+type Conflict struct {
+	Entry   string   `json:"entry"`
+	Winner  string   `json:"winner"`
+	Dropped []string `json:"dropped"`
+}
+
+// ConflictReport lists every conflict found while preparing an overlay.
+type ConflictReport struct {
+	Mods      []string   `json:"mods"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// HasConflicts returns true if at least one entry was overridden.
+func (r ConflictReport) HasConflicts() bool {
+	return len(r.Conflicts) > 0
+}
+
+// ValidPriority reports whether priority is a permutation of the known categories.
+func ValidPriority(priority []string) bool {
+	if len(priority) != len(DefaultPriority) {
+		return false
+	}
+	seen := make(map[string]bool, len(priority))
+	for _, c := range priority {
+		if c != CategoryOwn && c != CategoryGlobal && c != CategoryTeammate {
+			return false
+		}
+		if seen[c] {
+			return false
+		}
+		seen[c] = true
+	}
+	return true
+}
+
+// SortByPriority returns mods ordered by category rank (highest first).
+// Mods within the same category keep their original order.
+func SortByPriority(mods []Mod, priority []string) []Mod {
+	if !ValidPriority(priority) {
+		priority = DefaultPriority
+	}
+	rank := make(map[string]int, len(priority))
+	for i, c := range priority {
+		rank[c] = i
+	}
+	sorted := make([]Mod, len(mods))
+	copy(sorted, mods)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank[sorted[i].Category] < rank[sorted[j].Category]
+	})
+	return sorted
+}
+
+// Names returns the slash-separated mod list expected by mkoverlay --mods.
+func Names(mods []Mod) string {
+	names := make([]string, len(mods))
+	for i, m := range mods {
+		names[i] = m.Name
+	}
+	return strings.Join(names, "/")
+}
+
+// modEntry is one file a mod would place into the overlay.
+type modEntry struct {
+	key    string // wad name + path hash (or "raw/" + path), used for comparison
+	name   string // human-readable entry name for the report
+	path   string // file on disk
+	packed bool   // entry lives inside a packed .wad.client at path
+}
+
+// listEntries walks a mod directory and returns the entries it changes.
+// Extracted WADs (WAD/<name>.wad.client/<file>) yield one entry per file and
+// packed WADs (WAD/<name>.wad.client as a file) one entry per TOC record.
+// Entries are keyed by path hash so hash-named and path-named files match.
+func listEntries(modDir string) ([]modEntry, error) {
+	var entries []modEntry
+
+	wadRoot := filepath.Join(modDir, "WAD")
+	wads, err := os.ReadDir(wadRoot)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, w := range wads {
+		wadName := strings.ToLower(w.Name())
+		wadPath := filepath.Join(wadRoot, w.Name())
+		if !w.IsDir() {
+			a, err := wad.Open(wadPath)
+			if err != nil {
+				return nil, err
+			}
+			for _, h := range a.Hashes() {
+				key := fmt.Sprintf("%s/%016x", wadName, h)
+				entries = append(entries, modEntry{key: key, name: key, path: wadPath, packed: true})
+			}
+			a.Close()
+			continue
+		}
+		err := filepath.WalkDir(wadPath, func(p string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(wadPath, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			h, ok := wad.ParseHashName(rel)
+			if !ok {
+				h = wad.HashPath(rel)
+			}
+			key := fmt.Sprintf("%s/%016x", wadName, h)
+			name := wadName + "/" + strings.ToLower(rel)
+			entries = append(entries, modEntry{key: key, name: name, path: p})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rawRoot := filepath.Join(modDir, "RAW")
+	err = filepath.WalkDir(rawRoot, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(rawRoot, p)
+		if err != nil {
+			return err
+		}
+		key := "raw/" + strings.ToLower(filepath.ToSlash(rel))
+		entries = append(entries, modEntry{key: key, name: key, path: p})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries, nil
+}
+
+// ResolveConflicts finds entries changed by more than one mod and removes the
+// losing copies from modsDir, so mkoverlay sees a conflict-free mod set.
+// mods must already be in priority order (highest first): the first mod to
+// claim an entry keeps it. A packed WAD cannot be split, so it is dropped
+// whole if any of its entries was already claimed by a higher-priority mod.
+func ResolveConflicts(modsDir string, mods []Mod) (ConflictReport, error) {
+	report := ConflictReport{Mods: make([]string, 0, len(mods))}
+
+	owner := make(map[string]string) // entry key -> winning mod
+	byEntry := make(map[string]int)  // entry key -> index into report.Conflicts
+
+	record := func(e modEntry, winner, loser string) {
+		i, ok := byEntry[e.key]
+		if !ok {
+			report.Conflicts = append(report.Conflicts, Conflict{Entry: e.name, Winner: winner})
+			i = len(report.Conflicts) - 1
+			byEntry[e.key] = i
+		}
+		report.Conflicts[i].Dropped = append(report.Conflicts[i].Dropped, loser)
+	}
+
+	for _, m := range mods {
+		report.Mods = append(report.Mods, m.Name)
+		entries, err := listEntries(filepath.Join(modsDir, m.Name))
+		if err != nil {
+			return report, err
+		}
+
+		// Group packed entries by WAD file so each file is kept or dropped as a unit
+		packed := make(map[string][]modEntry)
+		var packedOrder []string
+
+		for _, e := range entries {
+			if e.packed {
+				if _, ok := packed[e.path]; !ok {
+					packedOrder = append(packedOrder, e.path)
+				}
+				packed[e.path] = append(packed[e.path], e)
+				continue
+			}
+			if w, ok := owner[e.key]; ok && w != m.Name {
+				if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+					return report, err
+				}
+				record(e, w, m.Name)
+				continue
+			}
+			owner[e.key] = m.Name
+		}
+
+		for _, path := range packedOrder {
+			group := packed[path]
+			lost := false
+			for _, e := range group {
+				if w, ok := owner[e.key]; ok && w != m.Name {
+					record(e, w, m.Name)
+					lost = true
+				}
+			}
+			if lost {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return report, err
+				}
+				continue
+			}
+			for _, e := range group {
+				owner[e.key] = m.Name
+			}
+		}
+	}
+
+	sort.SliceStable(report.Conflicts, func(i, j int) bool {
+		return report.Conflicts[i].Entry < report.Conflicts[j].Entry
+	})
+	return report, nil
+}
@@ -0,0 +1,191 @@
+package overlay
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hoangvu12/ame/internal/wad"
+)
+
+// writeFiles creates files (slash-separated paths relative to root) with the given contents.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, data := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeWAD packs files (keyed by entry path) into a WAD archive at path.
+func writeWAD(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	w := wad.NewWriter(3)
+	for name, data := range files {
+		w.AddData(wad.HashPath(name), []byte(data))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := w.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSortByPriority(t *testing.T) {
+	mods := []Mod{
+		{"skin_1", CategoryOwn},
+		{"skin_2", CategoryTeammate},
+		{"skin_3", CategoryTeammate},
+		{"global_a", CategoryGlobal},
+		{"global_b", CategoryGlobal},
+	}
+	tests := []struct {
+		name     string
+		priority []string
+		want     string
+	}{
+		{"default", DefaultPriority, "skin_1/global_a/global_b/skin_2/skin_3"},
+		{"unset", nil, "skin_1/global_a/global_b/skin_2/skin_3"},
+		{"teammates first", []string{CategoryTeammate, CategoryOwn, CategoryGlobal}, "skin_2/skin_3/skin_1/global_a/global_b"},
+		{"globals first", []string{CategoryGlobal, CategoryTeammate, CategoryOwn}, "global_a/global_b/skin_2/skin_3/skin_1"},
+		{"duplicate category", []string{CategoryOwn, CategoryOwn, CategoryTeammate}, "skin_1/global_a/global_b/skin_2/skin_3"},
+		{"unknown category", []string{CategoryOwn, "extra", CategoryTeammate}, "skin_1/global_a/global_b/skin_2/skin_3"},
+	}
+	for _, tt := range tests {
+		if got := Names(SortByPriority(mods, tt.priority)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Conflicts are named after the first dropped copy, so the hash-named file
+// shows up by hash when it loses and by path when it wins.
+func TestResolveConflicts(t *testing.T) {
+	const champ = "WAD/Ahri.wad.client/"
+
+	// Mods in the order prepareMods lists them: own, teammates, globals
+	mods := []Mod{
+		{"skin_1", CategoryOwn},
+		{"skin_2", CategoryTeammate},
+		{"global_ui", CategoryGlobal},
+	}
+	fixture := map[string]map[string]string{
+		"skin_1": {
+			champ + "data/shared.bin": "own",
+			champ + "data/own.bin":    "own",
+			"RAW/readme.txt":          "own",
+		},
+		"skin_2": {
+			champ + "data/shared.bin": "teammate",
+			champ + fmt.Sprintf("%016x.bin", wad.HashPath("data/global.bin")): "teammate", // hash-named copy of global.bin
+			champ + "data/teammate.bin":                                       "teammate",
+			"RAW/README.TXT":                                                  "teammate",
+		},
+		"global_ui": {
+			champ + "data/shared.bin": "global",
+			champ + "data/global.bin": "global",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		priority  []string
+		conflicts []Conflict
+	}{
+		{
+			name:     "own > global > teammate",
+			priority: DefaultPriority,
+			conflicts: []Conflict{
+				{Entry: fmt.Sprintf("ahri.wad.client/%016x.bin", wad.HashPath("data/global.bin")), Winner: "global_ui", Dropped: []string{"skin_2"}},
+				{Entry: "ahri.wad.client/data/shared.bin", Winner: "skin_1", Dropped: []string{"global_ui", "skin_2"}},
+				{Entry: "raw/readme.txt", Winner: "skin_1", Dropped: []string{"skin_2"}},
+			},
+		},
+		{
+			name:     "teammate > own > global",
+			priority: []string{CategoryTeammate, CategoryOwn, CategoryGlobal},
+			conflicts: []Conflict{
+				{Entry: "ahri.wad.client/data/global.bin", Winner: "skin_2", Dropped: []string{"global_ui"}},
+				{Entry: "ahri.wad.client/data/shared.bin", Winner: "skin_2", Dropped: []string{"skin_1", "global_ui"}},
+				{Entry: "raw/readme.txt", Winner: "skin_2", Dropped: []string{"skin_1"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		modsDir := t.TempDir()
+		for name, files := range fixture {
+			writeFiles(t, filepath.Join(modsDir, name), files)
+		}
+
+		sorted := SortByPriority(mods, tt.priority)
+		report, err := ResolveConflicts(modsDir, sorted)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(report.Conflicts, tt.conflicts) {
+			t.Errorf("%s: conflicts\n got %+v\nwant %+v", tt.name, report.Conflicts, tt.conflicts)
+		}
+
+		// Every entry is left in exactly one mod directory, the winner's
+		owner := map[string]string{}
+		for _, m := range sorted {
+			entries, err := listEntries(filepath.Join(modsDir, m.Name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if w, ok := owner[e.key]; ok {
+					t.Errorf("%s: %s still has %s, won by %s", tt.name, m.Name, e.name, w)
+				}
+				owner[e.key] = m.Name
+			}
+		}
+		for _, unique := range []string{"skin_1/" + champ + "data/own.bin", "skin_2/" + champ + "data/teammate.bin"} {
+			if !exists(filepath.Join(modsDir, filepath.FromSlash(unique))) {
+				t.Errorf("%s: %s removed without a conflict", tt.name, unique)
+			}
+		}
+	}
+}
+
+func TestResolveConflictsPacked(t *testing.T) {
+	modsDir := t.TempDir()
+	writeFiles(t, filepath.Join(modsDir, "skin_1"), map[string]string{"WAD/Ahri.wad.client/data/a.bin": "own"})
+	packed := filepath.Join(modsDir, "global_ui", "WAD", "Ahri.wad.client")
+	writeWAD(t, packed, map[string]string{"data/a.bin": "global", "data/b.bin": "global"})
+	other := filepath.Join(modsDir, "skin_2", "WAD", "UI.wad.client")
+	writeWAD(t, other, map[string]string{"ui/a.bin": "teammate"})
+
+	mods := []Mod{{"skin_1", CategoryOwn}, {"global_ui", CategoryGlobal}, {"skin_2", CategoryTeammate}}
+	report, err := ResolveConflicts(modsDir, mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Conflict{{
+		Entry:   fmt.Sprintf("ahri.wad.client/%016x", wad.HashPath("data/a.bin")),
+		Winner:  "skin_1",
+		Dropped: []string{"global_ui"},
+	}}
+	if !reflect.DeepEqual(report.Conflicts, want) {
+		t.Errorf("conflicts\n got %+v\nwant %+v", report.Conflicts, want)
+	}
+	if exists(packed) {
+		t.Error("packed WAD with a lost entry was kept")
+	}
+	if !exists(other) {
+		t.Error("packed WAD without conflicts was removed")
+	}
+}
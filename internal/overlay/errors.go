@@ -0,0 +1,56 @@
+package overlay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// BuildErrorKind says why an overlay build did not complete.
+type BuildErrorKind string
+
+const (
+	BuildTimeout  BuildErrorKind = "timeout"  // build exceeded its deadline and was killed
+	BuildCanceled BuildErrorKind = "canceled" // build was cancelled (cleanup or superseded)
+	BuildFailed   BuildErrorKind = "failed"   // the builder itself reported an error
+)
+
+// BuildError is returned by Builder.Build when the overlay was not written.
+type BuildError struct {
+	Kind     BuildErrorKind
+	Backend  string
+	ExitCode int    // mkoverlay exit code for BuildFailed, 0 otherwise
+	Mod      string // mod the failure was traced to, "" if not known
+	Err      error
+}
+
+func (e *BuildError) Error() string {
+	switch e.Kind {
+	case BuildTimeout:
+		return fmt.Sprintf("%s build timed out", e.Backend)
+	case BuildCanceled:
+		return fmt.Sprintf("%s build cancelled", e.Backend)
+	}
+	if e.ExitCode != 0 {
+		return fmt.Sprintf("%s build failed (code %d)", e.Backend, e.ExitCode)
+	}
+	return fmt.Sprintf("%s build failed: %v", e.Backend, e.Err)
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// newBuildError classifies err, treating context errors as timeout or cancel.
+func newBuildError(backend string, exitCode int, err error) *BuildError {
+	kind := BuildFailed
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = BuildTimeout
+		exitCode = 0
+	case errors.Is(err, context.Canceled):
+		kind = BuildCanceled
+		exitCode = 0
+	}
+	return &BuildError{Kind: kind, Backend: backend, ExitCode: exitCode, Err: err}
+}
@@ -0,0 +1,35 @@
+package overlay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestBuildError(t *testing.T) {
+	toolErr := errors.New("exit status 3")
+	tests := []struct {
+		name     string
+		exitCode int
+		err      error
+		kind     BuildErrorKind
+		code     int
+		msg      string
+	}{
+		{"timeout", -1, context.DeadlineExceeded, BuildTimeout, 0, "modtools build timed out"},
+		{"wrapped timeout", 0, fmt.Errorf("Ahri.wad.client: %w", context.DeadlineExceeded), BuildTimeout, 0, "modtools build timed out"},
+		{"canceled", -1, context.Canceled, BuildCanceled, 0, "modtools build cancelled"},
+		{"tool failure", 3, toolErr, BuildFailed, 3, "modtools build failed (code 3)"},
+		{"no exit code", 0, toolErr, BuildFailed, 0, "modtools build failed: exit status 3"},
+	}
+	for _, tt := range tests {
+		err := newBuildError(BackendModTools, tt.exitCode, tt.err)
+		if err.Kind != tt.kind || err.ExitCode != tt.code || err.Error() != tt.msg {
+			t.Errorf("%s: got %s/%d %q, want %s/%d %q", tt.name, err.Kind, err.ExitCode, err.Error(), tt.kind, tt.code, tt.msg)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: does not unwrap to %v", tt.name, tt.err)
+		}
+	}
+}
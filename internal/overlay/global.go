@@ -0,0 +1,112 @@
+package overlay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// GlobalModPrefix marks global mod directories copied into the mods dir, so
+// they never collide with extracted skins.
+const GlobalModPrefix = "global_"
+
+// CopyGlobalMods copies every mod directory under srcDir into modsDir and
+// returns them as global mods, ordered by name. Copies are used because
+// ResolveConflicts deletes losing entries from the mods dir.
+// A missing srcDir means no global mods.
+func CopyGlobalMods(srcDir, modsDir string) ([]Mod, error) {
+	items, err := os.ReadDir(srcDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var mods []Mod
+	for _, item := range items {
+		if !item.IsDir() {
+			continue
+		}
+		name := GlobalModPrefix + item.Name()
+		if err := copyDir(filepath.Join(srcDir, item.Name()), filepath.Join(modsDir, name)); err != nil {
+			return nil, fmt.Errorf("failed to copy global mod %s: %w", item.Name(), err)
+		}
+		mods = append(mods, Mod{Name: name, Category: CategoryGlobal})
+	}
+	return mods, nil
+}
+
+// HashGlobalMods fingerprints the global mods under dir by file path, size
+// and modification time, for use in overlay cache keys. Returns "" if there
+// are none.
+func HashGlobalMods(dir string) (string, error) {
+	h := sha256.New()
+	files := 0
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s|%d|%d\n", filepath.ToSlash(rel), info.Size(), info.ModTime().UnixNano())
+		files++
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if files == 0 {
+		return "", nil
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// copyDir copies the regular files under src into dst, creating directories as needed.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(p, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
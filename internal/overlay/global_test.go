@@ -0,0 +1,70 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCopyGlobalMods(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "global_mods")
+	writeFiles(t, src, map[string]string{
+		"ui/WAD/UI.wad.client/ui/a.bin":     "ui",
+		"maps/WAD/Map11.wad.client/map.bin": "map",
+		"notes.txt":                         "not a mod",
+	})
+
+	modsDir := t.TempDir()
+	mods, err := CopyGlobalMods(src, modsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Mod{{"global_maps", CategoryGlobal}, {"global_ui", CategoryGlobal}}
+	if !reflect.DeepEqual(mods, want) {
+		t.Errorf("mods = %+v, want %+v", mods, want)
+	}
+	got, err := os.ReadFile(filepath.Join(modsDir, "global_ui", "WAD", "UI.wad.client", "ui", "a.bin"))
+	if err != nil || string(got) != "ui" {
+		t.Errorf("copied file = %q, %v; want %q", got, err, "ui")
+	}
+
+	// Resolving conflicts in the copy leaves the user's mods alone
+	os.RemoveAll(filepath.Join(modsDir, "global_ui"))
+	if !exists(filepath.Join(src, "ui", "WAD", "UI.wad.client", "ui", "a.bin")) {
+		t.Error("source mod changed by removing its copy")
+	}
+
+	mods, err = CopyGlobalMods(filepath.Join(t.TempDir(), "missing"), modsDir)
+	if err != nil || mods != nil {
+		t.Errorf("missing dir: got %+v, %v; want no mods", mods, err)
+	}
+}
+
+func TestHashGlobalMods(t *testing.T) {
+	dir := t.TempDir()
+	empty, err := HashGlobalMods(dir)
+	if err != nil || empty != "" {
+		t.Errorf("empty dir: got %q, %v; want \"\"", empty, err)
+	}
+	if h, err := HashGlobalMods(filepath.Join(dir, "missing")); err != nil || h != "" {
+		t.Errorf("missing dir: got %q, %v; want \"\"", h, err)
+	}
+
+	writeFiles(t, dir, map[string]string{"ui/WAD/UI.wad.client/a.bin": "a"})
+	first, _ := HashGlobalMods(dir)
+	if first == "" {
+		t.Fatal("hash of one mod is empty")
+	}
+	if again, _ := HashGlobalMods(dir); again != first {
+		t.Errorf("hash not stable: %s then %s", first, again)
+	}
+
+	path := filepath.Join(dir, "ui", "WAD", "UI.wad.client", "a.bin")
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(path, later, later)
+	if changed, _ := HashGlobalMods(dir); changed == first {
+		t.Error("hash unchanged after the mod was modified")
+	}
+}
@@ -0,0 +1,313 @@
+package overlay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hoangvu12/ame/internal/wad"
+)
+
+// NativeBuilder builds overlays in pure Go: for every WAD a mod touches it
+// copies the game's base WAD, replaces the modded entries and writes the
+// result under the same relative path in the overlay directory. Loose files
+// under a mod's RAW folder go into whichever base WAD holds their path.
+type NativeBuilder struct{}
+
+// Name returns the backend identifier.
+func (NativeBuilder) Name() string { return BackendNative }
+
+// Build merges mods over the base game WADs. Mods are applied lowest
+// priority first so the highest-priority mod wins any remaining overlap.
+func (NativeBuilder) Build(ctx context.Context, modsDir, overlayDir, gameDir string, mods []Mod) error {
+	if err := buildNative(ctx, modsDir, overlayDir, gameDir, mods); err != nil {
+		buildErr := newBuildError(BackendNative, 0, err)
+		var modErr *modError
+		if errors.As(err, &modErr) {
+			buildErr.Mod = modErr.mod
+		}
+		return buildErr
+	}
+	return nil
+}
+
+// modError is a build failure caused by one mod's files.
+type modError struct {
+	mod string
+	err error
+}
+
+func (e *modError) Error() string { return e.mod + ": " + e.err.Error() }
+func (e *modError) Unwrap() error { return e.err }
+
+func buildNative(ctx context.Context, modsDir, overlayDir, gameDir string, mods []Mod) error {
+	baseWads, err := indexGameWads(gameDir)
+	if err != nil {
+		return fmt.Errorf("failed to index game WADs: %w", err)
+	}
+
+	// wad name -> mod entries to merge, in application order
+	changes := make(map[string][]wadChange)
+	var order []string
+	add := func(name string, c wadChange) {
+		if _, ok := changes[name]; !ok {
+			order = append(order, name)
+		}
+		changes[name] = append(changes[name], c)
+	}
+
+	var owners map[uint64]string // entry hash -> base wad name, loaded on first RAW file
+	for i := len(mods) - 1; i >= 0; i-- {
+		modDir := filepath.Join(modsDir, mods[i].Name)
+		wadRoot := filepath.Join(modDir, "WAD")
+		items, err := os.ReadDir(wadRoot)
+		if err != nil && !os.IsNotExist(err) {
+			return &modError{mods[i].Name, err}
+		}
+		for _, item := range items {
+			add(strings.ToLower(item.Name()), wadChange{
+				mod:    mods[i].Name,
+				path:   filepath.Join(wadRoot, item.Name()),
+				packed: !item.IsDir(),
+			})
+		}
+
+		raw, err := readRawFiles(filepath.Join(modDir, "RAW"))
+		if err != nil {
+			return &modError{mods[i].Name, err}
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		if owners == nil {
+			if owners, err = indexGameEntries(gameDir, baseWads); err != nil {
+				return fmt.Errorf("failed to index game WAD entries: %w", err)
+			}
+		}
+		byWad := make(map[string]map[uint64]string)
+		var wads []string
+		for _, f := range raw {
+			name, ok := owners[f.hash]
+			if !ok {
+				return &modError{mods[i].Name, fmt.Errorf("RAW file %s is not in any game WAD", f.rel)}
+			}
+			if byWad[name] == nil {
+				byWad[name] = make(map[uint64]string)
+				wads = append(wads, name)
+			}
+			byWad[name][f.hash] = f.path
+		}
+		for _, name := range wads {
+			add(name, wadChange{mod: mods[i].Name, files: byWad[name]})
+		}
+	}
+
+	for _, name := range order {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, ok := baseWads[name]
+		if !ok {
+			return fmt.Errorf("base WAD not found in game directory: %s", name)
+		}
+		if err := buildWad(filepath.Join(gameDir, rel), filepath.Join(overlayDir, rel), changes[name]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// wadChange is one mod's contribution to a single WAD.
+type wadChange struct {
+	mod    string
+	path   string // extracted WAD directory or packed .wad.client file
+	packed bool
+	files  map[uint64]string // RAW files by entry hash, instead of path
+}
+
+// rawFile is a loose file from a mod's RAW folder.
+type rawFile struct {
+	rel  string
+	path string
+	hash uint64
+}
+
+// readRawFiles lists the files under root, sorted by relative path. A missing
+// folder has no files.
+func readRawFiles(root string) ([]rawFile, error) {
+	var files []rawFile
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		h, ok := wad.ParseHashName(rel)
+		if !ok {
+			h = wad.HashPath(rel)
+		}
+		files = append(files, rawFile{rel: rel, path: p, hash: h})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// indexGameEntries maps every entry hash in the game's WADs to the name of
+// the first WAD (by relative path) that holds it.
+func indexGameEntries(gameDir string, baseWads map[string]string) (map[uint64]string, error) {
+	names := make([]string, 0, len(baseWads))
+	for name := range baseWads {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return baseWads[names[i]] < baseWads[names[j]] })
+
+	owners := make(map[uint64]string)
+	for _, name := range names {
+		a, err := wad.Open(filepath.Join(gameDir, baseWads[name]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, h := range a.Hashes() {
+			if _, ok := owners[h]; !ok {
+				owners[h] = name
+			}
+		}
+		a.Close()
+	}
+	return owners, nil
+}
+
+// indexGameWads maps lowercased WAD file names to their path relative to gameDir.
+func indexGameWads(gameDir string) (map[string]string, error) {
+	index := make(map[string]string)
+	root := filepath.Join(gameDir, "DATA")
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name := strings.ToLower(d.Name())
+		if !strings.HasSuffix(name, ".wad.client") {
+			return nil
+		}
+		rel, err := filepath.Rel(gameDir, p)
+		if err != nil {
+			return err
+		}
+		if _, ok := index[name]; !ok {
+			index[name] = rel
+		}
+		return nil
+	})
+	return index, err
+}
+
+// buildWad writes the merged WAD for basePath to outPath.
+func buildWad(basePath, outPath string, changes []wadChange) error {
+	base, err := wad.Open(basePath)
+	if err != nil {
+		return err
+	}
+	defer base.Close()
+
+	w := wad.NewWriter(base.Minor)
+	for _, e := range base.Entries {
+		w.AddFrom(base, e)
+	}
+
+	for _, c := range changes {
+		var err error
+		switch {
+		case c.files != nil:
+			err = mergeFiles(w, c.files)
+		case c.packed:
+			err = mergePacked(w, c.path)
+		default:
+			err = mergeExtracted(w, c.path)
+		}
+		if err != nil {
+			return &modError{c.mod, err}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), os.ModePerm); err != nil {
+		return err
+	}
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	if _, err := w.WriteTo(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// mergePacked adds every entry of a mod's packed WAD. Entries are decoded and
+// re-added because their subchunk indices refer to the mod's own archive.
+func mergePacked(w *wad.Writer, path string) error {
+	a, err := wad.Open(path)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	for _, e := range a.Entries {
+		if e.Type == wad.TypeLink {
+			continue
+		}
+		data, err := a.ReadEntry(e)
+		if err != nil {
+			return err
+		}
+		w.AddData(e.Hash, data)
+	}
+	return nil
+}
+
+// mergeFiles adds files keyed by entry hash.
+func mergeFiles(w *wad.Writer, files map[uint64]string) error {
+	for h, p := range files {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		w.AddData(h, data)
+	}
+	return nil
+}
+
+// mergeExtracted adds every file of an extracted WAD directory, hashing its
+// relative path (or reusing the hash when the file is named by one).
+func mergeExtracted(w *wad.Writer, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		h, ok := wad.ParseHashName(rel)
+		if !ok {
+			h = wad.HashPath(rel)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		w.AddData(h, data)
+		return nil
+	})
+}
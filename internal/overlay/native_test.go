@@ -0,0 +1,173 @@
+package overlay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/hoangvu12/ame/internal/wad"
+)
+
+// gameFixture writes a game directory with one champion WAD and one UI WAD.
+func gameFixture(t *testing.T) string {
+	t.Helper()
+	gameDir := t.TempDir()
+	writeWAD(t, filepath.Join(gameDir, "DATA", "FINAL", "Champions", "Ahri.wad.client"), map[string]string{
+		"data/a.bin": "base a",
+		"data/b.bin": "base b",
+		"data/c.bin": "base c",
+	})
+	writeWAD(t, filepath.Join(gameDir, "DATA", "FINAL", "UI.wad.client"), map[string]string{
+		"ui/a.bin": "base ui",
+	})
+	return gameDir
+}
+
+// readWAD returns the contents of every entry in the WAD at path, keyed by hash.
+func readWAD(t *testing.T, path string) map[uint64]string {
+	t.Helper()
+	a, err := wad.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	got := make(map[uint64]string)
+	for _, e := range a.Entries {
+		data, err := a.ReadEntry(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[e.Hash] = string(data)
+	}
+	return got
+}
+
+func TestNativeBuild(t *testing.T) {
+	gameDir := gameFixture(t)
+	modsDir := t.TempDir()
+	writeFiles(t, filepath.Join(modsDir, "skin_1"), map[string]string{
+		"WAD/Ahri.wad.client/data/a.bin": "own a",
+	})
+	writeFiles(t, filepath.Join(modsDir, "global_ui"), map[string]string{
+		"WAD/Ahri.wad.client/data/a.bin":                                              "global a",
+		"WAD/Ahri.wad.client/" + fmt.Sprintf("%016x.bin", wad.HashPath("data/b.bin")): "global b",
+	})
+	writeWAD(t, filepath.Join(modsDir, "skin_2", "WAD", "Ahri.wad.client"), map[string]string{
+		"data/b.bin": "teammate b",
+		"data/c.bin": "teammate c",
+		"data/d.bin": "teammate d",
+	})
+
+	overlayDir := t.TempDir()
+	mods := []Mod{{"skin_1", CategoryOwn}, {"global_ui", CategoryGlobal}, {"skin_2", CategoryTeammate}}
+	if err := (NativeBuilder{}).Build(context.Background(), modsDir, overlayDir, gameDir, mods); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readWAD(t, filepath.Join(overlayDir, "DATA", "FINAL", "Champions", "Ahri.wad.client"))
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"data/a.bin", "own a"},      // own beats global
+		{"data/b.bin", "global b"},   // global beats teammate, hash-named file
+		{"data/c.bin", "teammate c"}, // teammate beats base
+		{"data/d.bin", "teammate d"}, // new entry
+	}
+	for _, tt := range tests {
+		if got[wad.HashPath(tt.path)] != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, got[wad.HashPath(tt.path)], tt.want)
+		}
+	}
+	if len(got) != len(tests) {
+		t.Errorf("got %d entries, want %d", len(got), len(tests))
+	}
+	if exists(filepath.Join(overlayDir, "DATA", "FINAL", "UI.wad.client")) {
+		t.Error("untouched WAD written to the overlay")
+	}
+}
+
+func TestNativeBuildErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		files map[string]string
+		kind  BuildErrorKind
+	}{
+		{"missing base WAD", context.Background(), map[string]string{"WAD/Zed.wad.client/a.bin": "x"}, BuildFailed},
+		{"canceled", canceled, map[string]string{"WAD/Ahri.wad.client/data/a.bin": "x"}, BuildCanceled},
+	}
+	for _, tt := range tests {
+		modsDir := t.TempDir()
+		writeFiles(t, filepath.Join(modsDir, "skin_1"), tt.files)
+		err := (NativeBuilder{}).Build(tt.ctx, modsDir, t.TempDir(), gameFixture(t), []Mod{{"skin_1", CategoryOwn}})
+		var buildErr *BuildError
+		if !errors.As(err, &buildErr) || buildErr.Kind != tt.kind || buildErr.Backend != BackendNative {
+			t.Errorf("%s: got %v, want %s native build error", tt.name, err, tt.kind)
+		}
+	}
+}
+
+func TestNativeBuildRaw(t *testing.T) {
+	gameDir := gameFixture(t)
+	modsDir := t.TempDir()
+	writeFiles(t, filepath.Join(modsDir, "skin_1"), map[string]string{
+		"RAW/data/a.bin": "own raw a",
+		"RAW/ui/a.bin":   "own raw ui",
+	})
+	writeFiles(t, filepath.Join(modsDir, "skin_2"), map[string]string{
+		"RAW/DATA/A.bin":                 "teammate raw a",
+		"WAD/Ahri.wad.client/data/b.bin": "teammate b",
+	})
+
+	overlayDir := t.TempDir()
+	mods := []Mod{{"skin_1", CategoryOwn}, {"skin_2", CategoryTeammate}}
+	if err := (NativeBuilder{}).Build(context.Background(), modsDir, overlayDir, gameDir, mods); err != nil {
+		t.Fatal(err)
+	}
+
+	champ := readWAD(t, filepath.Join(overlayDir, "DATA", "FINAL", "Champions", "Ahri.wad.client"))
+	ui := readWAD(t, filepath.Join(overlayDir, "DATA", "FINAL", "UI.wad.client"))
+	tests := []struct {
+		wad  map[uint64]string
+		path string
+		want string
+	}{
+		{champ, "data/a.bin", "own raw a"}, // own RAW beats teammate RAW
+		{champ, "data/b.bin", "teammate b"},
+		{champ, "data/c.bin", "base c"},
+		{ui, "ui/a.bin", "own raw ui"}, // routed to the WAD holding the path
+	}
+	for _, tt := range tests {
+		if got := tt.wad[wad.HashPath(tt.path)]; got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// A RAW file no game WAD holds cannot be placed
+	writeFiles(t, filepath.Join(modsDir, "skin_3"), map[string]string{"RAW/data/new.bin": "x"})
+	err := (NativeBuilder{}).Build(context.Background(), modsDir, t.TempDir(), gameDir, []Mod{{"skin_3", CategoryOwn}})
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) || buildErr.Kind != BuildFailed || buildErr.Mod != "skin_3" {
+		t.Errorf("unknown RAW file: got %v, want a failed build traced to skin_3", err)
+	}
+}
+
+func TestNativeBuildTracesMod(t *testing.T) {
+	gameDir := gameFixture(t)
+	modsDir := t.TempDir()
+	writeFiles(t, filepath.Join(modsDir, "skin_1"), map[string]string{"WAD/Ahri.wad.client/data/a.bin": "own a"})
+	writeFiles(t, filepath.Join(modsDir, "skin_2"), map[string]string{"WAD/Ahri.wad.client": "not a wad"})
+
+	mods := []Mod{{"skin_1", CategoryOwn}, {"skin_2", CategoryTeammate}}
+	err := (NativeBuilder{}).Build(context.Background(), modsDir, t.TempDir(), gameDir, mods)
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) || buildErr.Mod != "skin_2" {
+		t.Errorf("corrupt teammate WAD: got %v, want a failed build traced to skin_2", err)
+	}
+}
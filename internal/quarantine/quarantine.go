@@ -0,0 +1,229 @@
+// Package quarantine links apply and game failures to skin IDs and sets
+// aside skins that keep failing, so random picks and teammate builds stop
+// using them until the user releases them.
+package quarantine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Failure reasons.
+const (
+	ReasonBuild   = "build"   // mkoverlay failed
+	ReasonOverlay = "overlay" // runoverlay failed to start or crashed
+	ReasonCrash   = "crash"   // the game exited shortly after the hook
+)
+
+// DefaultThreshold is how many failures in a row quarantine a skin.
+const DefaultThreshold = 3
+
+// DefaultCrashWindow is how soon after the hook a game exit counts as a crash.
+const DefaultCrashWindow = 60 * time.Second
+
+// maxFailures caps the failures kept per skin.
+const maxFailures = 10
+
+// Suspects returns the skins a failure is counted against. traced is the
+// skin the failure was traced to, if any. An untraced failure of an overlay
+// that included teammate skins is counted against the teammates only: the
+// own skin is the one the user picked, and a teammate's broken mod must not
+// get it quarantined.
+func Suspects(own string, teammates []string, traced string) []string {
+	if traced != "" {
+		return []string{traced}
+	}
+	var ids []string
+	for _, id := range teammates {
+		if id != "" && id != own {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 && own != "" {
+		ids = append(ids, own)
+	}
+	return ids
+}
+
+// Failure is one failure attributed to a skin.
+type Failure struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Skin is the failure record of one skin ID.
+type Skin struct {
+	SkinID      string    `json:"skinId"`
+	Failures    []Failure `json:"failures"` // since the last success, oldest first
+	Quarantined bool      `json:"quarantined"`
+	Since       time.Time `json:"since,omitempty"` // when it was quarantined
+}
+
+// Store keeps skin failure records, persisted as JSON.
+type Store struct {
+	path string
+
+	mu        sync.Mutex
+	threshold int
+	loaded    bool
+	skins     map[string]*Skin
+}
+
+// NewStore creates a store backed by path that quarantines a skin after
+// threshold failures in a row (DefaultThreshold if threshold <= 0).
+func NewStore(path string, threshold int) *Store {
+	s := &Store{path: path}
+	s.SetThreshold(threshold)
+	return s
+}
+
+// SetThreshold changes the failure threshold for future failures.
+func (s *Store) SetThreshold(threshold int) {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	s.mu.Lock()
+	s.threshold = threshold
+	s.mu.Unlock()
+}
+
+// load reads the file once. Caller must hold mu.
+func (s *Store) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.skins = make(map[string]*Skin)
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var list []*Skin
+	if json.Unmarshal(raw, &list) != nil {
+		return
+	}
+	for _, skin := range list {
+		if skin != nil && skin.SkinID != "" {
+			s.skins[skin.SkinID] = skin
+		}
+	}
+}
+
+// save writes the file. Caller must hold mu.
+func (s *Store) save() error {
+	raw, err := json.MarshalIndent(s.list(false), "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(s.path), os.ModePerm)
+	return os.WriteFile(s.path, raw, 0644)
+}
+
+// list copies the records sorted by skin ID. Caller must hold mu.
+func (s *Store) list(quarantinedOnly bool) []Skin {
+	out := make([]Skin, 0, len(s.skins))
+	for _, skin := range s.skins {
+		if quarantinedOnly && !skin.Quarantined {
+			continue
+		}
+		cp := *skin
+		cp.Failures = append([]Failure(nil), skin.Failures...)
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SkinID < out[j].SkinID })
+	return out
+}
+
+// RecordFailure counts a failure against every skin in skinIDs and returns
+// the skins it pushed into quarantine.
+func (s *Store) RecordFailure(skinIDs []string, reason, detail string) ([]Skin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	now := time.Now()
+	var quarantined []Skin
+	for _, id := range skinIDs {
+		if id == "" {
+			continue
+		}
+		skin := s.skins[id]
+		if skin == nil {
+			skin = &Skin{SkinID: id}
+			s.skins[id] = skin
+		}
+		skin.Failures = append(skin.Failures, Failure{Time: now, Reason: reason, Detail: detail})
+		if len(skin.Failures) > maxFailures {
+			skin.Failures = skin.Failures[len(skin.Failures)-maxFailures:]
+		}
+		if !skin.Quarantined && len(skin.Failures) >= s.threshold {
+			skin.Quarantined = true
+			skin.Since = now
+			quarantined = append(quarantined, *skin)
+		}
+	}
+	return quarantined, s.save()
+}
+
+// RecordSuccess forgets the failures of skins that are not quarantined,
+// so only failures in a row count towards the threshold.
+func (s *Store) RecordSuccess(skinIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	changed := false
+	for _, id := range skinIDs {
+		if skin := s.skins[id]; skin != nil && !skin.Quarantined {
+			delete(s.skins, id)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// IsQuarantined reports whether a skin is quarantined.
+func (s *Store) IsQuarantined(skinID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	skin := s.skins[skinID]
+	return skin != nil && skin.Quarantined
+}
+
+// Quarantined returns every quarantined skin.
+func (s *Store) Quarantined() []Skin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.list(true)
+}
+
+// All returns every skin with a failure record, quarantined or not.
+func (s *Store) All() []Skin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.list(false)
+}
+
+// Release takes a skin out of quarantine and forgets its failures.
+// Returns false if the skin had no record.
+func (s *Store) Release(skinID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	if _, ok := s.skins[skinID]; !ok {
+		return false, nil
+	}
+	delete(s.skins, skinID)
+	return true, s.save()
+}
@@ -0,0 +1,162 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestStore(t *testing.T, threshold int) *Store {
+	t.Helper()
+	return NewStore(filepath.Join(t.TempDir(), "quarantine.json"), threshold)
+}
+
+func TestThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		failures  int // failures recorded in a row
+		want      int // failure that quarantines the skin (1-based), 0 for never
+	}{
+		{"default", 0, 5, DefaultThreshold},
+		{"negative uses default", -1, 5, DefaultThreshold},
+		{"one strike", 1, 3, 1},
+		{"five", 5, 5, 5},
+		{"below threshold", 5, 4, 0},
+	}
+	for _, tt := range tests {
+		s := newTestStore(t, tt.threshold)
+		got := 0
+		for i := 1; i <= tt.failures; i++ {
+			quarantined, err := s.RecordFailure([]string{"1001"}, ReasonBuild, "exit 1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(quarantined) > 0 {
+				if got != 0 {
+					t.Errorf("%s: quarantined again at failure %d", tt.name, i)
+				}
+				got = i
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: quarantined at failure %d, want %d", tt.name, got, tt.want)
+		}
+		if want := tt.want != 0; s.IsQuarantined("1001") != want {
+			t.Errorf("%s: IsQuarantined = %v, want %v", tt.name, !want, want)
+		}
+	}
+}
+
+func TestFailuresInARow(t *testing.T) {
+	s := newTestStore(t, 3)
+	fail := func(ids ...string) []Skin {
+		t.Helper()
+		q, err := s.RecordFailure(ids, ReasonCrash, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q
+	}
+
+	// A success in between starts the count over
+	fail("1001", "2002")
+	fail("1001", "2002")
+	s.RecordSuccess([]string{"1001"})
+	if q := fail("1001", "2002"); len(q) != 1 || q[0].SkinID != "2002" {
+		t.Fatalf("got %+v, want only 2002 quarantined", q)
+	}
+	if s.IsQuarantined("1001") {
+		t.Error("1001 quarantined despite a success")
+	}
+
+	// Success does not release a quarantined skin, only Release does
+	s.RecordSuccess([]string{"2002"})
+	if !s.IsQuarantined("2002") {
+		t.Error("success released a quarantined skin")
+	}
+	if ok, err := s.Release("2002"); !ok || err != nil {
+		t.Errorf("Release = %v, %v", ok, err)
+	}
+	if s.IsQuarantined("2002") || len(s.Quarantined()) != 0 {
+		t.Error("2002 still quarantined after Release")
+	}
+	if ok, _ := s.Release("2002"); ok {
+		t.Error("Release of an unknown skin returned true")
+	}
+
+	// Empty IDs (e.g. unknown teammate skins) are ignored
+	fail("", "")
+	if all := s.All(); len(all) != 1 || all[0].SkinID != "1001" {
+		t.Errorf("All = %+v, want only 1001", all)
+	}
+}
+
+func TestSetThreshold(t *testing.T) {
+	s := newTestStore(t, 5)
+	s.RecordFailure([]string{"1001"}, ReasonBuild, "")
+	s.RecordFailure([]string{"1001"}, ReasonBuild, "")
+
+	// Lowering the threshold applies from the next failure on
+	s.SetThreshold(2)
+	if s.IsQuarantined("1001") {
+		t.Error("lowering the threshold quarantined retroactively")
+	}
+	if q, _ := s.RecordFailure([]string{"1001"}, ReasonBuild, ""); len(q) != 1 {
+		t.Errorf("got %+v, want 1001 quarantined past the new threshold", q)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+	s := NewStore(path, 20)
+	for i := 0; i < maxFailures+5; i++ {
+		s.RecordFailure([]string{"1001"}, ReasonOverlay, "")
+	}
+	s.RecordFailure([]string{"2002"}, ReasonBuild, "exit 3")
+	s.SetThreshold(1)
+	s.RecordFailure([]string{"3003"}, ReasonCrash, "")
+
+	reloaded := NewStore(path, 20)
+	all := reloaded.All()
+	if len(all) != 3 {
+		t.Fatalf("reloaded %d skins, want 3", len(all))
+	}
+	if n := len(all[0].Failures); n != maxFailures {
+		t.Errorf("1001 kept %d failures, want %d", n, maxFailures)
+	}
+	if f := all[1].Failures[0]; f.Reason != ReasonBuild || f.Detail != "exit 3" {
+		t.Errorf("2002 failure = %+v", f)
+	}
+	if !reloaded.IsQuarantined("3003") || all[2].Since.IsZero() {
+		t.Errorf("3003 = %+v, want quarantined with a time", all[2])
+	}
+
+	os.WriteFile(path, []byte("not json"), 0644)
+	if all := NewStore(path, 0).All(); len(all) != 0 {
+		t.Errorf("corrupt file: got %+v, want empty", all)
+	}
+}
+
+func TestSuspects(t *testing.T) {
+	tests := []struct {
+		name      string
+		own       string
+		teammates []string
+		traced    string
+		want      []string
+	}{
+		{"own skin alone", "1001", nil, "", []string{"1001"}},
+		{"teammates included", "1001", []string{"2002", "3003"}, "", []string{"2002", "3003"}},
+		{"teammate with the same skin", "1001", []string{"1001"}, "", []string{"1001"}},
+		{"traced to the own skin", "1001", []string{"2002"}, "1001", []string{"1001"}},
+		{"traced to a teammate", "1001", []string{"2002", "3003"}, "3003", []string{"3003"}},
+		{"nothing applied", "", nil, "", nil},
+	}
+	for _, tt := range tests {
+		if got := Suspects(tt.own, tt.teammates, tt.traced); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Suspects = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
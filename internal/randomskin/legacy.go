@@ -0,0 +1,69 @@
+package randomskin
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/history"
+)
+
+// legacyUsage is one skin in the per-champion usage file written before the
+// apply history existed: championID -> skinID -> usage.
+type legacyUsage struct {
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// MigrateUsage imports the old skin usage file at path into log, so rotation
+// and top-3 picks keep their ranking, then renames it to path + ".migrated".
+// Each recorded use becomes a successful apply at the skin's last use time.
+// Returns the number of imported applies; a missing file imports nothing.
+func MigrateUsage(path string, log *history.Log) (int, error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var usage map[string]map[string]legacyUsage
+	if err := json.Unmarshal(raw, &usage); err != nil {
+		return 0, err
+	}
+
+	var entries []history.Entry
+	for championID, skins := range usage {
+		for skinID, u := range skins {
+			count := u.Count
+			if count > history.DefaultMaxEntries {
+				count = history.DefaultMaxEntries
+			}
+			for i := 0; i < count; i++ {
+				entries = append(entries, history.Entry{
+					Time:       u.LastUsed,
+					ChampionID: championID,
+					SkinID:     skinID,
+					Success:    true,
+				})
+			}
+		}
+	}
+	// Map order is random; keep the import stable
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.ChampionID != b.ChampionID {
+			return a.ChampionID < b.ChampionID
+		}
+		return a.SkinID < b.SkinID
+	})
+
+	if err := log.Import(entries); err != nil {
+		return 0, err
+	}
+	return len(entries), os.Rename(path, path+".migrated")
+}
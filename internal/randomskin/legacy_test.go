@@ -0,0 +1,66 @@
+package randomskin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hoangvu12/ame/internal/history"
+)
+
+func TestMigrateUsage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "skin_usage.json")
+	log := history.NewLog(filepath.Join(dir, "history.jsonl"), 0)
+
+	if n, err := MigrateUsage(path, log); n != 0 || err != nil {
+		t.Fatalf("missing file: got %d, %v", n, err)
+	}
+
+	os.WriteFile(path, []byte(`{
+		"103": {
+			"103001": {"count": 3, "lastUsed": "2026-01-02T00:00:00Z"},
+			"103002": {"count": 1, "lastUsed": "2026-01-03T00:00:00Z"}
+		},
+		"7": {"7001": {"count": 2, "lastUsed": "2026-01-01T00:00:00Z"}}
+	}`), 0644)
+	n, err := MigrateUsage(path, log)
+	if n != 6 || err != nil {
+		t.Fatalf("got %d, %v; want 6 applies", n, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("old file not renamed")
+	}
+	if _, err := os.Stat(path + ".migrated"); err != nil {
+		t.Error(err)
+	}
+
+	most := log.MostUsed("103", 0)
+	if len(most) != 2 || most[0].SkinID != "103001" || most[0].Uses != 3 {
+		t.Errorf("MostUsed = %+v, want 103001 with 3 uses", most)
+	}
+	if most[1].LastUsed.Format("2006-01-02") != "2026-01-03" {
+		t.Errorf("103002 last used %v", most[1].LastUsed)
+	}
+
+	// Rotation picks up the imported order
+	e := NewEngine(log)
+	e.Candidates = candidates("103001", "103002", "103003")
+	if got, _ := e.Pick("103", ModeRotation); got.SkinID != "103003" {
+		t.Errorf("rotation picked %s, want the never used 103003", got.SkinID)
+	}
+
+	// Already migrated: nothing to do
+	if n, err := MigrateUsage(path, log); n != 0 || err != nil {
+		t.Errorf("second run: got %d, %v", n, err)
+	}
+}
+
+func TestMigrateUsageCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "skin_usage.json")
+	os.WriteFile(path, []byte("{"), 0644)
+	if _, err := MigrateUsage(path, history.NewLog(filepath.Join(dir, "history.jsonl"), 0)); err == nil {
+		t.Error("want error for a corrupt file")
+	}
+}
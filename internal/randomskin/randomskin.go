@@ -0,0 +1,222 @@
+// Package randomskin picks a skin for a champion when random mode is on,
+// using the player's apply history.
+package randomskin
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/history"
+	"github.com/hoangvu12/ame/internal/skin"
+)
+
+// Random skin modes, as stored in config.RandomSkin.
+const (
+	ModeOff       = ""
+	ModeAll       = "all"       // uniform over every available skin
+	ModeRotation  = "rotation"  // least recently used first
+	ModeFavorites = "favorites" // weighted towards favorites
+	ModeTop3      = "top3"      // uniform over the 3 most used skins
+)
+
+// TopN is how many of the most used skins ModeTop3 picks from.
+const TopN = 3
+
+// FavoriteWeight is the weight of a favorite skin relative to 1 for others.
+const FavoriteWeight = 5.0
+
+// ErrNoCandidates is returned when no skin of the champion can be picked.
+var ErrNoCandidates = errors.New("no skins available")
+
+// ValidMode reports whether mode is a known random skin mode.
+func ValidMode(mode string) bool {
+	switch mode {
+	case ModeOff, ModeAll, ModeRotation, ModeFavorites, ModeTop3:
+		return true
+	}
+	return false
+}
+
+// Engine picks random skins. The func fields are optional hooks; nil means
+// the default behaviour described on each.
+type Engine struct {
+	History *history.Log
+
+	// Candidates lists a champion's skins. Defaults to skin.Candidates.
+	Candidates func(championID string) ([]skin.Candidate, error)
+	// Favorites lists a champion's favorite skin IDs. Nil means none.
+	Favorites func(championID string) []string
+	// Skip excludes extra skins, e.g. ones known to break. Nil skips nothing.
+	Skip func(championID, skinID string) bool
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	failed map[string]bool // championID + "/" + skinID that failed to apply this session
+}
+
+// NewEngine creates an engine that ranks skins by the applies in log.
+func NewEngine(log *history.Log) *Engine {
+	return &Engine{
+		History: log,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		failed:  make(map[string]bool),
+	}
+}
+
+// MarkSucceeded makes a skin that failed earlier pickable again. Chromas
+// count towards their base skin.
+func (e *Engine) MarkSucceeded(championID, skinID, baseSkinID string) {
+	if baseSkinID != "" {
+		skinID = baseSkinID
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.failed, championID+"/"+skinID)
+}
+
+// MarkFailed excludes a skin from picks until it applies successfully or
+// ame restarts.
+func (e *Engine) MarkFailed(championID, skinID, baseSkinID string) {
+	if baseSkinID != "" {
+		skinID = baseSkinID
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failed[championID+"/"+skinID] = true
+}
+
+// Available returns the skins Pick can choose from: non-base skins (not
+// chromas) that are cached or downloadable and not skipped or failed.
+func (e *Engine) Available(championID string) ([]skin.Candidate, error) {
+	list := e.Candidates
+	if list == nil {
+		list = skin.Candidates
+	}
+	all, err := list(championID)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out []skin.Candidate
+	for _, c := range all {
+		if c.IsBase || c.BaseSkinID != "" || !(c.Downloadable || c.Cached) {
+			continue
+		}
+		if e.failed[championID+"/"+c.SkinID] {
+			continue
+		}
+		if e.Skip != nil && e.Skip(championID, c.SkinID) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// Pick chooses a skin for championID using mode. ModeOff behaves like ModeAll.
+func (e *Engine) Pick(championID, mode string) (skin.Candidate, error) {
+	candidates, err := e.Available(championID)
+	if err != nil {
+		return skin.Candidate{}, err
+	}
+	if len(candidates) == 0 {
+		return skin.Candidate{}, ErrNoCandidates
+	}
+
+	usage := make(map[string]history.SkinStats)
+	for _, s := range e.History.Stats(championID) {
+		usage[s.SkinID] = s
+	}
+	switch mode {
+	case ModeRotation:
+		candidates = leastRecent(candidates, usage)
+	case ModeTop3:
+		candidates = mostUsed(candidates, usage, TopN)
+	case ModeFavorites:
+		var favorites []string
+		if e.Favorites != nil {
+			favorites = e.Favorites(championID)
+		}
+		return e.weighted(candidates, favorites), nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return candidates[e.rng.Intn(len(candidates))], nil
+}
+
+// leastRecent keeps the candidates used longest ago. Never-used skins come
+// first, so a rotation goes through every skin before repeating.
+func leastRecent(candidates []skin.Candidate, usage map[string]history.SkinStats) []skin.Candidate {
+	var oldest time.Time
+	var out []skin.Candidate
+	for _, c := range candidates {
+		last := usage[c.SkinID].LastUsed
+		switch {
+		case out == nil || last.Before(oldest):
+			oldest = last
+			out = []skin.Candidate{c}
+		case last.Equal(oldest):
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// mostUsed keeps the n most used candidates. With no usage at all every
+// candidate is kept.
+func mostUsed(candidates []skin.Candidate, usage map[string]history.SkinStats, n int) []skin.Candidate {
+	var used []skin.Candidate
+	for _, c := range candidates {
+		if usage[c.SkinID].Uses > 0 {
+			used = append(used, c)
+		}
+	}
+	if len(used) == 0 {
+		return candidates
+	}
+	sort.SliceStable(used, func(i, j int) bool {
+		a, b := usage[used[i].SkinID], usage[used[j].SkinID]
+		if a.Uses != b.Uses {
+			return a.Uses > b.Uses
+		}
+		return a.LastUsed.After(b.LastUsed)
+	})
+	if len(used) > n {
+		used = used[:n]
+	}
+	return used
+}
+
+// weighted picks with FavoriteWeight for favorites and 1 for the rest.
+func (e *Engine) weighted(candidates []skin.Candidate, favorites []string) skin.Candidate {
+	isFavorite := make(map[string]bool, len(favorites))
+	for _, id := range favorites {
+		isFavorite[id] = true
+	}
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, c := range candidates {
+		weights[i] = 1
+		if isFavorite[c.SkinID] {
+			weights[i] = FavoriteWeight
+		}
+		total += weights[i]
+	}
+
+	e.mu.Lock()
+	r := e.rng.Float64() * total
+	e.mu.Unlock()
+	for i, w := range weights {
+		if r < w {
+			return candidates[i]
+		}
+		r -= w
+	}
+	return candidates[len(candidates)-1]
+}
@@ -0,0 +1,130 @@
+package randomskin
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/history"
+	"github.com/hoangvu12/ame/internal/skin"
+)
+
+// candidates returns a Candidates hook listing downloadable skins.
+func candidates(ids ...string) func(string) ([]skin.Candidate, error) {
+	return func(string) ([]skin.Candidate, error) {
+		var out []skin.Candidate
+		for _, id := range ids {
+			out = append(out, skin.Candidate{SkinID: id, Downloadable: true})
+		}
+		return out, nil
+	}
+}
+
+func newEngine(t *testing.T, uses map[string]int) *Engine {
+	t.Helper()
+	log := history.NewLog(filepath.Join(t.TempDir(), "history.jsonl"), 0)
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		for i := 0; i < uses[id]; i++ {
+			at = at.Add(time.Minute)
+			log.Add(history.Entry{Time: at, ChampionID: "c", SkinID: id, Success: true})
+		}
+	}
+	e := NewEngine(log)
+	e.Candidates = candidates("1", "2", "3", "4", "5")
+	return e
+}
+
+// picks returns the set of skins chosen over many picks.
+func picks(t *testing.T, e *Engine, mode string) map[string]int {
+	t.Helper()
+	got := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		c, err := e.Pick("c", mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[c.SkinID]++
+	}
+	return got
+}
+
+func TestPickModes(t *testing.T) {
+	uses := map[string]int{"1": 4, "2": 1, "3": 3, "4": 2}
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{ModeAll, []string{"1", "2", "3", "4", "5"}},
+		{ModeRotation, []string{"5"}}, // never used
+		{ModeTop3, []string{"1", "3", "4"}},
+	}
+	for _, tt := range tests {
+		got := picks(t, newEngine(t, uses), tt.mode)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: picked %v, want only %v", tt.mode, got, tt.want)
+			continue
+		}
+		for _, id := range tt.want {
+			if got[id] == 0 {
+				t.Errorf("%s: never picked %s (%v)", tt.mode, id, got)
+			}
+		}
+	}
+}
+
+func TestPickRotationOrder(t *testing.T) {
+	// Every skin used once: the one used longest ago comes next
+	e := newEngine(t, map[string]int{"1": 1, "2": 1, "3": 1, "4": 1, "5": 1})
+	if got := picks(t, e, ModeRotation); len(got) != 1 || got["1"] == 0 {
+		t.Errorf("picked %v, want only 1", got)
+	}
+}
+
+func TestPickFavorites(t *testing.T) {
+	e := newEngine(t, nil)
+	e.Favorites = func(string) []string { return []string{"3"} }
+	got := picks(t, e, ModeFavorites)
+	// Weight 5 of 9: expect ~167 of 300, far above the ~33 of each other skin
+	if got["3"] < 100 {
+		t.Errorf("favorite picked %d of 300 times (%v)", got["3"], got)
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	e := newEngine(t, nil)
+	e.Candidates = func(string) ([]skin.Candidate, error) {
+		return []skin.Candidate{
+			{SkinID: "0", IsBase: true, Downloadable: true},
+			{SkinID: "1", Downloadable: true},
+			{SkinID: "2", Cached: true},
+			{SkinID: "3"}, // neither downloadable nor cached
+			{SkinID: "4", BaseSkinID: "1", Downloadable: true},
+			{SkinID: "5", Downloadable: true},
+			{SkinID: "6", Downloadable: true},
+		}, nil
+	}
+	e.Skip = func(_, id string) bool { return id == "5" }
+	e.MarkFailed("c", "6", "")
+	e.MarkFailed("c", "4", "2") // chroma failure counts towards its base
+
+	ids := func() (out []string) {
+		list, _ := e.Available("c")
+		for _, c := range list {
+			out = append(out, c.SkinID)
+		}
+		return out
+	}
+	if got := ids(); len(got) != 1 || got[0] != "1" {
+		t.Errorf("Available = %v, want [1]", got)
+	}
+	e.MarkSucceeded("c", "6", "")
+	if got := ids(); len(got) != 2 {
+		t.Errorf("after success Available = %v, want [1 6]", got)
+	}
+
+	e.Candidates = candidates()
+	if _, err := e.Pick("c", ModeAll); err != ErrNoCandidates {
+		t.Errorf("no skins: got %v, want ErrNoCandidates", err)
+	}
+}
@@ -0,0 +1,63 @@
+package roomparty
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+)
+
+// signingDomain separates room party keys from any other use of the secret.
+const signingDomain = "ame-room-party-v1"
+
+// deriveKey derives a room's signing key from its roomKey and the secret
+// only its players share (the champ select chat password, which never
+// reaches the room server).
+func deriveKey(roomKey, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(signingDomain))
+	mac.Write([]byte(roomKey))
+	mac.Write([]byte{0})
+	mac.Write([]byte(secret))
+	return mac.Sum(nil)
+}
+
+// skinSignature signs a member's skin info. The room key and puuid are
+// covered so a signature can't be replayed in another room or by another player.
+func skinSignature(key []byte, roomKey, puuid string, info SkinInfo) string {
+	mac := hmac.New(sha256.New, key)
+	for _, field := range []string{roomKey, puuid, info.ChampionID, info.SkinID, info.BaseSkinID, info.ChampionName, info.SkinName, info.ChromaName} {
+		mac.Write([]byte(field))
+		mac.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// lanSignature signs a LAN packet. It covers the packet type, so a captured
+// announce can't be replayed as a leave, the send time, which lanSeen
+// checks against replays, and the member's skin signature, which covers the
+// rest of the member.
+func lanSignature(key []byte, p lanPacket) string {
+	mac := hmac.New(sha256.New, key)
+	for _, field := range []string{"lan", p.Room, p.Type, strconv.FormatInt(p.Time, 10), p.Member.Puuid, p.Member.SkinInfo.Signature} {
+		mac.Write([]byte(field))
+		mac.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyLAN reports whether a LAN packet was signed with key.
+func verifyLAN(key []byte, p lanPacket) bool {
+	if p.Sig == "" {
+		return false
+	}
+	return hmac.Equal([]byte(p.Sig), []byte(lanSignature(key, p)))
+}
+
+// verifySkin reports whether info carries a valid signature for puuid.
+func verifySkin(key []byte, roomKey, puuid string, info SkinInfo) bool {
+	if info.Signature == "" {
+		return false
+	}
+	want := skinSignature(key, roomKey, puuid, info)
+	return hmac.Equal([]byte(info.Signature), []byte(want))
+}
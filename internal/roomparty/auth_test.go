@@ -0,0 +1,158 @@
+package roomparty
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hoangvu12/ame/internal/display"
+	"github.com/hoangvu12/ame/internal/roomparty/server"
+)
+
+func TestDeriveKey(t *testing.T) {
+	key := deriveKey("room", "secret")
+	if len(key) != 32 || !bytes.Equal(key, deriveKey("room", "secret")) {
+		t.Fatalf("deriveKey not a stable 32-byte key: %x", key)
+	}
+	others := map[string][]byte{
+		"other secret":     deriveKey("room", "secret2"),
+		"other room":       deriveKey("room2", "secret"),
+		"shifted boundary": deriveKey("roomsecret", ""),
+		"swapped":          deriveKey("secret", "room"),
+	}
+	for name, other := range others {
+		if bytes.Equal(key, other) {
+			t.Errorf("%s: same key", name)
+		}
+	}
+}
+
+func TestSkinSignature(t *testing.T) {
+	key := deriveKey("room", "secret")
+	info := SkinInfo{ChampionID: "103", SkinID: "103001", ChampionName: "Ahri", SkinName: "Dynasty Ahri"}
+	info.Signature = skinSignature(key, "room", "pa", info)
+	if !verifySkin(key, "room", "pa", info) {
+		t.Fatal("valid signature rejected")
+	}
+
+	tampered := []struct {
+		name   string
+		key    []byte
+		room   string
+		puuid  string
+		mutate func(*SkinInfo)
+	}{
+		{"wrong key", deriveKey("room", "guess"), "room", "pa", nil},
+		{"other room", key, "room2", "pa", nil},
+		{"other player", key, "room", "pb", nil},
+		{"skin", key, "room", "pa", func(s *SkinInfo) { s.SkinID = "103002" }},
+		{"champion", key, "room", "pa", func(s *SkinInfo) { s.ChampionID = "1" }},
+		{"base skin", key, "room", "pa", func(s *SkinInfo) { s.BaseSkinID = "103001" }},
+		{"name", key, "room", "pa", func(s *SkinInfo) { s.SkinName = "Other" }},
+		{"chroma", key, "room", "pa", func(s *SkinInfo) { s.ChromaName = "Ruby" }},
+		{"shifted boundary", key, "room", "pa", func(s *SkinInfo) { s.ChampionName, s.SkinName = "AhriDynasty", " Ahri" }},
+		{"no signature", key, "room", "pa", func(s *SkinInfo) { s.Signature = "" }},
+		{"garbage signature", key, "room", "pa", func(s *SkinInfo) { s.Signature = "x" }},
+	}
+	for _, tt := range tampered {
+		got := info
+		if tt.mutate != nil {
+			tt.mutate(&got)
+		}
+		if verifySkin(tt.key, tt.room, tt.puuid, got) {
+			t.Errorf("%s: tampered skin info verified", tt.name)
+		}
+	}
+}
+
+// logCount counts display log lines containing s.
+func logCount(s string) int {
+	n := 0
+	for _, e := range display.GetLogsJSON() {
+		if strings.Contains(e.Message, s) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestVerifyMembers(t *testing.T) {
+	key := deriveKey("room", "secret")
+	signed := func(puuid string, info SkinInfo) Member {
+		info.Signature = skinSignature(key, "room", puuid, info)
+		return Member{Puuid: puuid, SkinInfo: info}
+	}
+	members := []Member{
+		signed("p-no-skin", SkinInfo{ChampionName: "Ahri"}),
+		{Puuid: "p-old-peer", SkinInfo: SkinInfo{ChampionID: "103", SkinID: "103001"}},
+		signed("p-forged", SkinInfo{ChampionID: "103", SkinID: "103001"}),
+		signed("p-wrong-champ", SkinInfo{ChampionID: "103", SkinID: "1001"}),
+	}
+	members[2].Puuid = "p-forged-as" // signed for another player
+
+	logged := make(map[string]int) // display logs are global: count new lines only
+	for _, m := range members {
+		logged[m.Puuid] = logCount("dropped unverified member " + shortPuuid(m.Puuid))
+	}
+	rs := NewRoomState()
+	rs.rejected = make(map[string]string)
+	for round := 0; round < 2; round++ {
+		got := rs.verifyMembers(members, key, "room")
+		if len(got) != 1 || got[0].Puuid != "p-no-skin" || got[0].SkinInfo.Signature != "" {
+			t.Fatalf("verified %+v, want only p-no-skin with its signature stripped", got)
+		}
+	}
+
+	want := map[string]string{
+		"p-old-peer":    "not signed",
+		"p-forged-as":   "invalid signature",
+		"p-wrong-champ": "does not belong to champion 103",
+	}
+	if len(rs.rejected) != len(want) {
+		t.Errorf("rejected = %v", rs.rejected)
+	}
+	for puuid, reason := range want {
+		if !strings.Contains(rs.rejected[puuid], reason) {
+			t.Errorf("%s rejected for %q, want %q", puuid, rs.rejected[puuid], reason)
+		}
+		// Logged once, not on every member list
+		if n := logCount("dropped unverified member "+shortPuuid(puuid)) - logged[puuid]; n != 1 {
+			t.Errorf("%s logged %d times, want 1", puuid, n)
+		}
+	}
+
+	// A member that fixes its skin is accepted again
+	rs.verifyMembers([]Member{signed("p-wrong-champ", SkinInfo{})}, key, "room")
+	if _, ok := rs.rejected["p-wrong-champ"]; ok {
+		t.Error("accepted member still rejected")
+	}
+}
+
+func TestUnsignedPeerStatus(t *testing.T) {
+	url := startServer(t, server.New())
+	a := newMember(t, url)
+	a.Join("room", "secret", "pa", []string{"p-old"})
+
+	// An older ame joins with unsigned skin info
+	old, _, err := websocket.DefaultDialer.Dial(url+"/?roomKey=room", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	old.WriteMessage(websocket.TextMessage, []byte(`{"type":"join","puuid":"p-old","skinInfo":{"championId":"103","skinId":"103001"}}`))
+
+	waitFor(t, "unverified status", 5*time.Second, func() bool { return len(a.TeammateStatuses()) == 1 })
+	status := a.TeammateStatuses()[0]
+	if status.Puuid != "p-old" || status.Included || status.Reason != ReasonUnverified || !strings.Contains(status.Detail, "not signed") {
+		t.Errorf("status = %+v, want p-old unverified as unsigned", status)
+	}
+	if len(a.GetTeammates()) != 0 {
+		t.Error("unsigned member became a teammate")
+	}
+
+	// Once it leaves the room, the rejection is forgotten
+	old.Close()
+	waitFor(t, "rejection cleared", 5*time.Second, func() bool { return len(a.TeammateStatuses()) == 0 })
+}
@@ -0,0 +1,51 @@
+package roomparty
+
+import (
+	"math/rand"
+	"time"
+)
+
+// ConnState is the state of the connection to the room server.
+type ConnState string
+
+const (
+	StateIdle         ConnState = "idle"         // not in a room
+	StateConnecting   ConnState = "connecting"   // first connection of the session
+	StateConnected    ConnState = "connected"    // joined and receiving members
+	StateReconnecting ConnState = "reconnecting" // waiting to retry after a failure or drop
+	StateFailed       ConnState = "failed"       // gave up until the next Join
+)
+
+// Reconnect policy. Retries are counted per Join, i.e. per champ select.
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 30 * time.Second
+	maxRetries = 10
+)
+
+// readTimeout detects half-open sockets: the server answers every heartbeat
+// ping, so nothing for two intervals means the connection is dead.
+const readTimeout = 2*heartbeatInterval + 5*time.Second
+
+// Connection describes the room server connection.
+type Connection struct {
+	State     ConnState `json:"state"`
+	Retries   int       `json:"retries,omitempty"`   // reconnect attempts so far this session
+	LastError string    `json:"lastError,omitempty"` // why the last attempt failed or dropped
+	NextRetry time.Time `json:"nextRetry,omitempty"` // when the next attempt starts, while reconnecting
+}
+
+// backoffDelay returns the wait before retry n (1-based): exponential from
+// minBackoff, capped at maxBackoff, with the upper half randomized so
+// teammates that dropped together don't reconnect in lockstep.
+func backoffDelay(n int) time.Duration {
+	d := minBackoff
+	for i := 1; i < n && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
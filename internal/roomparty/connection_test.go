@@ -0,0 +1,78 @@
+package roomparty
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		n        int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, 1 * time.Second},
+		{2, 1 * time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{5, 8 * time.Second, 16 * time.Second},
+		{6, 15 * time.Second, 30 * time.Second},
+		{maxRetries, 15 * time.Second, 30 * time.Second},
+		{1000, 15 * time.Second, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := backoffDelay(tt.n); d < tt.min || d > tt.max {
+				t.Fatalf("backoffDelay(%d) = %v, want within [%v, %v]", tt.n, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestRetryCap(t *testing.T) {
+	// A server that accepts and hangs up: every handshake fails
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	var attempts atomic.Int32
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			attempts.Add(1)
+			c.Close()
+		}
+	}()
+
+	rs := newMember(t, "ws://"+l.Addr().String())
+	var delays []int
+	rs.retryDelay = func(n int) time.Duration {
+		delays = append(delays, n) // only called from the connection loop
+		return time.Millisecond
+	}
+	rs.Join("room", "secret", "pa", nil)
+	waitFor(t, "giving up", 5*time.Second, func() bool { return rs.Connection().State == StateFailed })
+
+	c := rs.Connection()
+	if c.Retries != maxRetries || c.LastError == "" {
+		t.Errorf("connection = %+v, want %d retries and the last error", c, maxRetries)
+	}
+	if got := attempts.Load(); got != maxRetries+1 {
+		t.Errorf("%d connection attempts, want %d", got, maxRetries+1)
+	}
+	for i, n := range delays {
+		if n != i+1 {
+			t.Fatalf("retry delays asked for %v, want 1..%d", delays, maxRetries)
+		}
+	}
+
+	// A new Join starts counting again
+	rs.Join("room", "secret", "pa", nil)
+	if s := rs.Connection().State; s == StateFailed {
+		t.Errorf("state after rejoin = %s", s)
+	}
+}
@@ -0,0 +1,244 @@
+package roomparty
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/config"
+	"github.com/hoangvu12/ame/internal/display"
+)
+
+// DefaultLANAddr is the multicast group LAN discovery uses unless another is set.
+const DefaultLANAddr = "239.255.77.77:47777"
+
+const (
+	lanAnnounceInterval = 5 * time.Second
+	lanPeerTimeout      = 3 * lanAnnounceInterval // peers not heard from for this long are dropped
+	lanMaxPacket        = 4096
+	lanMaxPacketAge     = 30 * time.Second // packets sent longer ago (or ahead, for clock skew) are dropped
+)
+
+// lanPacket is one LAN discovery datagram. Room is a hash of the roomKey so
+// the key itself is never sent in the clear; the member's skin info carries
+// the same signature as on the room server.
+type lanPacket struct {
+	Room   string `json:"room"`
+	Type   string `json:"type"` // "announce" or "leave"
+	Time   int64  `json:"time"` // send time in Unix nanoseconds, increasing per sender
+	Member Member `json:"member"`
+	Sig    string `json:"sig"` // see lanSignature
+}
+
+type lanPeer struct {
+	member Member
+	seen   time.Time
+}
+
+// lanSeen holds the send time of the last packet accepted from each member.
+type lanSeen map[string]int64
+
+// fresh reports whether p was sent recently and after the last packet
+// accepted from its member, and records it if so. A captured packet replayed
+// later is either stale or no newer than one already seen.
+func (s lanSeen) fresh(p lanPacket, now time.Time) bool {
+	age := now.Sub(time.Unix(0, p.Time))
+	if age > lanMaxPacketAge || age < -lanMaxPacketAge {
+		return false
+	}
+	if p.Time <= s[p.Member.Puuid] {
+		return false
+	}
+	s[p.Member.Puuid] = p.Time
+	return true
+}
+
+// lanRoomID hashes a roomKey for LAN packets.
+func lanRoomID(roomKey string) string {
+	sum := sha256.Sum256([]byte("ame-room-lan:" + roomKey))
+	return hex.EncodeToString(sum[:16])
+}
+
+// lanAddr returns the multicast group to use.
+func (rs *RoomState) lanAddr() string {
+	if rs.LANAddr != "" {
+		return rs.LANAddr
+	}
+	return DefaultLANAddr
+}
+
+// lanEnabled reports whether sessions should also use LAN discovery.
+func (rs *RoomState) lanEnabled() bool {
+	return rs.LAN || config.RoomPartyLAN()
+}
+
+// runLAN announces the local member to the room's multicast group and
+// collects teammates announced by others until ctx ends. It runs next to
+// the room server connection, so teammates on the same network are still
+// found when the server is unreachable.
+func (rs *RoomState) runLAN(ctx context.Context, roomKey, puuid string, key []byte, wake chan struct{}) {
+	group, err := net.ResolveUDPAddr("udp4", rs.lanAddr())
+	if err != nil {
+		display.Log(fmt.Sprintf("! Room Party: invalid LAN address: %v", err))
+		return
+	}
+	listener, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		display.Log(fmt.Sprintf("! Room Party: LAN discovery unavailable: %v", err))
+		return
+	}
+	defer listener.Close()
+	if err := listener.SetReadBuffer(lanMaxPacket * 16); err != nil {
+		display.Log(fmt.Sprintf("! Room Party: LAN receive buffer not enlarged: %v", err))
+	}
+	sender, err := net.DialUDP("udp4", nil, group)
+	if err != nil {
+		display.Log(fmt.Sprintf("! Room Party: LAN discovery unavailable: %v", err))
+		return
+	}
+	defer sender.Close()
+
+	room := lanRoomID(roomKey)
+	display.Log(fmt.Sprintf("Room Party: LAN discovery on %s", group))
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	go rs.lanReceive(ctx, listener, roomKey, key, room, wake)
+
+	ticker := time.NewTicker(lanAnnounceInterval)
+	defer ticker.Stop()
+	var sent int64 // send time of the last packet, so every packet is newer
+	send := func(kind string) error {
+		sent = max(time.Now().UnixNano(), sent+1)
+		return rs.lanSend(sender, room, roomKey, puuid, key, kind, sent)
+	}
+	sendFailed := false // log the first failure only, not every announce
+	for {
+		if err := send("announce"); err != nil && !sendFailed {
+			sendFailed = true
+			display.Log(fmt.Sprintf("! Room Party: LAN announce failed: %v", err))
+		}
+		select {
+		case <-ctx.Done():
+			send("leave")
+			return
+		case <-wake:
+		case <-ticker.C:
+			if rs.expireLANPeers() {
+				rs.updateTeammates(roomKey)
+			}
+		}
+	}
+}
+
+// lanSend sends the local member, signed with the session's key, to the
+// group. sent is the packet's send time.
+func (rs *RoomState) lanSend(conn *net.UDPConn, room, roomKey, puuid string, key []byte, kind string, sent int64) error {
+	rs.mu.Lock()
+	info := rs.mySkinInfo
+	rs.mu.Unlock()
+	info.Signature = skinSignature(key, roomKey, puuid, info)
+	packet := lanPacket{Room: room, Type: kind, Time: sent, Member: Member{Puuid: puuid, SkinInfo: info}}
+	packet.Sig = lanSignature(key, packet)
+	data, err := json.Marshal(packet)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(data)
+	return err
+}
+
+// lanReceive reads packets for room until the listener is closed. Packets
+// not signed with the room key, stale or replayed are ignored; announced
+// members are verified again with the rest of the members. A new peer wakes the announcer so it
+// learns about us without waiting.
+func (rs *RoomState) lanReceive(ctx context.Context, conn *net.UDPConn, roomKey string, key []byte, room string, wake chan<- struct{}) {
+	buf := make([]byte, lanMaxPacket)
+	seen := make(lanSeen)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				display.Log(fmt.Sprintf("! Room Party: LAN discovery stopped: %v", err))
+			}
+			return
+		}
+		var packet lanPacket
+		if json.Unmarshal(buf[:n], &packet) != nil || packet.Room != room || packet.Member.Puuid == "" {
+			continue
+		}
+		if !verifyLAN(key, packet) || !seen.fresh(packet, time.Now()) {
+			continue
+		}
+
+		rs.mu.Lock()
+		if ctx.Err() != nil || rs.lanPeers == nil || packet.Member.Puuid == rs.puuid {
+			rs.mu.Unlock()
+			continue
+		}
+		old, known := rs.lanPeers[packet.Member.Puuid]
+		changed := false
+		switch packet.Type {
+		case "announce":
+			rs.lanPeers[packet.Member.Puuid] = lanPeer{member: packet.Member, seen: time.Now()}
+			changed = !known || old.member.SkinInfo != packet.Member.SkinInfo
+		case "leave":
+			if known {
+				delete(rs.lanPeers, packet.Member.Puuid)
+				changed = true
+			}
+		}
+		rs.mu.Unlock()
+
+		if !known && packet.Type == "announce" {
+			display.Log(fmt.Sprintf("Room Party: LAN peer %s found", shortPuuid(packet.Member.Puuid)))
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+		if changed {
+			rs.updateTeammates(roomKey)
+		}
+	}
+}
+
+// expireLANPeers drops peers that stopped announcing and reports whether
+// any were dropped.
+func (rs *RoomState) expireLANPeers() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	expired := false
+	for puuid, peer := range rs.lanPeers {
+		if time.Since(peer.seen) > lanPeerTimeout {
+			delete(rs.lanPeers, puuid)
+			expired = true
+		}
+	}
+	return expired
+}
+
+// mergeMembers combines room server and LAN members. The room server's
+// entry wins when a player is on both. Caller must hold mu.
+func (rs *RoomState) mergeMembers() []Member {
+	members := append([]Member(nil), rs.workerMembers...)
+	seen := make(map[string]bool, len(members))
+	for _, m := range members {
+		seen[m.Puuid] = true
+	}
+	var lan []Member
+	for _, peer := range rs.lanPeers {
+		if !seen[peer.member.Puuid] {
+			lan = append(lan, peer.member)
+		}
+	}
+	sort.Slice(lan, func(i, j int) bool { return lan[i].Puuid < lan[j].Puuid })
+	return append(members, lan...)
+}
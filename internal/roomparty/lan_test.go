@@ -0,0 +1,186 @@
+package roomparty
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/roomparty/server"
+)
+
+// lanMember creates a LAN-enabled room state on group that can't reach a
+// room server unless serverURL is set.
+func lanMember(t *testing.T, group, serverURL string) *RoomState {
+	t.Helper()
+	if serverURL == "" {
+		serverURL = "ws://127.0.0.1:1"
+	}
+	rs := newMember(t, serverURL)
+	rs.LAN = true
+	rs.LANAddr = group
+	return rs
+}
+
+// listenGroup joins a multicast group to watch LAN packets, skipping the
+// test where multicast is unavailable.
+func listenGroup(t *testing.T, group string) *net.UDPConn {
+	t.Helper()
+	addr, _ := net.ResolveUDPAddr("udp4", group)
+	conn, err := net.ListenMulticastUDP("udp4", nil, addr)
+	if err != nil {
+		t.Skipf("multicast unavailable: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// capture reads packets on conn until one of kind from puuid arrives.
+func capture(t *testing.T, conn *net.UDPConn, kind, puuid string) lanPacket {
+	t.Helper()
+	buf := make([]byte, lanMaxPacket)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatalf("no %s packet from %s: %v", kind, puuid, err)
+		}
+		var p lanPacket
+		if json.Unmarshal(buf[:n], &p) == nil && p.Type == kind && p.Member.Puuid == puuid {
+			return p
+		}
+	}
+}
+
+func inject(t *testing.T, group string, p lanPacket) {
+	t.Helper()
+	addr, _ := net.ResolveUDPAddr("udp4", group)
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	data, _ := json.Marshal(p)
+	conn.Write(data)
+}
+
+func TestLAN(t *testing.T) {
+	const group = "239.255.77.78:47891"
+	watch := listenGroup(t, group)
+
+	a, b := lanMember(t, group, ""), lanMember(t, group, "")
+	wrongSecret, otherRoom := lanMember(t, group, ""), lanMember(t, group, "")
+	a.Join("room", "secret", "pa", []string{"pb", "pc", "pd"})
+	b.Join("room", "secret", "pb", []string{"pa", "pc", "pd"})
+	wrongSecret.Join("room", "guess", "pc", []string{"pa", "pb", "pd"})
+	otherRoom.Join("other", "secret", "pd", []string{"pa", "pb", "pc"})
+
+	// No room server: teammates are found over the LAN alone
+	waitFor(t, "LAN teammates", 5*time.Second, func() bool {
+		return len(a.GetTeammates()) == 1 && len(b.GetTeammates()) == 1
+	})
+	b.UpdateSkin(SkinInfo{ChampionName: "Ahri"})
+	waitFor(t, "LAN skin update", 5*time.Second, func() bool { return teammateNames(a)["pb"] == "Ahri" })
+
+	// A captured announce replayed as a leave is ignored
+	announce := capture(t, watch, "announce", "pb")
+	forged := announce
+	forged.Type = "leave"
+	inject(t, group, forged)
+	unsigned := announce
+	unsigned.Type, unsigned.Sig = "leave", ""
+	inject(t, group, unsigned)
+	time.Sleep(200 * time.Millisecond)
+	if got := a.GetTeammates(); len(got) != 1 {
+		t.Fatalf("forged leave dropped b: %+v", got)
+	}
+
+	// The wrong secret and the other room never got in; a real leave counts
+	if len(a.GetTeammates()) != 1 || len(wrongSecret.GetTeammates()) != 0 || len(otherRoom.GetTeammates()) != 0 {
+		t.Errorf("teammates: a=%v wrongSecret=%v otherRoom=%v", a.GetTeammates(), wrongSecret.GetTeammates(), otherRoom.GetTeammates())
+	}
+	b.Leave()
+	waitFor(t, "LAN leave", 5*time.Second, func() bool { return len(a.GetTeammates()) == 0 })
+
+	// Replaying b's captured announce does not bring b back
+	inject(t, group, announce)
+	time.Sleep(200 * time.Millisecond)
+	if got := a.GetTeammates(); len(got) != 0 {
+		t.Errorf("replayed announce restored b: %+v", got)
+	}
+}
+
+func TestLANMerge(t *testing.T) {
+	const group = "239.255.77.79:47892"
+	listenGroup(t, group)
+	url := startServer(t, server.New())
+
+	// a is on the room server and the LAN, b only on the server, c only on the LAN
+	a := lanMember(t, group, url)
+	b := newMember(t, url)
+	c := lanMember(t, group, "")
+	a.Join("room", "secret", "pa", []string{"pb", "pc"})
+	b.Join("room", "secret", "pb", []string{"pa", "pc"})
+	c.Join("room", "secret", "pc", []string{"pa", "pb"})
+
+	waitFor(t, "a to see both", 5*time.Second, func() bool { return len(a.GetTeammates()) == 2 })
+	waitFor(t, "c to see a", 5*time.Second, func() bool { return len(c.GetTeammates()) == 1 })
+	if got := b.GetTeammates(); len(got) != 1 || got[0].Puuid != "pa" {
+		t.Errorf("b sees %+v, want only a", got)
+	}
+}
+
+func TestLANSignature(t *testing.T) {
+	key := deriveKey("room", "secret")
+	p := lanPacket{Room: lanRoomID("room"), Type: "announce", Time: 1, Member: Member{Puuid: "pa", SkinInfo: SkinInfo{Signature: "skin-sig"}}}
+	p.Sig = lanSignature(key, p)
+	if !verifyLAN(key, p) {
+		t.Fatal("valid packet rejected")
+	}
+	tampered := map[string]func(*lanPacket){
+		"type":           func(p *lanPacket) { p.Type = "leave" },
+		"time":           func(p *lanPacket) { p.Time++ },
+		"room":           func(p *lanPacket) { p.Room = lanRoomID("other") },
+		"puuid":          func(p *lanPacket) { p.Member.Puuid = "pb" },
+		"skin signature": func(p *lanPacket) { p.Member.SkinInfo.Signature = "other" },
+		"no signature":   func(p *lanPacket) { p.Sig = "" },
+	}
+	for name, mutate := range tampered {
+		got := p
+		mutate(&got)
+		if verifyLAN(key, got) {
+			t.Errorf("%s: tampered packet verified", name)
+		}
+	}
+	if verifyLAN(deriveKey("room", "guess"), p) {
+		t.Error("packet verified with the wrong key")
+	}
+}
+
+func TestLANSeen(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	at := func(puuid string, offset time.Duration) lanPacket {
+		return lanPacket{Time: now.Add(offset).UnixNano(), Member: Member{Puuid: puuid}}
+	}
+	seen := make(lanSeen)
+	steps := []struct {
+		name   string
+		packet lanPacket
+		want   bool
+	}{
+		{"first packet", at("pa", -time.Second), true},
+		{"replayed", at("pa", -time.Second), false},
+		{"newer", at("pa", 0), true},
+		{"older than the last", at("pa", -500*time.Millisecond), false},
+		{"another member", at("pb", -time.Second), true},
+		{"stale", at("pc", -lanMaxPacketAge-time.Second), false},
+		{"ahead of the clock", at("pc", lanMaxPacketAge+time.Second), false},
+		{"within the skew", at("pc", lanMaxPacketAge/2), true},
+		{"no time", lanPacket{Member: Member{Puuid: "pd"}}, false},
+	}
+	for _, s := range steps {
+		if got := seen.fresh(s.packet, now); got != s.want {
+			t.Errorf("%s: fresh = %v, want %v", s.name, got, s.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package roomparty
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/hoangvu12/ame/internal/config"
+	"github.com/hoangvu12/ame/internal/display"
+	"github.com/hoangvu12/ame/internal/skin"
+)
+
+// Reasons a teammate's skin is left out of overlay builds.
+const (
+	ReasonNoSkin          = "no_skin"          // no skin selected yet
+	ReasonBlockedPlayer   = "blocked_player"   // puuid is on the blocklist
+	ReasonBlockedChampion = "blocked_champion" // champion is on the blocklist
+	ReasonNotFriend       = "not_friend"       // friends-only mode and not on the friends list
+	ReasonQuarantined     = "quarantined"      // excluded by the Skip hook
+	ReasonTooLarge        = "too_large"        // over the download size limit
+	ReasonCap             = "cap"              // the teammate skin cap was reached
+	ReasonUnverified      = "unverified"       // failed the signature or skin checks, see Detail
+)
+
+// TeammateStatus tells whether a teammate's skin goes into overlay builds,
+// and why not when it doesn't.
+type TeammateStatus struct {
+	Puuid    string   `json:"puuid"`
+	SkinInfo SkinInfo `json:"skinInfo"`
+	Included bool     `json:"included"`
+	Reason   string   `json:"reason,omitempty"`
+	Detail   string   `json:"detail,omitempty"` // why an unverified member was dropped
+}
+
+// TeammateStatuses applies the teammate policy and the Skip hook to the
+// current teammates, in room order. The cap goes to the first teammates
+// that pass every other rule. Teammates dropped as unverified follow, by
+// puuid, with no skin info. It runs on every build key computation, so it
+// never waits on the LCU and checks each skin's size on disk once.
+func (rs *RoomState) TeammateStatuses() []TeammateStatus {
+	policy := rs.policy()
+	maxBytes := maxDownloadBytes(policy)
+
+	rs.mu.Lock()
+	teammates := make([]Member, len(rs.teammates))
+	copy(teammates, rs.teammates)
+	skip := rs.Skip
+	friends := rs.friends
+	var unverified []TeammateStatus
+	for puuid, detail := range rs.rejected {
+		unverified = append(unverified, TeammateStatus{Puuid: puuid, Reason: ReasonUnverified, Detail: detail})
+	}
+	rs.mu.Unlock()
+	sort.Slice(unverified, func(i, j int) bool { return unverified[i].Puuid < unverified[j].Puuid })
+
+	blockedPuuids := toSet(policy.BlockedPuuids)
+	blockedChampions := toSet(policy.BlockedChampions)
+	var tooLarge map[string]bool
+	if maxBytes > 0 {
+		tooLarge = rs.oversizedSkins(teammates, maxBytes)
+	}
+
+	statuses := make([]TeammateStatus, 0, len(teammates)+len(unverified))
+	included := 0
+	for _, tm := range teammates {
+		info := tm.SkinInfo
+		status := TeammateStatus{Puuid: tm.Puuid, SkinInfo: info}
+		switch {
+		case info.SkinID == "":
+			status.Reason = ReasonNoSkin
+		case blockedPuuids[tm.Puuid]:
+			status.Reason = ReasonBlockedPlayer
+		case blockedChampions[info.ChampionID]:
+			status.Reason = ReasonBlockedChampion
+		case policy.FriendsOnly && !friends[tm.Puuid]:
+			status.Reason = ReasonNotFriend
+		case skip != nil && skip(info.SkinID):
+			status.Reason = ReasonQuarantined
+		case tooLarge[info.SkinID]:
+			status.Reason = ReasonTooLarge
+		case policy.MaxSkins > 0 && included >= policy.MaxSkins:
+			status.Reason = ReasonCap
+		default:
+			status.Included = true
+			included++
+		}
+		statuses = append(statuses, status)
+	}
+	return append(statuses, unverified...)
+}
+
+// policy returns the teammate policy in effect.
+func (rs *RoomState) policy() config.TeammatePolicy {
+	if rs.Policy != nil {
+		return rs.Policy()
+	}
+	return config.Teammates()
+}
+
+// fetchFriends loads the friends list for the friends-only policy, once per
+// Join, and notifies OnUpdate since it changes the teammate statuses. Until
+// it arrives, or if it is unavailable, nobody counts as a friend.
+func (rs *RoomState) fetchFriends(ctx context.Context, fetch func() ([]string, error)) {
+	puuids, err := fetch()
+	if err != nil {
+		display.Log(fmt.Sprintf("! Room Party: friends list unavailable: %v", err))
+		return
+	}
+	rs.mu.Lock()
+	if ctx.Err() != nil {
+		rs.mu.Unlock()
+		return
+	}
+	rs.friends = toSet(puuids)
+	rs.mu.Unlock()
+	rs.notify()
+}
+
+// oversizedSkins returns the teammate skins over maxBytes, either as a
+// cached archive or because their download was cut off this session. Sizes
+// are looked up on disk the first time a skin is seen, outside the lock.
+func (rs *RoomState) oversizedSkins(teammates []Member, maxBytes int64) map[string]bool {
+	result := make(map[string]bool)
+	var unknown []SkinInfo
+	rs.mu.Lock()
+	for _, tm := range teammates {
+		id := tm.SkinInfo.SkinID
+		size, known := rs.sizes[id]
+		switch {
+		case id == "":
+		case rs.oversized[id] || size > maxBytes:
+			result[id] = true
+		case !known:
+			unknown = append(unknown, tm.SkinInfo)
+		}
+	}
+	rs.mu.Unlock()
+
+	for _, info := range unknown {
+		size := rs.recordSize(info.SkinID, skin.GetCachedPath(info.ChampionID, info.SkinID))
+		if size > maxBytes {
+			result[info.SkinID] = true
+		}
+	}
+	return result
+}
+
+// recordSize remembers the size of the skin archive at path ("" if not
+// downloaded) and returns it, -1 if there is none.
+func (rs *RoomState) recordSize(skinID, path string) int64 {
+	size := int64(-1)
+	if path != "" {
+		if st, err := os.Stat(path); err == nil {
+			size = st.Size()
+		}
+	}
+	rs.mu.Lock()
+	// A lookup racing a download must not forget the downloaded size
+	if _, known := rs.sizes[skinID]; rs.sizes != nil && (size >= 0 || !known) {
+		rs.sizes[skinID] = size
+	}
+	rs.mu.Unlock()
+	return size
+}
+
+// markOversized records a skin whose download exceeded the size limit and
+// tells OnUpdate, since it changes the teammate statuses.
+func (rs *RoomState) markOversized(si SkinInfo) {
+	rs.mu.Lock()
+	if rs.oversized == nil {
+		rs.oversized = make(map[string]bool)
+	}
+	rs.oversized[si.SkinID] = true
+	rs.mu.Unlock()
+	display.Log(fmt.Sprintf("! Teammate skin over the size limit: %s", si.SkinName))
+	rs.notify()
+}
+
+func maxDownloadBytes(policy config.TeammatePolicy) int64 {
+	return int64(policy.MaxDownloadMB) << 20
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
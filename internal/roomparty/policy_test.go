@@ -0,0 +1,188 @@
+package roomparty
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/config"
+)
+
+// policyState returns a room state holding teammates under policy. Each
+// teammate is "puuid:championID:skinID".
+func policyState(policy config.TeammatePolicy, teammates ...string) *RoomState {
+	rs := NewRoomState()
+	rs.Policy = func() config.TeammatePolicy { return policy }
+	rs.sizes = make(map[string]int64)
+	for _, tm := range teammates {
+		parts := strings.Split(tm, ":")
+		rs.teammates = append(rs.teammates, Member{Puuid: parts[0], SkinInfo: SkinInfo{ChampionID: parts[1], SkinID: parts[2]}})
+	}
+	return rs
+}
+
+// reasons summarizes statuses as "puuid:reason", or "puuid:+" if included.
+func reasons(statuses []TeammateStatus) string {
+	var out []string
+	for _, s := range statuses {
+		if s.Included {
+			out = append(out, s.Puuid+":+")
+		} else {
+			out = append(out, s.Puuid+":"+s.Reason)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func TestTeammatePolicy(t *testing.T) {
+	team := []string{"pa:1:1001", "pb:2:", "pc:3:3001", "pd:4:4001", "pe:5:5001", "pf:6:6001"}
+	tests := []struct {
+		name   string
+		policy config.TeammatePolicy
+		skip   string // skin ID excluded by the Skip hook
+		want   string
+	}{
+		{"no limits", config.TeammatePolicy{}, "", "pa:+ pb:no_skin pc:+ pd:+ pe:+ pf:+"},
+		{"cap in room order", config.TeammatePolicy{MaxSkins: 2}, "", "pa:+ pb:no_skin pc:+ pd:cap pe:cap pf:cap"},
+		{
+			"cap goes to teammates passing every other rule",
+			config.TeammatePolicy{MaxSkins: 2, BlockedPuuids: []string{"pa"}, BlockedChampions: []string{"3"}},
+			"",
+			"pa:blocked_player pb:no_skin pc:blocked_champion pd:+ pe:+ pf:cap",
+		},
+		{
+			"blocked player checked before champion",
+			config.TeammatePolicy{BlockedPuuids: []string{"pc"}, BlockedChampions: []string{"3", "6"}},
+			"",
+			"pa:+ pb:no_skin pc:blocked_player pd:+ pe:+ pf:blocked_champion",
+		},
+		{"quarantined", config.TeammatePolicy{MaxSkins: 3}, "4001", "pa:+ pb:no_skin pc:+ pd:quarantined pe:+ pf:cap"},
+	}
+	for _, tt := range tests {
+		rs := policyState(tt.policy, team...)
+		if tt.skip != "" {
+			rs.Skip = func(skinID string) bool { return skinID == tt.skip }
+		}
+		if got := reasons(rs.TeammateStatuses()); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+
+	// Builds only use included teammates
+	rs := policyState(config.TeammatePolicy{MaxSkins: 1}, team...)
+	if got := rs.ComputeModKey("9001"); got != "1001,9001" {
+		t.Errorf("ComputeModKey = %q, want own skin and the first teammate", got)
+	}
+}
+
+func TestFriendsOnly(t *testing.T) {
+	release := make(chan struct{})
+	calls := 0
+	rs := newMember(t, "ws://127.0.0.1:1") // unreachable: no members arrive
+	rs.Policy = func() config.TeammatePolicy { return config.TeammatePolicy{FriendsOnly: true} }
+	rs.Friends = func() ([]string, error) {
+		calls++ // only called from the fetch goroutine
+		<-release
+		return []string{"pa"}, nil
+	}
+	updated := make(chan struct{}, 16)
+	rs.OnUpdate = func([]Member) { updated <- struct{}{} }
+
+	rs.Join("room", "secret", "self", []string{"pa", "pb"})
+	rs.mu.Lock()
+	rs.teammates = []Member{
+		{Puuid: "pa", SkinInfo: SkinInfo{ChampionID: "1", SkinID: "1001"}},
+		{Puuid: "pb", SkinInfo: SkinInfo{ChampionID: "2", SkinID: "2001"}},
+	}
+	rs.mu.Unlock()
+
+	// The friends list is still loading: statuses don't wait for it
+	done := make(chan string)
+	go func() { done <- reasons(rs.TeammateStatuses()) }()
+	select {
+	case got := <-done:
+		if got != "pa:not_friend pb:not_friend" {
+			t.Errorf("before the friends list: %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("TeammateStatuses waited for the friends list")
+	}
+
+	close(release)
+	waitFor(t, "friends list", 5*time.Second, func() bool {
+		return reasons(rs.TeammateStatuses()) == "pa:+ pb:not_friend"
+	})
+	select {
+	case <-updated:
+	case <-time.After(5 * time.Second):
+		t.Error("OnUpdate not called after the friends list arrived")
+	}
+	rs.TeammateStatuses()
+	if calls != 1 {
+		t.Errorf("friends list fetched %d times, want once per Join", calls)
+	}
+}
+
+func TestFriendsUnavailable(t *testing.T) {
+	rs := newMember(t, "ws://127.0.0.1:1")
+	rs.Policy = func() config.TeammatePolicy { return config.TeammatePolicy{FriendsOnly: true} }
+	fetched := make(chan struct{})
+	rs.Friends = func() ([]string, error) {
+		defer close(fetched)
+		return nil, errors.New("client closed")
+	}
+	rs.Join("room", "secret", "self", []string{"pa"})
+	<-fetched
+	rs.mu.Lock()
+	rs.teammates = []Member{{Puuid: "pa", SkinInfo: SkinInfo{ChampionID: "1", SkinID: "1001"}}}
+	rs.mu.Unlock()
+	if got := reasons(rs.TeammateStatuses()); got != "pa:not_friend" {
+		t.Errorf("got %s, want nobody counted as a friend", got)
+	}
+}
+
+func TestSizeLimit(t *testing.T) {
+	dir := t.TempDir()
+	old := config.SkinsDir
+	config.SkinsDir = dir
+	t.Cleanup(func() { config.SkinsDir = old })
+
+	write := func(championID, skinID string, size int) string {
+		path := filepath.Join(dir, championID, skinID, skinID+".zip")
+		os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	big := write("1", "1001", 2<<20)
+	write("2", "2001", 100)
+
+	rs := policyState(config.TeammatePolicy{MaxDownloadMB: 1, MaxSkins: 1}, "pa:1:1001", "pb:2:2001", "pc:3:3001")
+	want := "pa:too_large pb:+ pc:cap" // oversized skins don't use up the cap
+	if got := reasons(rs.TeammateStatuses()); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// Sizes are checked on disk once per session
+	os.Remove(big)
+	if got := reasons(rs.TeammateStatuses()); got != want {
+		t.Errorf("after removing the archive: got %s, want the remembered size", got)
+	}
+
+	// A download cut off at the limit, and a later download, are both picked up
+	rs.markOversized(SkinInfo{SkinID: "2001"})
+	rs.recordSize("3001", write("3", "3001", 3<<20))
+	if got := reasons(rs.TeammateStatuses()); got != "pa:too_large pb:too_large pc:too_large" {
+		t.Errorf("after downloads: got %s", got)
+	}
+
+	// No limit: sizes don't matter
+	rs.Policy = func() config.TeammatePolicy { return config.TeammatePolicy{} }
+	if got := reasons(rs.TeammateStatuses()); got != "pa:+ pb:+ pc:+" {
+		t.Errorf("no limit: got %s", got)
+	}
+}
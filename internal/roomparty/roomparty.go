@@ -3,8 +3,10 @@ package roomparty
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -18,9 +20,10 @@ import (
 	"github.com/hoangvu12/ame/internal/skin"
 )
 
-const workerURL = "wss://ame-rooms-ws.kaguya-gindex.workers.dev"
+// DefaultServerURL is the hosted room server, used unless another is configured.
+const DefaultServerURL = "wss://ame-rooms-ws.kaguya-gindex.workers.dev"
 const heartbeatInterval = 30 * time.Second
-const reconnectDelay = 3 * time.Second
+const writeTimeout = 10 * time.Second
 
 // SkinInfo describes a skin selection shared between Ame users.
 type SkinInfo struct {
@@ -30,6 +33,7 @@ type SkinInfo struct {
 	ChampionName string `json:"championName"`
 	SkinName     string `json:"skinName"`
 	ChromaName   string `json:"chromaName"`
+	Signature    string `json:"sig,omitempty"` // HMAC by the sender, see skinSignature
 }
 
 // Member represents another Ame user in the same game.
@@ -43,17 +47,35 @@ type OnUpdateFunc func(teammates []Member)
 
 // RoomState manages a room party session.
 type RoomState struct {
-	mu         sync.Mutex
-	active     bool
-	roomKey    string
-	puuid      string
-	teamPuuids []string
-	mySkinInfo SkinInfo
-	teammates  []Member
-	conn       *websocket.Conn
-	cancelRead context.CancelFunc
-	OnUpdate   OnUpdateFunc
-	dialer     *websocket.Dialer
+	mu            sync.Mutex
+	active        bool
+	roomKey       string
+	key           []byte // signing key derived from the room secret
+	puuid         string
+	teamPuuids    []string
+	mySkinInfo    SkinInfo
+	teammates     []Member
+	workerMembers []Member           // last member list from the room server
+	lanPeers      map[string]lanPeer // members announced on the LAN, by puuid
+	lanWake       chan struct{}      // asks the LAN transport to announce now
+	updateMu      sync.Mutex         // serializes teammate list rebuilds
+	rejected      map[string]string  // puuid -> why a teammate in the room was dropped as unverified
+	friends       map[string]bool    // friends list puuids, fetched on Join; nil until it arrives
+	oversized     map[string]bool    // skin IDs whose download hit the size limit
+	sizes         map[string]int64   // skin ID -> cached archive size, -1 if not downloaded
+	conn          *websocket.Conn
+	writeMu       sync.Mutex // serializes frames on conn; never held with mu
+	connection    Connection
+	cancel        context.CancelFunc // ends the current session's connection loop
+	OnUpdate      OnUpdateFunc
+	Skip          func(skinID string) bool     // excludes teammate skins from builds, e.g. quarantined ones
+	Friends       func() ([]string, error)     // lists friend puuids for the friends-only policy
+	Policy        func() config.TeammatePolicy // teammate policy, config.Teammates when nil
+	ServerURL     string                       // overrides config.RoomServerURL when set
+	LAN           bool                         // use LAN discovery even if config.RoomPartyLAN is off
+	LANAddr       string                       // overrides DefaultLANAddr when set
+	dialer        *websocket.Dialer
+	retryDelay    func(n int) time.Duration // wait before retry n, backoffDelay outside tests
 }
 
 // NewRoomState creates a new room state manager.
@@ -62,33 +84,60 @@ func NewRoomState() *RoomState {
 		dialer: &websocket.Dialer{
 			HandshakeTimeout: 10 * time.Second,
 		},
+		retryDelay: backoffDelay,
 	}
 }
 
 // Join starts a room party session. Connects WS and sends join message.
-func (rs *RoomState) Join(roomKey, puuid string, teamPuuids []string) {
+// secret is shared only by the real players of the room (the champ select
+// chat password); messages are signed with a key derived from it and
+// members that can't prove they know it are dropped.
+func (rs *RoomState) Join(roomKey, secret, puuid string, teamPuuids []string) {
+	if secret == "" {
+		display.Log("! Room Party: no room secret, not joining")
+		return
+	}
+
 	rs.mu.Lock()
 
-	// If already in a room, close existing connection
-	if rs.active && rs.cancelRead != nil {
-		rs.cancelRead()
+	// If already in a room, stop the existing session
+	if rs.cancel != nil {
+		rs.cancel()
 	}
 	if rs.conn != nil {
 		rs.conn.Close()
 		rs.conn = nil
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
 
 	rs.active = true
 	rs.roomKey = roomKey
+	rs.key = deriveKey(roomKey, secret)
 	rs.puuid = puuid
 	rs.teamPuuids = teamPuuids
 	rs.teammates = nil
+	rs.rejected = make(map[string]string)
+	rs.friends = nil
+	rs.oversized = nil
+	rs.sizes = make(map[string]int64)
+	rs.workerMembers = nil
+	rs.lanPeers = make(map[string]lanPeer)
+	rs.lanWake = nil
+	if rs.lanEnabled() {
+		rs.lanWake = make(chan struct{}, 1)
+		go rs.runLAN(ctx, roomKey, puuid, rs.key, rs.lanWake)
+	}
+	if rs.Friends != nil {
+		go rs.fetchFriends(ctx, rs.Friends)
+	}
 	rs.mu.Unlock()
 
 	display.SetPartyKey("display.value.party_in_room_teammates", map[string]interface{}{"count": len(teamPuuids)})
 	display.Log(fmt.Sprintf("Room key: %s", roomKey))
+	rs.setConnection(Connection{State: StateConnecting})
 
-	go rs.connectAndRun(roomKey, puuid)
+	go rs.connectAndRun(ctx, roomKey, puuid)
 }
 
 // UpdateSkin updates the local user's skin info and sends it over WS.
@@ -96,16 +145,31 @@ func (rs *RoomState) UpdateSkin(info SkinInfo) {
 	rs.mu.Lock()
 	rs.mySkinInfo = info
 	active := rs.active
+	signed := rs.signedSkinInfo()
+	if rs.lanWake != nil {
+		select {
+		case rs.lanWake <- struct{}{}:
+		default:
+		}
+	}
 	rs.mu.Unlock()
 
 	if active {
 		rs.sendJSON(map[string]interface{}{
 			"type":     "skin",
-			"skinInfo": info,
+			"skinInfo": signed,
 		})
 	}
 }
 
+// signedSkinInfo returns the local skin info signed for the current room.
+// Caller must hold mu.
+func (rs *RoomState) signedSkinInfo() SkinInfo {
+	info := rs.mySkinInfo
+	info.Signature = skinSignature(rs.key, rs.roomKey, rs.puuid, info)
+	return info
+}
+
 // Leave stops the WS connection and sends a leave message.
 func (rs *RoomState) Leave() {
 	rs.mu.Lock()
@@ -114,13 +178,18 @@ func (rs *RoomState) Leave() {
 		return
 	}
 	rs.active = false
-	if rs.cancelRead != nil {
-		rs.cancelRead()
-		rs.cancelRead = nil
-	}
 	conn := rs.conn
 	rs.conn = nil
 	rs.teammates = nil
+	rs.rejected = nil
+	rs.workerMembers = nil
+	rs.lanPeers = nil
+	rs.lanWake = nil
+	// Cancel before closing so the connection loop doesn't treat it as a drop
+	if rs.cancel != nil {
+		rs.cancel()
+		rs.cancel = nil
+	}
 	rs.mu.Unlock()
 
 	display.SetPartyKey("display.value.party_off", nil)
@@ -128,9 +197,46 @@ func (rs *RoomState) Leave() {
 	if conn != nil {
 		// Best-effort leave message
 		data, _ := json.Marshal(map[string]string{"type": "leave"})
-		conn.WriteMessage(websocket.TextMessage, data)
+		rs.write(conn, data)
 		conn.Close()
 	}
+	rs.setConnection(Connection{State: StateIdle})
+}
+
+// Connection returns the current room server connection state.
+func (rs *RoomState) Connection() Connection {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if !rs.active {
+		return Connection{State: StateIdle}
+	}
+	return rs.connection
+}
+
+// setConnection records the connection state and notifies OnUpdate so the
+// plugin sees it alongside the teammate list.
+func (rs *RoomState) setConnection(c Connection) {
+	rs.mu.Lock()
+	rs.connection = c
+	rs.mu.Unlock()
+
+	if c.State != StateConnected && c.LastError != "" {
+		display.Log(fmt.Sprintf("Room Party: %s (%s)", c.State, c.LastError))
+	}
+	rs.notify()
+}
+
+// notify calls OnUpdate with the current teammates.
+func (rs *RoomState) notify() {
+	rs.mu.Lock()
+	teammates := make([]Member, len(rs.teammates))
+	copy(teammates, rs.teammates)
+	onUpdate := rs.OnUpdate
+	rs.mu.Unlock()
+
+	if onUpdate != nil {
+		onUpdate(teammates)
+	}
 }
 
 // GetTeammates returns the current list of Ame-using teammates.
@@ -142,6 +248,18 @@ func (rs *RoomState) GetTeammates() []Member {
 	return cp
 }
 
+// buildTeammates returns the teammates whose skins go into overlay builds
+// under the teammate policy.
+func (rs *RoomState) buildTeammates() []Member {
+	var teammates []Member
+	for _, status := range rs.TeammateStatuses() {
+		if status.Included {
+			teammates = append(teammates, Member{Puuid: status.Puuid, SkinInfo: status.SkinInfo})
+		}
+	}
+	return teammates
+}
+
 // IsActive returns whether a room party session is active.
 func (rs *RoomState) IsActive() bool {
 	rs.mu.Lock()
@@ -151,12 +269,10 @@ func (rs *RoomState) IsActive() bool {
 
 // GetAllModNames returns a slash-separated mod directory name list for mkoverlay --mods.
 // mod-tools expects slash-separated names (e.g. "skin_1/skin_2/skin_3").
-// It includes the user's own skin and all teammate skins that exist in ModsDir.
+// It includes the user's own skin and the teammate skins allowed by the
+// teammate policy that exist in ModsDir.
 func (rs *RoomState) GetAllModNames(ownSkinID string) string {
-	rs.mu.Lock()
-	teammates := make([]Member, len(rs.teammates))
-	copy(teammates, rs.teammates)
-	rs.mu.Unlock()
+	teammates := rs.buildTeammates()
 
 	names := []string{fmt.Sprintf("skin_%s", ownSkinID)}
 	seen := map[string]bool{ownSkinID: true}
@@ -177,12 +293,10 @@ func (rs *RoomState) GetAllModNames(ownSkinID string) string {
 }
 
 // ComputeModKey returns a sorted, deduplicated, comma-separated skin ID list
-// for cache-keying the prebuilt overlay.
+// for cache-keying the prebuilt overlay. Teammate skins excluded by the
+// teammate policy are left out.
 func (rs *RoomState) ComputeModKey(ownSkinID string) string {
-	rs.mu.Lock()
-	teammates := make([]Member, len(rs.teammates))
-	copy(teammates, rs.teammates)
-	rs.mu.Unlock()
+	teammates := rs.buildTeammates()
 
 	ids := []string{ownSkinID}
 	seen := map[string]bool{ownSkinID: true}
@@ -202,10 +316,7 @@ func (rs *RoomState) ComputeModKey(ownSkinID string) string {
 // the own skin and teammate skins whose mod directories actually exist in ModsDir.
 // Use this (instead of ComputeModKey) when recording what was actually built into the overlay.
 func (rs *RoomState) ComputeBuiltModKey(ownSkinID string) string {
-	rs.mu.Lock()
-	teammates := make([]Member, len(rs.teammates))
-	copy(teammates, rs.teammates)
-	rs.mu.Unlock()
+	teammates := rs.buildTeammates()
 
 	ids := []string{ownSkinID}
 	seen := map[string]bool{ownSkinID: true}
@@ -224,10 +335,12 @@ func (rs *RoomState) ComputeBuiltModKey(ownSkinID string) string {
 	return strings.Join(ids, ",")
 }
 
-// DownloadTeammateSkins downloads all teammate skins that are not yet cached.
-// It also extracts them into ModsDir for overlay building.
+// DownloadTeammateSkins downloads the teammate skins allowed by the teammate
+// policy that are not yet cached. It also extracts them into ModsDir for
+// overlay building.
 func (rs *RoomState) DownloadTeammateSkins() {
-	teammates := rs.GetTeammates()
+	teammates := rs.buildTeammates()
+	maxBytes := maxDownloadBytes(rs.policy())
 	var wg sync.WaitGroup
 
 	for _, tm := range teammates {
@@ -246,11 +359,16 @@ func (rs *RoomState) DownloadTeammateSkins() {
 			defer wg.Done()
 			zipPath := skin.GetCachedPath(si.ChampionID, si.SkinID)
 			if zipPath == "" {
-				downloaded, err := skin.Download(si.ChampionID, si.SkinID, si.BaseSkinID, si.ChampionName, si.SkinName, si.ChromaName)
+				downloaded, err := skin.DownloadMax(maxBytes, si.ChampionID, si.SkinID, si.BaseSkinID, si.ChampionName, si.SkinName, si.ChromaName)
+				if errors.Is(err, skin.ErrTooLarge) {
+					rs.markOversized(si)
+					return
+				}
 				if err != nil {
 					display.Log(fmt.Sprintf("! Teammate skin unavailable: %s", si.SkinName))
 					return
 				}
+				rs.recordSize(si.SkinID, downloaded)
 				zipPath = downloaded
 			}
 
@@ -266,117 +384,122 @@ func (rs *RoomState) DownloadTeammateSkins() {
 	wg.Wait()
 }
 
-// connectAndRun connects the WS, sends join, runs readLoop, and auto-reconnects on drop.
-func (rs *RoomState) connectAndRun(roomKey, puuid string) {
+// connectAndRun connects the WS, sends join, runs readLoop, and reconnects
+// with backoff after failures or drops until ctx ends or retries run out.
+func (rs *RoomState) connectAndRun(ctx context.Context, roomKey, puuid string) {
+	retries := 0
+	lastErr := ""
 	for {
-		rs.mu.Lock()
-		if !rs.active || rs.roomKey != roomKey {
+		conn, err := rs.connectWS(roomKey)
+		if err == nil {
+			rs.mu.Lock()
+			if ctx.Err() != nil {
+				rs.mu.Unlock()
+				conn.Close()
+				return
+			}
+			rs.conn = conn
+			skinInfo := rs.signedSkinInfo()
 			rs.mu.Unlock()
-			return
-		}
-		skinInfo := rs.mySkinInfo
-		rs.mu.Unlock()
 
-		conn, err := rs.connectWS(roomKey)
-		if err != nil {
-			time.Sleep(reconnectDelay)
-			continue
-		}
+			// Send join message
+			err = rs.sendJSON(map[string]interface{}{
+				"type":     "join",
+				"puuid":    puuid,
+				"skinInfo": skinInfo,
+			})
+			if err == nil {
+				rs.setConnection(Connection{State: StateConnected, Retries: retries})
+				err = rs.readLoop(ctx, conn, roomKey)
+			}
 
-		rs.mu.Lock()
-		if !rs.active || rs.roomKey != roomKey {
+			rs.mu.Lock()
+			if rs.conn == conn {
+				rs.conn = nil
+			}
 			rs.mu.Unlock()
 			conn.Close()
+		}
+		if ctx.Err() != nil {
 			return
 		}
-		rs.conn = conn
-		ctx, cancel := context.WithCancel(context.Background())
-		rs.cancelRead = cancel
-		rs.mu.Unlock()
 
-		// Send join message
-		joinMsg, _ := json.Marshal(map[string]interface{}{
-			"type":     "join",
-			"puuid":    puuid,
-			"skinInfo": skinInfo,
-		})
-		rs.mu.Lock()
-		if rs.conn != nil {
-			rs.conn.WriteMessage(websocket.TextMessage, joinMsg)
+		if err != nil {
+			lastErr = err.Error()
 		}
-		rs.mu.Unlock()
-
-		rs.readLoop(ctx, roomKey)
-
-		// readLoop exited — check if we should reconnect
-		rs.mu.Lock()
-		shouldReconnect := rs.active && rs.roomKey == roomKey
-		rs.mu.Unlock()
+		if retries >= maxRetries {
+			display.Log(fmt.Sprintf("! Room Party: giving up after %d retries: %s", retries, lastErr))
+			display.SetPartyKey("display.value.party_failed", nil)
+			rs.setConnection(Connection{State: StateFailed, Retries: retries, LastError: lastErr})
+			return
+		}
+		retries++
+		delay := rs.retryDelay(retries)
+		display.SetPartyKey("display.value.party_reconnecting", map[string]interface{}{"attempt": retries})
+		rs.setConnection(Connection{State: StateReconnecting, Retries: retries, LastError: lastErr, NextRetry: time.Now().Add(delay)})
 
-		if !shouldReconnect {
+		select {
+		case <-ctx.Done():
 			return
+		case <-time.After(delay):
 		}
+	}
+}
 
-		time.Sleep(reconnectDelay)
+// serverURL returns the room server to connect to.
+func (rs *RoomState) serverURL() string {
+	if rs.ServerURL != "" {
+		return rs.ServerURL
+	}
+	if u := config.RoomServerURL(); u != "" {
+		return u
 	}
+	return DefaultServerURL
+}
+
+// ValidServerURL reports whether u can be used as a room server URL.
+func ValidServerURL(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && (parsed.Scheme == "ws" || parsed.Scheme == "wss") && parsed.Host != ""
 }
 
 // connectWS dials the WebSocket endpoint.
 func (rs *RoomState) connectWS(roomKey string) (*websocket.Conn, error) {
-	u := fmt.Sprintf("%s/?roomKey=%s", workerURL, roomKey)
+	u := fmt.Sprintf("%s/?roomKey=%s", strings.TrimSuffix(rs.serverURL(), "/"), url.QueryEscape(roomKey))
 	header := http.Header{}
 	conn, _, err := rs.dialer.Dial(u, header)
 	return conn, err
 }
 
-// readLoop reads WS messages and runs a heartbeat goroutine.
-func (rs *RoomState) readLoop(ctx context.Context, roomKey string) {
-	// Start heartbeat goroutine
-	heartbeatDone := make(chan struct{})
+// readLoop reads WS messages and runs a heartbeat goroutine. It returns
+// when the connection fails, goes silent for readTimeout, or ctx ends.
+func (rs *RoomState) readLoop(ctx context.Context, conn *websocket.Conn, roomKey string) error {
+	// Join and Leave close the connection themselves, which unblocks ReadMessage
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
-		defer close(heartbeatDone)
 		ticker := time.NewTicker(heartbeatInterval)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case <-done:
+				return
 			case <-ticker.C:
-				rs.mu.Lock()
-				if rs.conn == nil {
-					rs.mu.Unlock()
-					return
-				}
-				err := rs.conn.WriteMessage(websocket.TextMessage, []byte("ping"))
-				rs.mu.Unlock()
-				if err != nil {
+				if err := rs.write(conn, []byte("ping")); err != nil {
+					conn.Close()
 					return
 				}
 			}
 		}
 	}()
 
-	defer func() {
-		rs.mu.Lock()
-		if rs.cancelRead != nil {
-			rs.cancelRead()
-		}
-		rs.mu.Unlock()
-		<-heartbeatDone
-	}()
-
 	for {
-		rs.mu.Lock()
-		conn := rs.conn
-		rs.mu.Unlock()
-
-		if conn == nil {
-			return
-		}
-
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			return
+			return err
 		}
 
 		text := string(message)
@@ -399,47 +522,143 @@ func (rs *RoomState) readLoop(ctx context.Context, roomKey string) {
 		rs.mu.Lock()
 		if !rs.active || rs.roomKey != roomKey {
 			rs.mu.Unlock()
-			return
+			return nil
 		}
-		oldTeammates := rs.teammates
-		rs.teammates = filterTeammates(msg.Members, rs.teamPuuids)
-		newTeammates := make([]Member, len(rs.teammates))
-		copy(newTeammates, rs.teammates)
-		onUpdate := rs.OnUpdate
+		rs.workerMembers = msg.Members
 		rs.mu.Unlock()
 
-		if len(newTeammates) != len(oldTeammates) {
-			if len(newTeammates) > 0 {
-				display.SetPartyKey("display.value.party_active_users", map[string]interface{}{"count": len(newTeammates)})
-			} else {
-				display.SetPartyKey("display.value.party_in_room_waiting", nil)
+		rs.updateTeammates(roomKey)
+	}
+}
+
+// updateTeammates rebuilds the teammate list from the room server and LAN
+// members, keeping only verified teammates, and notifies OnUpdate.
+func (rs *RoomState) updateTeammates(roomKey string) {
+	rs.updateMu.Lock()
+	defer rs.updateMu.Unlock()
+
+	rs.mu.Lock()
+	if !rs.active || rs.roomKey != roomKey {
+		rs.mu.Unlock()
+		return
+	}
+	candidates := filterTeammates(rs.mergeMembers(), rs.teamPuuids)
+	key := rs.key
+	rs.mu.Unlock()
+
+	// Verifying may fetch the skin catalog, so it runs outside the lock
+	verified := rs.verifyMembers(candidates, key, roomKey)
+
+	rs.mu.Lock()
+	if !rs.active || rs.roomKey != roomKey {
+		rs.mu.Unlock()
+		return
+	}
+	// Forget rejections of members that left the room
+	inRoom := make(map[string]bool, len(candidates))
+	for _, m := range candidates {
+		inRoom[m.Puuid] = true
+	}
+	for puuid := range rs.rejected {
+		if !inRoom[puuid] {
+			delete(rs.rejected, puuid)
+		}
+	}
+	oldTeammates := rs.teammates
+	rs.teammates = verified
+	newTeammates := make([]Member, len(rs.teammates))
+	copy(newTeammates, rs.teammates)
+	onUpdate := rs.OnUpdate
+	rs.mu.Unlock()
+
+	if len(newTeammates) > 0 {
+		display.SetPartyKey("display.value.party_active_users", map[string]interface{}{"count": len(newTeammates)})
+	} else {
+		display.SetPartyKey("display.value.party_in_room_waiting", nil)
+	}
+
+	if onUpdate != nil {
+		onUpdate(newTeammates)
+	}
+
+	go rs.prefetchTeammateSkins(oldTeammates)
+}
+
+// verifyMembers keeps the members whose skin info is signed with the room
+// key and names a real skin of the champion. Dropped members are recorded
+// for TeammateStatuses and logged once per reason.
+func (rs *RoomState) verifyMembers(members []Member, key []byte, roomKey string) []Member {
+	var result []Member
+	for _, m := range members {
+		reason := ""
+		if m.SkinInfo.Signature == "" {
+			reason = "not signed, likely an older ame version"
+		} else if !verifySkin(key, roomKey, m.Puuid, m.SkinInfo) {
+			reason = "invalid signature"
+		} else if m.SkinInfo.SkinID != "" {
+			if err := skin.Validate(m.SkinInfo.ChampionID, m.SkinInfo.SkinID, m.SkinInfo.BaseSkinID); err != nil {
+				reason = err.Error()
 			}
 		}
 
-		if onUpdate != nil {
-			onUpdate(newTeammates)
+		rs.mu.Lock()
+		logged := rs.rejected[m.Puuid]
+		if reason == "" {
+			delete(rs.rejected, m.Puuid)
+		} else if rs.rejected != nil {
+			rs.rejected[m.Puuid] = reason
 		}
+		rs.mu.Unlock()
+
+		if reason != "" {
+			if logged != reason {
+				display.Log(fmt.Sprintf("! Room Party: dropped unverified member %s: %s", shortPuuid(m.Puuid), reason))
+			}
+			continue
+		}
+		m.SkinInfo.Signature = ""
+		result = append(result, m)
+	}
+	return result
+}
 
-		go rs.prefetchTeammateSkins(oldTeammates, newTeammates)
+// shortPuuid abbreviates a puuid for logs.
+func shortPuuid(puuid string) string {
+	if len(puuid) > 8 {
+		return puuid[:8]
 	}
+	return puuid
 }
 
-// sendJSON marshals v and writes it to the WS connection under lock.
-func (rs *RoomState) sendJSON(v interface{}) {
+// sendJSON marshals v and writes it to the WS connection.
+func (rs *RoomState) sendJSON(v interface{}) error {
 	data, err := json.Marshal(v)
 	if err != nil {
-		return
+		return err
 	}
 	rs.mu.Lock()
-	defer rs.mu.Unlock()
-	if rs.conn == nil {
-		return
+	conn := rs.conn
+	rs.mu.Unlock()
+	if conn == nil {
+		return errors.New("not connected")
 	}
-	rs.conn.WriteMessage(websocket.TextMessage, data)
+	return rs.write(conn, data)
+}
+
+// write sends a text frame on conn. It holds writeMu rather than mu, so a
+// slow network doesn't block readers of the room state.
+func (rs *RoomState) write(conn *websocket.Conn, data []byte) error {
+	rs.writeMu.Lock()
+	defer rs.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return conn.WriteMessage(websocket.TextMessage, data)
 }
 
-// prefetchTeammateSkins downloads skins for newly discovered teammates or changed skins.
-func (rs *RoomState) prefetchTeammateSkins(old, current []Member) {
+// prefetchTeammateSkins downloads skins for newly discovered teammates or
+// changed skins allowed by the teammate policy.
+func (rs *RoomState) prefetchTeammateSkins(old []Member) {
+	current := rs.buildTeammates()
+	maxBytes := maxDownloadBytes(rs.policy())
 	oldMap := make(map[string]string, len(old))
 	for _, m := range old {
 		oldMap[m.Puuid] = m.SkinInfo.SkinID
@@ -459,8 +678,11 @@ func (rs *RoomState) prefetchTeammateSkins(old, current []Member) {
 		}
 		// Download in background
 		go func(si SkinInfo) {
-			_, err := skin.Download(si.ChampionID, si.SkinID, si.BaseSkinID, si.ChampionName, si.SkinName, si.ChromaName)
-			if err == nil {
+			path, err := skin.DownloadMax(maxBytes, si.ChampionID, si.SkinID, si.BaseSkinID, si.ChampionName, si.SkinName, si.ChromaName)
+			if errors.Is(err, skin.ErrTooLarge) {
+				rs.markOversized(si)
+			} else if err == nil {
+				rs.recordSize(si.SkinID, path)
 				display.Log(fmt.Sprintf("Prefetched teammate skin: %s", si.SkinName))
 			}
 		}(m.SkinInfo)
@@ -0,0 +1,197 @@
+package roomparty
+
+import (
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hoangvu12/ame/internal/roomparty/server"
+)
+
+// startServer runs an in-process room server and returns its ws:// URL.
+func startServer(t *testing.T, srv *server.Server) string {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+// dropListener remembers accepted connections so a test can cut them all,
+// like a network drop.
+type dropListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *dropListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, c)
+		l.mu.Unlock()
+	}
+	return c, err
+}
+
+func (l *dropListener) drop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		c.Close()
+	}
+	l.conns = nil
+}
+
+// newMember creates a RoomState connected to serverURL, left on cleanup.
+func newMember(t *testing.T, serverURL string) *RoomState {
+	t.Helper()
+	rs := NewRoomState()
+	rs.ServerURL = serverURL
+	t.Cleanup(rs.Leave)
+	return rs
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, what string, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// teammateNames returns the champion names of rs's teammates by puuid.
+func teammateNames(rs *RoomState) map[string]string {
+	names := make(map[string]string)
+	for _, m := range rs.GetTeammates() {
+		names[m.Puuid] = m.SkinInfo.ChampionName
+	}
+	return names
+}
+
+func TestRoomServer(t *testing.T) {
+	srv := server.New()
+	url := startServer(t, srv)
+	a, b := newMember(t, url), newMember(t, url)
+
+	a.Join("room", "secret", "pa", []string{"pb"})
+	b.Join("room", "secret", "pb", []string{"pa"})
+	waitFor(t, "members to see each other", 5*time.Second, func() bool {
+		return len(a.GetTeammates()) == 1 && len(b.GetTeammates()) == 1
+	})
+	if c := a.Connection(); c.State != StateConnected || c.Retries != 0 {
+		t.Errorf("connection = %+v, want connected", c)
+	}
+	if got := a.GetTeammates()[0]; got.Puuid != "pb" || got.SkinInfo.Signature != "" {
+		t.Errorf("a sees %+v, want pb with the signature stripped", got)
+	}
+
+	// Skin updates reach the other member, signed
+	b.UpdateSkin(SkinInfo{ChampionName: "Ahri"})
+	waitFor(t, "skin update", 5*time.Second, func() bool { return teammateNames(a)["pb"] == "Ahri" })
+
+	// Leaving removes the member
+	b.Leave()
+	waitFor(t, "leave", 5*time.Second, func() bool { return len(a.GetTeammates()) == 0 })
+	if b.IsActive() || b.Connection().State != StateIdle {
+		t.Errorf("after Leave: active=%v connection=%+v", b.IsActive(), b.Connection())
+	}
+
+	a.Leave()
+	waitFor(t, "room to close", 5*time.Second, func() bool { return srv.Rooms() == 0 })
+}
+
+func TestRoomServerIsolation(t *testing.T) {
+	url := startServer(t, server.New())
+	a, wrongSecret, otherRoom, notTeam := newMember(t, url), newMember(t, url), newMember(t, url), newMember(t, url)
+
+	// Team lists hold the other players, as champ select reports them
+	a.Join("room", "secret", "pa", []string{"pb", "pc"})
+	wrongSecret.Join("room", "guess", "pb", []string{"pa", "pc"})
+	otherRoom.Join("other", "secret", "pc", []string{"pa", "pb"})
+	notTeam.Join("room", "secret", "px", []string{"pa", "pb", "pc"})
+	waitFor(t, "outsider to see a", 5*time.Second, func() bool { return len(notTeam.GetTeammates()) == 1 })
+
+	// Nobody passes: wrong secret, other room, or not in a's team
+	if got := a.GetTeammates(); len(got) != 0 {
+		t.Errorf("a sees %+v, want no teammates", got)
+	}
+	if got := wrongSecret.GetTeammates(); len(got) != 0 {
+		t.Errorf("wrong secret sees %+v", got)
+	}
+	if got := otherRoom.GetTeammates(); len(got) != 0 {
+		t.Errorf("other room sees %+v", got)
+	}
+}
+
+func TestRoomServerReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := &dropListener{Listener: l}
+	ts := httptest.NewUnstartedServer(server.New())
+	ts.Listener = listener
+	ts.Start()
+	t.Cleanup(ts.Close)
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	a, b := newMember(t, url), newMember(t, url)
+	a.Join("room", "secret", "pa", []string{"pb"})
+	b.Join("room", "secret", "pb", []string{"pa"})
+	waitFor(t, "members to see each other", 5*time.Second, func() bool { return len(a.GetTeammates()) == 1 })
+
+	listener.drop()
+	waitFor(t, "reconnecting", 5*time.Second, func() bool { return a.Connection().State == StateReconnecting })
+	if c := a.Connection(); c.Retries != 1 || c.LastError == "" || c.NextRetry.IsZero() {
+		t.Errorf("connection = %+v, want the first retry scheduled with the error", c)
+	}
+
+	waitFor(t, "reconnected", 5*time.Second, func() bool {
+		c := a.Connection()
+		return c.State == StateConnected && c.Retries > 0
+	})
+	waitFor(t, "teammate after reconnect", 5*time.Second, func() bool { return len(a.GetTeammates()) == 1 })
+}
+
+func TestJoinWithoutSecret(t *testing.T) {
+	rs := newMember(t, "ws://127.0.0.1:1")
+	rs.Join("room", "", "pa", nil)
+	if rs.IsActive() {
+		t.Error("joined without a room secret")
+	}
+}
+
+func TestServerURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"ws://localhost:8787", true},
+		{"wss://rooms.example.com/", true},
+		{"http://localhost:8787", false},
+		{"ws://", false},
+		{"localhost:8787", false},
+	}
+	for _, tt := range tests {
+		if got := ValidServerURL(tt.url); got != tt.valid {
+			t.Errorf("ValidServerURL(%q) = %v, want %v", tt.url, got, tt.valid)
+		}
+	}
+
+	rs := NewRoomState()
+	if rs.serverURL() != DefaultServerURL {
+		t.Errorf("default server URL = %q", rs.serverURL())
+	}
+	rs.ServerURL = "ws://localhost:8787"
+	if rs.serverURL() != "ws://localhost:8787" {
+		t.Errorf("override ignored: %q", rs.serverURL())
+	}
+}
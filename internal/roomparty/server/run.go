@@ -0,0 +1,26 @@
+package server
+
+import (
+	"flag"
+	"log"
+)
+
+// DefaultAddr is the listen address when none is given.
+const DefaultAddr = ":8787"
+
+// Run parses command-line flags (-addr, -idle) and serves rooms until the
+// listener fails. It backs both the ame-room-server command and
+// `ame room-server`.
+func Run(args []string) error {
+	fs := flag.NewFlagSet("room-server", flag.ContinueOnError)
+	addr := fs.String("addr", DefaultAddr, "listen address")
+	idle := fs.Duration("idle", DefaultIdleTimeout, "drop connections idle for this long")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	srv := New()
+	srv.IdleTimeout = *idle
+	log.Printf("room server listening on %s", *addr)
+	return srv.ListenAndServe(*addr)
+}
@@ -0,0 +1,229 @@
+// Package server is a self-hostable room party server. It speaks the same
+// WebSocket protocol as the Cloudflare worker in worker/src: clients connect
+// with ?roomKey=..., send join/skin/leave messages and "ping" heartbeats,
+// and every member of a room receives the full member list on each change.
+package server
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultIdleTimeout drops connections that send nothing (not even a ping)
+// for this long. Clients ping every 30 seconds.
+const DefaultIdleTimeout = 90 * time.Second
+
+const writeTimeout = 10 * time.Second
+
+// member is the public state of a joined client. SkinInfo is passed through
+// untouched, like the worker does.
+type member struct {
+	Puuid    string          `json:"puuid"`
+	SkinInfo json.RawMessage `json:"skinInfo"`
+}
+
+type clientMessage struct {
+	Type     string          `json:"type"`
+	Puuid    string          `json:"puuid"`
+	SkinInfo json.RawMessage `json:"skinInfo"`
+}
+
+type client struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	member  *member // nil until joined
+}
+
+func (c *client) write(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+type room struct {
+	clients []*client // in connection order
+}
+
+// Server hosts rooms keyed by roomKey. Rooms exist while they have
+// connections and are dropped when the last one leaves or goes idle.
+type Server struct {
+	IdleTimeout time.Duration // 0 means DefaultIdleTimeout
+
+	mu       sync.Mutex
+	rooms    map[string]*room
+	upgrader websocket.Upgrader
+}
+
+// New creates a server with no rooms.
+func New() *Server {
+	return &Server{
+		rooms: make(map[string]*room),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// ListenAndServe serves rooms on addr with a new server until it fails.
+func ListenAndServe(addr string) error {
+	return New().ListenAndServe(addr)
+}
+
+// ListenAndServe serves rooms on addr until it fails.
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s)
+}
+
+// Serve serves rooms on an existing listener until it fails.
+func (s *Server) Serve(l net.Listener) error {
+	return http.Serve(l, s)
+}
+
+// Rooms returns the number of open rooms.
+func (s *Server) Rooms() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.rooms)
+}
+
+// ServeHTTP upgrades room connections and answers health checks on GET /.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		roomKey := r.URL.Query().Get("roomKey")
+		if roomKey == "" {
+			http.Error(w, "Missing roomKey query parameter", http.StatusBadRequest)
+			return
+		}
+		conn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.handle(roomKey, conn)
+		return
+	}
+
+	if r.URL.Path == "/" && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+		return
+	}
+	http.Error(w, "WebSocket upgrade required", http.StatusUpgradeRequired)
+}
+
+func (s *Server) idleTimeout() time.Duration {
+	if s.IdleTimeout > 0 {
+		return s.IdleTimeout
+	}
+	return DefaultIdleTimeout
+}
+
+// handle runs one connection until it closes, leaves or goes idle.
+func (s *Server) handle(roomKey string, conn *websocket.Conn) {
+	c := &client{conn: conn}
+
+	s.mu.Lock()
+	rm := s.rooms[roomKey]
+	if rm == nil {
+		rm = &room{}
+		s.rooms[roomKey] = rm
+	}
+	rm.clients = append(rm.clients, c)
+	s.mu.Unlock()
+
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		for i, other := range rm.clients {
+			if other == c {
+				rm.clients = append(rm.clients[:i], rm.clients[i+1:]...)
+				break
+			}
+		}
+		if len(rm.clients) == 0 && s.rooms[roomKey] == rm {
+			delete(s.rooms, roomKey)
+		}
+		s.mu.Unlock()
+		s.broadcast(rm)
+	}()
+
+	idle := s.idleTimeout()
+	for {
+		conn.SetReadDeadline(time.Now().Add(idle))
+		kind, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if kind != websocket.TextMessage {
+			continue
+		}
+		text := string(message)
+		if text == "ping" {
+			c.write([]byte("pong"))
+			continue
+		}
+		if text == "pong" {
+			continue
+		}
+
+		var msg clientMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "join":
+			s.mu.Lock()
+			c.member = &member{Puuid: msg.Puuid, SkinInfo: skinInfoOrEmpty(msg.SkinInfo)}
+			s.mu.Unlock()
+			s.broadcast(rm)
+		case "skin":
+			s.mu.Lock()
+			joined := c.member != nil
+			if joined {
+				c.member.SkinInfo = skinInfoOrEmpty(msg.SkinInfo)
+			}
+			s.mu.Unlock()
+			if joined {
+				s.broadcast(rm)
+			}
+		case "leave":
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, "client left"),
+				time.Now().Add(writeTimeout))
+			return
+		}
+	}
+}
+
+func skinInfoOrEmpty(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || string(raw) == "null" {
+		return json.RawMessage("{}")
+	}
+	return raw
+}
+
+// broadcast sends the member list of rm to all its connections.
+func (s *Server) broadcast(rm *room) {
+	s.mu.Lock()
+	members := []member{}
+	clients := append([]*client(nil), rm.clients...)
+	for _, c := range clients {
+		if c.member != nil && c.member.Puuid != "" {
+			members = append(members, *c.member)
+		}
+	}
+	s.mu.Unlock()
+
+	payload, err := json.Marshal(map[string]interface{}{"type": "members", "members": members})
+	if err != nil {
+		return
+	}
+	for _, c := range clients {
+		c.write(payload)
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func dial(t *testing.T, ts *httptest.Server, roomKey string) *websocket.Conn {
+	t.Helper()
+	u := "ws" + strings.TrimPrefix(ts.URL, "http") + "/?roomKey=" + roomKey
+	conn, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg string) {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// members reads messages until a member list arrives and returns its puuids.
+func members(t *testing.T, conn *websocket.Conn) []string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg struct {
+			Type    string   `json:"type"`
+			Members []member `json:"members"`
+		}
+		if json.Unmarshal(data, &msg) != nil || msg.Type != "members" {
+			continue
+		}
+		var puuids []string
+		for _, m := range msg.Members {
+			puuids = append(puuids, m.Puuid+":"+string(m.SkinInfo))
+		}
+		return puuids
+	}
+}
+
+func TestRooms(t *testing.T) {
+	srv := New()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	a := dial(t, ts, "room")
+	b := dial(t, ts, "room")
+	other := dial(t, ts, "other")
+
+	send(t, a, `{"type":"join","puuid":"pa"}`)
+	if got := members(t, a); len(got) != 1 || got[0] != "pa:{}" {
+		t.Errorf("after join: %q", got)
+	}
+	send(t, b, `{"type":"skin","skinInfo":{"skinId":"1"}}`) // ignored until joined
+	send(t, b, `{"type":"join","puuid":"pb","skinInfo":{"skinId":"2"}}`)
+	want := `[pa:{} pb:{"skinId":"2"}]`
+	if got := members(t, a); strings.Join(got, " ") != strings.Trim(want, "[]") {
+		t.Errorf("a sees %q, want %s", got, want)
+	}
+	members(t, b)
+
+	send(t, other, `{"type":"join","puuid":"po"}`)
+	if got := members(t, other); len(got) != 1 {
+		t.Errorf("other room sees %q", got)
+	}
+	if srv.Rooms() != 2 {
+		t.Errorf("Rooms = %d, want 2", srv.Rooms())
+	}
+
+	send(t, a, "ping")
+	a.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, data, err := a.ReadMessage(); err != nil || string(data) != "pong" {
+		t.Errorf("ping answered %q, %v", data, err)
+	}
+
+	send(t, b, `{"type":"leave"}`)
+	if got := members(t, a); len(got) != 1 || got[0] != "pa:{}" {
+		t.Errorf("after leave: %q", got)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	srv := New()
+	srv.IdleTimeout = 50 * time.Millisecond
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	conn := dial(t, ts, "room")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Fatal("idle connection not dropped")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.Rooms() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if srv.Rooms() != 0 {
+		t.Error("room of a dropped connection kept")
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	ts := httptest.NewServer(New())
+	defer ts.Close()
+
+	tests := []struct {
+		path   string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"/", nil, http.StatusOK, `{"status":"ok"}`},
+		{"/other", nil, http.StatusUpgradeRequired, "WebSocket upgrade required\n"},
+		{"/", map[string]string{"Connection": "Upgrade", "Upgrade": "websocket"}, http.StatusBadRequest, "Missing roomKey query parameter\n"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+tt.path, nil)
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || string(body) != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, resp.StatusCode, body, tt.status, tt.body)
+		}
+	}
+}
+
+func TestRunFlags(t *testing.T) {
+	if err := Run([]string{"-idle", "soon"}); err == nil {
+		t.Error("bad -idle value: want error")
+	}
+	if err := Run([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("-h: got %v, want flag.ErrHelp", err)
+	}
+	if err := Run([]string{"-addr", "bad address"}); err == nil {
+		t.Error("bad -addr: want error")
+	}
+}
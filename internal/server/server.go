@@ -1,7 +1,9 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,11 +15,18 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/hoangvu12/ame/internal/automation"
 	"github.com/hoangvu12/ame/internal/config"
 	"github.com/hoangvu12/ame/internal/display"
+	"github.com/hoangvu12/ame/internal/favorites"
 	"github.com/hoangvu12/ame/internal/game"
+	"github.com/hoangvu12/ame/internal/gameflow"
+	"github.com/hoangvu12/ame/internal/history"
 	"github.com/hoangvu12/ame/internal/modtools"
 	"github.com/hoangvu12/ame/internal/lcu"
+	"github.com/hoangvu12/ame/internal/overlay"
+	"github.com/hoangvu12/ame/internal/quarantine"
+	"github.com/hoangvu12/ame/internal/randomskin"
 	"github.com/hoangvu12/ame/internal/roomparty"
 	"github.com/hoangvu12/ame/internal/setup"
 	"github.com/hoangvu12/ame/internal/skin"
@@ -58,12 +67,27 @@ type StateMessage struct {
 	SkinName      string `json:"skinName,omitempty"`
 	ChromaName    string `json:"chromaName,omitempty"`
 	OverlayActive bool   `json:"overlayActive"`
+	Phase         string `json:"phase"`
 }
 
 // GamePathMessage represents a game path request/response
 type GamePathMessage struct {
+	Type    string `json:"type"`
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+// RoomServerURLMessage represents the room party server URL get/set.
+// An empty URL selects the default server.
+type RoomServerURLMessage struct {
 	Type string `json:"type"`
-	Path string `json:"path"`
+	URL  string `json:"url"`
+}
+
+// TeammatePolicyMessage gets (TO plugin) or sets (FROM plugin) the room party teammate skin policy
+type TeammatePolicyMessage struct {
+	Type   string                `json:"type"`
+	Policy config.TeammatePolicy `json:"policy"`
 }
 
 // BoolSettingMessage is a generic message for boolean setting get/set
@@ -84,6 +108,7 @@ type AutoSelectRoleMessage struct {
 type RoomPartyJoinMessage struct {
 	Type       string   `json:"type"`
 	RoomKey    string   `json:"roomKey"`
+	RoomSecret string   `json:"roomSecret,omitempty"` // champ select chat password; read from the LCU if empty
 	Puuid      string   `json:"puuid"`
 	TeamPuuids []string `json:"teamPuuids"`
 }
@@ -99,10 +124,77 @@ type RoomPartySkinMessage struct {
 	ChromaName   string      `json:"chromaName,omitempty"`
 }
 
+// SkinCandidatesMessage requests (FROM plugin) or lists (TO plugin) a champion's skins
+type SkinCandidatesMessage struct {
+	Type       string           `json:"type"`
+	ChampionID interface{}      `json:"championId"`
+	Candidates []skin.Candidate `json:"candidates,omitempty"`
+}
+
+// RandomSkinPickMessage asks for a random skin (FROM plugin) and carries the
+// pick back (TO plugin). With Apply set the pick is applied right away.
+type RandomSkinPickMessage struct {
+	Type         string          `json:"type"`
+	ChampionID   interface{}     `json:"championId"`
+	ChampionName string          `json:"championName,omitempty"`
+	Mode         string          `json:"mode,omitempty"`
+	Apply        bool            `json:"apply,omitempty"`
+	Skin         *skin.Candidate `json:"skin,omitempty"`
+}
+
+// FavoritesMessage carries favorite skin CRUD requests (FROM plugin) and
+// the resulting favorites (TO plugin). Without a champion ID, getFavorites
+// returns every champion in All.
+type FavoritesMessage struct {
+	Type       string                              `json:"type"`
+	ChampionID interface{}                         `json:"championId,omitempty"`
+	Favorites  *config.ChampionFavorites           `json:"favorites,omitempty"`
+	All        map[string]config.ChampionFavorites `json:"all,omitempty"`
+	Skin       *config.FavoriteSkin                `json:"skin,omitempty"`
+	SkinID     interface{}                         `json:"skinId,omitempty"`
+	Index      *int                                `json:"index,omitempty"`
+	Queue      string                              `json:"queue,omitempty"`
+}
+
+// ResolveFavoriteMessage asks which favorite to use (FROM plugin) and carries
+// the answer (TO plugin). An empty queue is read from the LCU. With Prefetch
+// or Apply set the resolved skin is also prefetched or applied.
+type ResolveFavoriteMessage struct {
+	Type         string               `json:"type"`
+	ChampionID   interface{}          `json:"championId"`
+	ChampionName string               `json:"championName,omitempty"`
+	Queue        string               `json:"queue,omitempty"`
+	Prefetch     bool                 `json:"prefetch,omitempty"`
+	Apply        bool                 `json:"apply,omitempty"`
+	Source       string               `json:"source,omitempty"`
+	Skin         *config.FavoriteSkin `json:"skin,omitempty"`
+}
+
+// HistoryMessage carries apply history queries (FROM plugin) and their
+// results (TO plugin). An empty champion ID means every champion.
+type HistoryMessage struct {
+	Type        string              `json:"type"`
+	ChampionID  interface{}         `json:"championId,omitempty"`
+	Limit       int                 `json:"limit,omitempty"`
+	MinAttempts int                 `json:"minAttempts,omitempty"`
+	Entries     []history.Entry     `json:"entries,omitempty"`
+	Stats       []history.SkinStats `json:"stats,omitempty"`
+}
+
+// GameflowPhaseMessage is sent TO the plugin when the gameflow phase changes
+type GameflowPhaseMessage struct {
+	Type     string `json:"type"`
+	Phase    string `json:"phase"`
+	Previous string `json:"previous,omitempty"`
+	Source   string `json:"source,omitempty"`
+}
+
 // RoomPartyUpdateMessage is sent TO the plugin with teammate info
 type RoomPartyUpdateMessage struct {
-	Type      string             `json:"type"`
-	Teammates []roomparty.Member `json:"teammates"`
+	Type           string                     `json:"type"`
+	Teammates      []roomparty.Member         `json:"teammates"`
+	TeammateStatus []roomparty.TeammateStatus `json:"teammateStatus"` // which teammate skins are built and why not
+	Connection     roomparty.Connection       `json:"connection"`
 }
 
 // RandomSkinMessage represents a random skin mode get/set
@@ -118,6 +210,66 @@ type ChatStatusSettingMessage struct {
 	StatusMessage string `json:"statusMessage"`
 }
 
+// ModPriorityMessage represents an overlay mod priority get/set
+type ModPriorityMessage struct {
+	Type     string   `json:"type"`
+	Priority []string `json:"priority"`
+}
+
+// OverlayBackendMessage represents an overlay builder backend get/set
+type OverlayBackendMessage struct {
+	Type    string `json:"type"`
+	Backend string `json:"backend"`
+}
+
+// OverlayBuildTimeoutMessage represents an overlay build timeout get/set (0 = default)
+type OverlayBuildTimeoutMessage struct {
+	Type    string `json:"type"`
+	Seconds int    `json:"seconds"`
+}
+
+// OverlayCacheSizeMessage represents the overlay cache limit get/set
+type OverlayCacheSizeMessage struct {
+	Type string `json:"type"`
+	Size int    `json:"size"`
+}
+
+// QuarantineMessage lists skins with failure records (TO plugin). As
+// "skinQuarantined" it announces newly quarantined skins with a notice.
+type QuarantineMessage struct {
+	Type    string            `json:"type"`
+	Skins   []quarantine.Skin `json:"skins"`
+	Message string            `json:"message,omitempty"`
+}
+
+// QuarantineSettingsMessage represents the quarantine settings get/set.
+// CrashWindow is in seconds.
+type QuarantineSettingsMessage struct {
+	Type        string `json:"type"`
+	Threshold   int    `json:"threshold"`
+	CrashWindow int    `json:"crashWindow"`
+}
+
+// ReleaseQuarantineMessage asks to take a skin out of quarantine (FROM plugin)
+type ReleaseQuarantineMessage struct {
+	Type   string      `json:"type"`
+	SkinID interface{} `json:"skinId"`
+}
+
+// ModConflictsMessage is sent TO the plugin after an overlay build with the
+// entries that were overridden by a higher-priority mod
+type ModConflictsMessage struct {
+	Type      string             `json:"type"`
+	Mods      []string           `json:"mods"`
+	Conflicts []overlay.Conflict `json:"conflicts"`
+}
+
+// OverlayEventMessage is sent TO the plugin when the runoverlay state changes
+type OverlayEventMessage struct {
+	Type  string         `json:"type"`
+	Event modtools.Event `json:"event"`
+}
+
 // IncomingMessage is used for parsing the message type first
 type IncomingMessage struct {
 	Type string `json:"type"`
@@ -129,6 +281,19 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// roomSecret reads the champ select chat password for roomKey from the LCU.
+// Returns "" if champ select is over or belongs to another room.
+func roomSecret(roomKey string) string {
+	var session lcu.ChampSelectSession
+	if err := lcu.Default().Get(lcu.EndpointChampSelectSession, &session); err != nil {
+		return ""
+	}
+	if session.ChatDetails.MultiUserChatID != roomKey {
+		return ""
+	}
+	return session.ChatDetails.MultiUserChatPassword
+}
+
 // toString converts interface{} to string (handles both string and number types)
 func toString(v interface{}) string {
 	if v == nil {
@@ -146,7 +311,7 @@ func toString(v interface{}) string {
 	}
 }
 
-var clients = make(map[*websocket.Conn]bool)
+var clients = make(map[*websocket.Conn]*sync.Mutex) // connection -> its write lock
 var clientsMu sync.Mutex
 
 // Last applied skin state — survives client reconnects
@@ -159,13 +324,69 @@ var lastChromaName string
 var lastModKey string
 var stateMu sync.Mutex
 
-// Prebuild state — tracks overlay pre-built during champion select
+// Overlay build state — builds happen in config.OverlayDir, one at a time,
+// and finished overlays are kept in the cache keyed by mod content and game version.
+// Package vars are built before config.Init, so StartServer applies the saved limit.
 var overlayBuildMu sync.Mutex
-var prebuiltModKey string
+var overlayCache = overlay.NewCache(config.OverlayCacheDir, overlay.DefaultCacheSize)
+
+// Persistent log of every apply, for usage and failure statistics
+var applyHistory = history.NewLog(filepath.Join(config.AmeDir, "history.jsonl"), history.DefaultMaxEntries)
+
+// Random skin picker driven by the apply history
+var randomSkins = randomskin.NewEngine(applyHistory)
+
+// Skins set aside after failing repeatedly. StartServer applies the saved threshold.
+var skinQuarantine = quarantine.NewStore(filepath.Join(config.AmeDir, "quarantine.json"), quarantine.DefaultThreshold)
+
+// Skins in the running overlay, for attributing overlay failures and crashes.
+// Reset on every apply so each run reports at most one failure.
+var overlayRunMu sync.Mutex
+var overlayRunSkins []string
+var overlayRunOwn string
+var overlayRunReported bool
+
+// Memoized skin archive hashes, keyed by path, size and mtime
+var archiveHashes = make(map[string]string)
+var archiveHashesMu sync.Mutex
+
+// defaultBuildTimeout bounds an overlay build when no timeout is configured.
+const defaultBuildTimeout = 3 * time.Minute
+
+// In-flight overlay build — lets cleanup or a different apply abort it
+var buildCancelMu sync.Mutex
+var buildCancel context.CancelFunc
+var buildKey string
 
 // Room party state
 var roomState = roomparty.NewRoomState()
 
+// writeMessage sends a text frame to conn. gorilla/websocket allows one
+// writer at a time and replies, broadcasts and background handlers all
+// write, so every write goes through the connection's lock.
+func writeMessage(conn *websocket.Conn, data []byte) error {
+	clientsMu.Lock()
+	writeMu := clients[conn]
+	clientsMu.Unlock()
+	if writeMu == nil {
+		return errors.New("connection closed")
+	}
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// connectedClients returns the open connections.
+func connectedClients() []*websocket.Conn {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	conns := make([]*websocket.Conn, 0, len(clients))
+	for conn := range clients {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
 // sendStatus sends a status message to the WebSocket client
 func sendStatus(conn *websocket.Conn, status, message string) {
 	payload := StatusMessage{
@@ -174,7 +395,7 @@ func sendStatus(conn *websocket.Conn, status, message string) {
 		Message: message,
 	}
 	data, _ := json.Marshal(payload)
-	conn.WriteMessage(websocket.TextMessage, data)
+	writeMessage(conn, data)
 
 	// Log important status changes to the live display
 	switch status {
@@ -185,6 +406,22 @@ func sendStatus(conn *websocket.Conn, status, message string) {
 	}
 }
 
+// modPriority returns the configured mod priority, or the default if unset.
+func modPriority() []string {
+	if p := config.ModPriority(); overlay.ValidPriority(p) {
+		return p
+	}
+	return overlay.DefaultPriority
+}
+
+// overlayCacheSize returns the configured overlay cache limit, or the default if unset.
+func overlayCacheSize() int {
+	if n := config.OverlayCacheSize(); n > 0 {
+		return n
+	}
+	return overlay.DefaultCacheSize
+}
+
 // OnUninstall is called after uninstall cleanup to trigger app exit.
 var OnUninstall func()
 
@@ -228,6 +465,7 @@ func handleUninstall(conn *websocket.Conn) {
 	os.RemoveAll(config.SkinsDir)
 	os.RemoveAll(config.ModsDir)
 	os.RemoveAll(config.OverlayDir)
+	os.RemoveAll(config.OverlayCacheDir)
 
 	// Schedule deletion of ame directory after process exits.
 	// Retries for 30s to handle locked files (core.dll released after client restart).
@@ -249,8 +487,15 @@ func handleUninstall(conn *websocket.Conn) {
 	}
 }
 
+// applyMu serializes applies. The plugin, random skin picks and favorites
+// can each start one, and they share ModsDir, the overlay and the applied state.
+var applyMu sync.Mutex
+
 // handleApply handles skin apply request
 func handleApply(conn *websocket.Conn, championID, skinID, baseSkinID, championName, skinName, chromaName string) {
+	applyMu.Lock()
+	defer applyMu.Unlock()
+
 	// Compute mod key early: includes own skin + teammate skins if room party is active
 	currentModKey := skinID
 	if roomState.IsActive() {
@@ -280,6 +525,17 @@ func handleApply(conn *websocket.Conn, championID, skinID, baseSkinID, championN
 		return
 	}
 
+	// From here on every outcome is recorded in the apply history
+	start := time.Now()
+	failure := ""
+	fail := func(message string) {
+		failure = message
+		sendStatus(conn, "error", message)
+	}
+	defer func() {
+		go recordApply(championID, skinID, baseSkinID, skinName, chromaName, currentModKey, start, failure)
+	}()
+
 	// Check for cached skin file
 	zipPath := skin.GetCachedPath(championID, skinID)
 
@@ -287,7 +543,8 @@ func handleApply(conn *websocket.Conn, championID, skinID, baseSkinID, championN
 	if zipPath == "" {
 		downloaded, err := skin.Download(championID, skinID, baseSkinID, championName, skinName, chromaName)
 		if err != nil {
-			sendStatus(conn, "error", "Skin not available for download")
+			randomSkins.MarkFailed(championID, skinID, baseSkinID)
+			fail("Skin not available for download")
 			return
 		}
 		zipPath = downloaded
@@ -364,6 +621,8 @@ func handleApply(conn *websocket.Conn, championID, skinID, baseSkinID, championN
 	}()
 
 	// Build overlay (or reuse pre-built one from prefetch).
+	// A prefetch building a different mod set would only delay us.
+	cancelBuild(currentModKey)
 	overlayBuildMu.Lock()
 
 	teammateSkinCount := 0
@@ -373,39 +632,25 @@ func handleApply(conn *websocket.Conn, championID, skinID, baseSkinID, championN
 		display.Log("Apply: room party inactive, own skin only")
 	}
 
-	prebuilt := prebuiltModKey == currentModKey
-	if prebuilt {
-		// Verify overlay still exists (mkoverlay writes .wad files, not a config)
-		overlayOK := false
-		if entries, err := os.ReadDir(config.OverlayDir); err == nil {
-			for _, entry := range entries {
-				if entry.IsDir() {
-					continue
-				}
-				if strings.HasSuffix(strings.ToLower(entry.Name()), ".wad") {
-					overlayOK = true
-					break
-				}
-			}
-		}
-		if !overlayOK {
-			prebuilt = false
-		}
+	version := game.Version(gameDir)
+	if overlayCache.SetGameVersion(version) {
+		display.Log("Game patch changed, cleared cached overlays")
 	}
-	if prebuilt {
-		display.Log("Apply: using prebuilt overlay")
-		prebuiltModKey = ""
+
+	overlayDir, cacheKey, cached := lookupOverlay(championID, skinID, currentModKey, version)
+	if cached {
+		display.Log(fmt.Sprintf("Apply: using cached overlay %s", cacheKey))
 		// Count teammate skins from the mod key
 		teammateSkinCount = strings.Count(currentModKey, ",")
 	} else {
-		prebuiltModKey = ""
 		os.RemoveAll(config.ModsDir)
 		modSubDir := filepath.Join(config.ModsDir, fmt.Sprintf("skin_%s", skinID))
 		os.MkdirAll(modSubDir, os.ModePerm)
 
 		if err := skin.Extract(zipPath, modSubDir); err != nil {
 			overlayBuildMu.Unlock()
-			sendStatus(conn, "error", "Failed to extract skin archive")
+			randomSkins.MarkFailed(championID, skinID, baseSkinID)
+			fail("Failed to extract skin archive")
 			return
 		}
 
@@ -417,29 +662,47 @@ func handleApply(conn *websocket.Conn, championID, skinID, baseSkinID, championN
 		os.RemoveAll(config.OverlayDir)
 		os.MkdirAll(config.OverlayDir, os.ModePerm)
 
-		// Build mod list: own skin + teammate skins
-		modName := fmt.Sprintf("skin_%s", skinID)
-		if roomState.IsActive() {
-			modName = roomState.GetAllModNames(skinID)
+		// Build mod list: own skin + global mods + teammate skins, in load-priority order
+		mods, err := prepareMods(skinID)
+		if err != nil {
+			overlayBuildMu.Unlock()
+			fail("Failed to resolve mod conflicts")
+			return
 		}
 
-		display.Log(fmt.Sprintf("Apply: building overlay with mods: %s", modName))
-		teammateSkinCount = strings.Count(modName, "/")
-
-		success, exitCode := modtools.RunMkOverlay(config.ModsDir, config.OverlayDir, gameDir, modName)
+		builder := overlay.NewBuilder(config.OverlayBackend())
+		display.Log(fmt.Sprintf("Apply: building overlay (%s) with mods: %s", builder.Name(), overlay.Names(mods)))
+		for _, m := range mods {
+			if m.Category == overlay.CategoryTeammate {
+				teammateSkinCount++
+			}
+		}
 
-		if !success {
+		ctx, done := startBuild(currentModKey)
+		err = builder.Build(ctx, config.ModsDir, config.OverlayDir, gameDir, mods)
+		done()
+		if err != nil {
 			overlayBuildMu.Unlock()
-			sendStatus(conn, "error", fmt.Sprintf("Failed to apply skin (code %d)", exitCode))
+			display.Log(fmt.Sprintf("Apply: overlay build failed: %v", err))
+			recordBuildFailure(mods, err)
+			fail(buildErrorMessage(err))
 			return
 		}
+
+		builtKey := skinID
+		if roomState.IsActive() {
+			builtKey = roomState.ComputeBuiltModKey(skinID)
+		}
+		overlayDir, cacheKey = storeOverlay(championID, skinID, builtKey, version)
 	}
+	overlayCache.SetActive(cacheKey)
 	overlayBuildMu.Unlock()
 
 	// Start runoverlay (hooks game process when it finds it)
-	configPath := filepath.Join(config.OverlayDir, "cslol-config.json")
-	if err := modtools.RunOverlay(config.OverlayDir, configPath, gameDir); err != nil {
-		sendStatus(conn, "error", fmt.Sprintf("Failed to start overlay: %v", err))
+	configPath := filepath.Join(overlayDir, "cslol-config.json")
+	if err := modtools.RunOverlay(overlayDir, configPath, gameDir); err != nil {
+		recordSkinFailure(runSuspects(skinID, modKeySkins(currentModKey)), quarantine.ReasonOverlay, err.Error())
+		fail(fmt.Sprintf("Failed to start overlay: %v", err))
 		return
 	}
 
@@ -466,6 +729,14 @@ func handleApply(conn *websocket.Conn, championID, skinID, baseSkinID, championN
 	stateMu.Unlock()
 	display.Log(fmt.Sprintf("Apply: stored lastModKey=%s (wanted=%s)", actualModKey, currentModKey))
 
+	overlayRunMu.Lock()
+	overlayRunSkins = modKeySkins(actualModKey)
+	overlayRunOwn = skinID
+	overlayRunReported = false
+	overlayRunMu.Unlock()
+
+	randomSkins.MarkSucceeded(championID, skinID, baseSkinID)
+
 	display.SetSkin(skinName, chromaName)
 	display.SetOverlayKey("display.value.overlay_active", nil)
 
@@ -476,6 +747,33 @@ func handleApply(conn *websocket.Conn, championID, skinID, baseSkinID, championN
 	}
 }
 
+// recordApply adds an apply attempt to the history. failure is the error
+// shown to the user, or "" if the apply succeeded.
+func recordApply(championID, skinID, baseSkinID, skinName, chromaName, modKey string, start time.Time, failure string) {
+	entry := history.Entry{
+		Time:       start,
+		ChampionID: championID,
+		SkinID:     skinID,
+		BaseSkinID: baseSkinID,
+		SkinName:   skinName,
+		ChromaName: chromaName,
+		Success:    failure == "",
+		Error:      failure,
+		Duration:   time.Since(start),
+	}
+	for _, id := range strings.Split(modKey, ",") {
+		if id != "" && id != skinID {
+			entry.Teammates = append(entry.Teammates, id)
+		}
+	}
+	if q, err := lcu.Default().CurrentQueue(); err == nil {
+		entry.Queue = q.Type
+	}
+	if err := applyHistory.Add(entry); err != nil {
+		display.Log(fmt.Sprintf("! Failed to save apply history: %v", err))
+	}
+}
+
 // handlePrefetch pre-downloads a skin and pre-builds the overlay during champion select
 func handlePrefetch(conn *websocket.Conn, championID, skinID, baseSkinID, championName, skinName, chromaName string) {
 	// Download if not cached
@@ -513,12 +811,17 @@ func handlePrefetch(conn *websocket.Conn, championID, skinID, baseSkinID, champi
 		currentModKey = roomState.ComputeModKey(skinID)
 	}
 
-	// Skip if already pre-built for this exact set of skins
-	if prebuiltModKey == currentModKey {
-		display.Log(fmt.Sprintf("Prefetch: skipped (already built), modKey=%s", currentModKey))
+	version := game.Version(gameDir)
+	if overlayCache.SetGameVersion(version) {
+		display.Log("Game patch changed, cleared cached overlays")
+	}
+
+	// Skip if already built for this exact set of skins
+	if _, cacheKey, cached := lookupOverlay(championID, skinID, currentModKey, version); cached {
+		display.Log(fmt.Sprintf("Prefetch: skipped (cached as %s), modKey=%s", cacheKey, currentModKey))
 		return
 	}
-	display.Log(fmt.Sprintf("Prefetch: modKey=%s (was %s), roomActive=%v", currentModKey, prebuiltModKey, roomState.IsActive()))
+	display.Log(fmt.Sprintf("Prefetch: modKey=%s, roomActive=%v", currentModKey, roomState.IsActive()))
 
 	os.RemoveAll(config.ModsDir)
 	modSubDir := filepath.Join(config.ModsDir, fmt.Sprintf("skin_%s", skinID))
@@ -536,39 +839,355 @@ func handlePrefetch(conn *websocket.Conn, championID, skinID, baseSkinID, champi
 	os.RemoveAll(config.OverlayDir)
 	os.MkdirAll(config.OverlayDir, os.ModePerm)
 
-	// Build mod list: own skin + teammate skins
-	modName := fmt.Sprintf("skin_%s", skinID)
-	if roomState.IsActive() {
-		modName = roomState.GetAllModNames(skinID)
+	// Build mod list: own skin + global mods + teammate skins, in load-priority order
+	mods, err := prepareMods(skinID)
+	if err != nil {
+		display.Log(fmt.Sprintf("Prefetch: failed to resolve mod conflicts: %v", err))
+		return
 	}
 
-	display.Log(fmt.Sprintf("Prefetch: building overlay with mods: %s", modName))
+	builder := overlay.NewBuilder(config.OverlayBackend())
+	display.Log(fmt.Sprintf("Prefetch: building overlay (%s) with mods: %s", builder.Name(), overlay.Names(mods)))
 
-	success, exitCode := modtools.RunMkOverlay(config.ModsDir, config.OverlayDir, gameDir, modName)
-	if !success {
-		display.Log(fmt.Sprintf("Prefetch: mkoverlay failed (code %d)", exitCode))
+	ctx, done := startBuild(currentModKey)
+	err = builder.Build(ctx, config.ModsDir, config.OverlayDir, gameDir, mods)
+	done()
+	if err != nil {
+		display.Log(fmt.Sprintf("Prefetch: overlay build failed: %v", err))
+		recordBuildFailure(mods, err)
 		return
 	}
 
 	// Record what was actually built (only skins whose mod dirs exist),
 	// not the theoretical set. This avoids false cache hits when a
 	// teammate skin download failed.
+	builtKey := skinID
 	if roomState.IsActive() {
-		prebuiltModKey = roomState.ComputeBuiltModKey(skinID)
-	} else {
-		prebuiltModKey = skinID
+		builtKey = roomState.ComputeBuiltModKey(skinID)
 	}
-	display.Log(fmt.Sprintf("Skin ready (prebuilt key: %s)", prebuiltModKey))
+	_, cacheKey := storeOverlay(championID, skinID, builtKey, version)
+	display.Log(fmt.Sprintf("Skin ready (cached as %s, mod key: %s)", cacheKey, builtKey))
+}
+
+// overlayCacheKey derives the overlay cache key for a comma-separated mod key:
+// the content hash of every skin archive plus the game version and build
+// options. Returns "" if any archive is not downloaded yet.
+func overlayCacheKey(championID, skinID, modKey, version string) string {
+	teammates := roomState.GetTeammates()
+	var hashes []string
+	for _, id := range strings.Split(modKey, ",") {
+		champ := championID
+		if id != skinID {
+			champ = ""
+			for _, tm := range teammates {
+				if tm.SkinInfo.SkinID == id {
+					champ = tm.SkinInfo.ChampionID
+					break
+				}
+			}
+		}
+		if champ == "" {
+			return ""
+		}
+		path := skin.GetCachedPath(champ, id)
+		if path == "" {
+			return ""
+		}
+		h, err := archiveHash(path)
+		if err != nil {
+			return ""
+		}
+		hashes = append(hashes, id+"@"+h)
+	}
+	globals, err := overlay.HashGlobalMods(config.GlobalModsDir)
+	if err != nil {
+		return ""
+	}
+	if globals != "" {
+		hashes = append(hashes, "global@"+globals)
+	}
+	backend := overlay.NewBuilder(config.OverlayBackend()).Name()
+	return overlay.CacheKey(hashes, version, backend, strings.Join(modPriority(), ">"))
+}
+
+// archiveHash returns the content hash of a skin archive, memoized by size and mtime.
+func archiveHash(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	memo := fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano())
+
+	archiveHashesMu.Lock()
+	h, ok := archiveHashes[memo]
+	archiveHashesMu.Unlock()
+	if ok {
+		return h, nil
+	}
+
+	h, err = overlay.HashFile(path)
+	if err != nil {
+		return "", err
+	}
+	archiveHashesMu.Lock()
+	archiveHashes[memo] = h
+	archiveHashesMu.Unlock()
+	return h, nil
+}
+
+// lookupOverlay returns the cached overlay directory for modKey, if any.
+func lookupOverlay(championID, skinID, modKey, version string) (string, string, bool) {
+	key := overlayCacheKey(championID, skinID, modKey, version)
+	if key == "" {
+		return "", "", false
+	}
+	dir, ok := overlayCache.Lookup(key)
+	return dir, key, ok
+}
+
+// storeOverlay moves the overlay just built in config.OverlayDir into the
+// cache and returns where it now lives. If it cannot be cached the overlay
+// is used in place.
+func storeOverlay(championID, skinID, builtKey, version string) (string, string) {
+	key := overlayCacheKey(championID, skinID, builtKey, version)
+	if key == "" {
+		return config.OverlayDir, ""
+	}
+	dir, err := overlayCache.Store(key, builtKey, config.OverlayDir)
+	if err != nil {
+		display.Log(fmt.Sprintf("! Failed to cache overlay: %v", err))
+		return config.OverlayDir, ""
+	}
+	return dir, key
+}
+
+// prepareMods adds the user's global mods (from GlobalModsDir) to the extracted
+// skins, orders them by the configured priority, removes entries that lose a
+// conflict and reports the result to the plugin.
+// Returns the mods in load-priority order, ready for the overlay builder.
+func prepareMods(skinID string) ([]overlay.Mod, error) {
+	names := fmt.Sprintf("skin_%s", skinID)
+	if roomState.IsActive() {
+		names = roomState.GetAllModNames(skinID)
+	}
+
+	// GetAllModNames lists the own skin first, followed by teammate skins
+	var mods []overlay.Mod
+	for i, name := range strings.Split(names, "/") {
+		category := overlay.CategoryTeammate
+		if i == 0 {
+			category = overlay.CategoryOwn
+		}
+		mods = append(mods, overlay.Mod{Name: name, Category: category})
+	}
+	globals, err := overlay.CopyGlobalMods(config.GlobalModsDir, config.ModsDir)
+	if err != nil {
+		return nil, err
+	}
+	mods = append(mods, globals...)
+	mods = overlay.SortByPriority(mods, config.ModPriority())
+
+	report, err := overlay.ResolveConflicts(config.ModsDir, mods)
+	if err != nil {
+		return nil, err
+	}
+	if report.HasConflicts() {
+		for _, c := range report.Conflicts {
+			display.Log(fmt.Sprintf("Conflict: %s kept from %s, dropped from %s", c.Entry, c.Winner, strings.Join(c.Dropped, ", ")))
+		}
+	}
+	broadcastConflicts(report)
+
+	return mods, nil
+}
+
+// buildTimeout returns the configured overlay build timeout.
+func buildTimeout() time.Duration {
+	if s := config.OverlayBuildTimeout(); s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	return defaultBuildTimeout
+}
+
+// startBuild registers a new in-flight build for modKey and returns its
+// context (bounded by the build timeout) and a func to call when it finishes.
+func startBuild(modKey string) (context.Context, func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout())
+
+	buildCancelMu.Lock()
+	buildCancel = cancel
+	buildKey = modKey
+	buildCancelMu.Unlock()
+
+	return ctx, func() {
+		buildCancelMu.Lock()
+		if buildKey == modKey {
+			buildCancel = nil
+			buildKey = ""
+		}
+		buildCancelMu.Unlock()
+		cancel()
+	}
+}
+
+// cancelBuild aborts the in-flight build unless it is building keepKey.
+// Pass "" to cancel unconditionally.
+func cancelBuild(keepKey string) {
+	buildCancelMu.Lock()
+	defer buildCancelMu.Unlock()
+	if buildCancel == nil || (keepKey != "" && buildKey == keepKey) {
+		return
+	}
+	display.Log(fmt.Sprintf("Cancelling overlay build for %s", buildKey))
+	buildCancel()
+	buildCancel = nil
+	buildKey = ""
+}
+
+// buildErrorMessage turns an overlay build error into a user-facing status.
+func buildErrorMessage(err error) string {
+	var buildErr *overlay.BuildError
+	if !errors.As(err, &buildErr) {
+		return fmt.Sprintf("Failed to apply skin: %v", err)
+	}
+	switch buildErr.Kind {
+	case overlay.BuildTimeout:
+		return fmt.Sprintf("Skin build timed out after %s", buildTimeout())
+	case overlay.BuildCanceled:
+		return "Skin build was cancelled"
+	}
+	if buildErr.ExitCode != 0 {
+		return fmt.Sprintf("Failed to apply skin (code %d)", buildErr.ExitCode)
+	}
+	return fmt.Sprintf("Failed to apply skin: %v", buildErr.Err)
+}
+
+// modKeySkins splits a comma-separated mod key into skin IDs.
+func modKeySkins(modKey string) []string {
+	var ids []string
+	for _, id := range strings.Split(modKey, ",") {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// runSuspects returns the skins of an overlay, own skin included, that a
+// failure of it is counted against.
+func runSuspects(own string, skins []string) []string {
+	var teammates []string
+	for _, id := range skins {
+		if id != own {
+			teammates = append(teammates, id)
+		}
+	}
+	return quarantine.Suspects(own, teammates, "")
+}
+
+// recordBuildFailure counts a failed overlay build against the mod it was
+// traced to, or else the skins that could have caused it. Timeouts and
+// cancellations say nothing about the skins.
+func recordBuildFailure(mods []overlay.Mod, err error) {
+	var buildErr *overlay.BuildError
+	if errors.As(err, &buildErr) && (buildErr.Kind == overlay.BuildTimeout || buildErr.Kind == overlay.BuildCanceled) {
+		return
+	}
+	var own, traced string
+	var teammates []string
+	for _, m := range mods {
+		id := strings.TrimPrefix(m.Name, "skin_")
+		if buildErr != nil && buildErr.Mod == m.Name {
+			if m.Category == overlay.CategoryGlobal {
+				return // global mods are never quarantined
+			}
+			traced = id
+		}
+		switch m.Category {
+		case overlay.CategoryOwn:
+			own = id
+		case overlay.CategoryTeammate:
+			teammates = append(teammates, id)
+		}
+	}
+	recordSkinFailure(quarantine.Suspects(own, teammates, traced), quarantine.ReasonBuild, err.Error())
+}
+
+// recordSkinFailure counts a failure against skins and announces any that
+// were quarantined because of it.
+func recordSkinFailure(skinIDs []string, reason, detail string) {
+	quarantined, err := skinQuarantine.RecordFailure(skinIDs, reason, detail)
+	if err != nil {
+		display.Log(fmt.Sprintf("! Failed to save quarantine: %v", err))
+	}
+	if len(quarantined) == 0 {
+		return
+	}
+	ids := make([]string, len(quarantined))
+	for i, q := range quarantined {
+		ids[i] = q.SkinID
+	}
+	message := fmt.Sprintf("Skin %s quarantined after repeated failures", strings.Join(ids, ", "))
+	display.Log("! " + message)
+	broadcastJSON(QuarantineMessage{Type: "skinQuarantined", Skins: quarantined, Message: message})
+}
+
+// reportOverlayRun records the outcome of the running overlay once: a
+// failure against its skins, or a success that clears their failure streak.
+func reportOverlayRun(reason, detail string) {
+	overlayRunMu.Lock()
+	skins, own := overlayRunSkins, overlayRunOwn
+	if overlayRunReported || len(skins) == 0 {
+		overlayRunMu.Unlock()
+		return
+	}
+	overlayRunReported = true
+	overlayRunMu.Unlock()
+
+	if reason == "" {
+		if err := skinQuarantine.RecordSuccess(skins); err != nil {
+			display.Log(fmt.Sprintf("! Failed to save quarantine: %v", err))
+		}
+		return
+	}
+	suspects := runSuspects(own, skins)
+	display.Log(fmt.Sprintf("! Overlay failure (%s) for skins %s: %s", reason, strings.Join(suspects, ","), detail))
+	recordSkinFailure(suspects, reason, detail)
+}
+
+// crashWindow returns how soon after the hook a game exit counts as a crash.
+func crashWindow() time.Duration {
+	if s := config.QuarantineCrashWindow(); s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	return quarantine.DefaultCrashWindow
+}
+
+// watchHookedGame reports a crash if the game exits within the crash window
+// after the hook, and a success if it is still running once the window ends.
+func watchHookedGame() {
+	deadline := time.Now().Add(crashWindow())
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+		if !gameRunning() {
+			reportOverlayRun(quarantine.ReasonCrash, "game exited shortly after the hook")
+			return
+		}
+	}
+	reportOverlayRun("", "")
 }
 
 // HandleCleanup handles cleanup request
 func HandleCleanup() {
+	cancelBuild("")
 	modtools.KillModTools()
+
+	overlayRunMu.Lock()
+	overlayRunSkins, overlayRunOwn = nil, ""
+	overlayRunMu.Unlock()
 	os.RemoveAll(config.OverlayDir)
 
-	overlayBuildMu.Lock()
-	prebuiltModKey = ""
-	overlayBuildMu.Unlock()
+	overlayCache.SetActive("")
 
 	stateMu.Lock()
 	lastChampionID = ""
@@ -624,20 +1243,206 @@ func handleUnstuck(conn *websocket.Conn) {
 // broadcastRoomUpdate sends room party teammate info to all connected clients.
 func broadcastRoomUpdate(teammates []roomparty.Member) {
 	msg := RoomPartyUpdateMessage{
-		Type:      "roomPartyUpdate",
-		Teammates: teammates,
+		Type:           "roomPartyUpdate",
+		Teammates:      teammates,
+		TeammateStatus: roomState.TeammateStatuses(),
+		Connection:     roomState.Connection(),
 	}
-	data, _ := json.Marshal(msg)
+	broadcastJSON(msg)
+}
 
-	clientsMu.Lock()
-	defer clientsMu.Unlock()
-	for conn := range clients {
-		conn.WriteMessage(websocket.TextMessage, data)
+// broadcastConflicts sends the overlay conflict report to all connected clients.
+func broadcastConflicts(report overlay.ConflictReport) {
+	conflicts := report.Conflicts
+	if conflicts == nil {
+		conflicts = []overlay.Conflict{}
+	}
+	msg := ModConflictsMessage{
+		Type:      "modConflicts",
+		Mods:      report.Mods,
+		Conflicts: conflicts,
+	}
+	broadcastJSON(msg)
+}
+
+// broadcastJSON marshals v and sends it to all connected clients.
+func broadcastJSON(v interface{}) {
+	data, _ := json.Marshal(v)
+	for _, conn := range connectedClients() {
+		writeMessage(conn, data)
+	}
+}
+
+// overlayEvents queues runoverlay events for runOverlayEvents, so the output
+// reader goroutines never wait on quarantine writes or slow clients.
+var overlayEvents = make(chan modtools.Event, 256)
+
+// queueOverlayEvent is the supervisor's event handler. It must not block, so
+// when the queue is full the event is dropped.
+func queueOverlayEvent(ev modtools.Event) {
+	select {
+	case overlayEvents <- ev:
+	default:
+		display.Log(fmt.Sprintf("! Overlay: event queue full, dropped %s event", ev.Kind))
+	}
+}
+
+// runOverlayEvents handles queued runoverlay events in order.
+func runOverlayEvents() {
+	for ev := range overlayEvents {
+		handleOverlayEvent(ev)
+	}
+}
+
+// handleOverlayEvent mirrors runoverlay events to the display and the plugin.
+func handleOverlayEvent(ev modtools.Event) {
+	switch ev.Kind {
+	case modtools.EventWaiting:
+		display.SetOverlayKey("display.value.overlay_waiting", nil)
+	case modtools.EventFoundGame:
+		display.Log("Overlay: game found")
+	case modtools.EventWaitingExit:
+		display.SetOverlayKey("display.value.overlay_hooked", nil)
+		display.Log("Overlay: hooked into game")
+		go watchHookedGame()
+	case modtools.EventError:
+		display.Log("! Overlay: " + ev.Message)
+	case modtools.EventExited:
+		if ev.Message != "stopped" {
+			display.SetOverlayKey("display.value.overlay_error", nil)
+			display.Log("! Overlay " + ev.Message)
+		}
+		if strings.HasPrefix(ev.Message, "exited unexpectedly") {
+			reportOverlayRun(quarantine.ReasonOverlay, ev.Message)
+		}
+	case modtools.EventRestarting:
+		display.SetOverlayKey("display.value.overlay_restarting", nil)
+		display.Log("Overlay: " + ev.Message)
+	}
+
+	if overlayStateChanged(ev) {
+		broadcastJSON(OverlayEventMessage{Type: "overlayEvent", Event: ev})
+	}
+}
+
+var (
+	overlayStateMu   sync.Mutex
+	overlayLastState modtools.EventKind
+)
+
+// overlayStateChanged reports whether ev moves runoverlay to another state.
+// Plain output lines and repeats of the current state are not sent to the
+// plugin; exits and restarts always are.
+func overlayStateChanged(ev modtools.Event) bool {
+	switch ev.Kind {
+	case modtools.EventLog, modtools.EventStatus:
+		return false
+	}
+	overlayStateMu.Lock()
+	defer overlayStateMu.Unlock()
+	if ev.Kind == overlayLastState && ev.Kind != modtools.EventExited && ev.Kind != modtools.EventRestarting {
+		return false
+	}
+	overlayLastState = ev.Kind
+	return true
+}
+
+// gameRunning reports whether the League game process is running.
+func gameRunning() bool {
+	return suspend.FindProcess("League of Legends.exe") != 0
+}
+
+// Gameflow state — fed by LCU events, or the game process when the LCU is unreachable
+var gameflowMachine = gameflow.NewMachine()
+
+const gameflowPollInterval = 3 * time.Second
+
+// Auto-accept and auto-select, run from the backend so they work without the plugin
+var automationEngine = automation.NewEngine(lcu.Default(), automation.Settings{}, display.Log)
+
+// currentPhase returns the current gameflow phase.
+func currentPhase() gameflow.Phase {
+	phase, _ := gameflowMachine.Phase()
+	return phase
+}
+
+// handleGameflowChange tells the plugin about the new phase and cleans up
+// after a game or a dodged champ select, in case the plugin missed it. It runs
+// on the machine's handler goroutine, so the cleanup may block.
+func handleGameflowChange(t gameflow.Transition) {
+	display.Log(fmt.Sprintf("Gameflow: %s -> %s (%s)", t.From, t.To, t.Source))
+	broadcastJSON(GameflowPhaseMessage{
+		Type:     "gameflowPhase",
+		Phase:    string(t.To),
+		Previous: string(t.From),
+		Source:   t.Source,
+	})
+
+	gameEnded := t.To == gameflow.PhaseEndOfGame ||
+		(t.From == gameflow.PhaseInProgress && t.To != gameflow.PhaseEndOfGame)
+	dodged := t.From == gameflow.PhaseChampSelect &&
+		(t.To == gameflow.PhaseLobby || t.To == gameflow.PhaseNone)
+	if !gameEnded && !dodged {
+		return
+	}
+
+	stateMu.Lock()
+	applied := lastSkinID != ""
+	stateMu.Unlock()
+	if applied || modtools.IsRunning() {
+		display.Log("Gameflow: cleaning up overlay")
+		HandleCleanup()
+	}
+	if roomState.IsActive() {
+		roomState.Leave()
+		display.Log("Room Party: left room (gameflow)")
 	}
 }
 
 func init() {
 	roomState.OnUpdate = broadcastRoomUpdate
+	modtools.Overlay().SetEventHandler(queueOverlayEvent)
+	go runOverlayEvents()
+	game.OnVersionChange(handleGameVersionChange)
+	randomSkins.Favorites = favorites.SkinIDs
+	randomSkins.Skip = func(championID, skinID string) bool {
+		return skinQuarantine.IsQuarantined(skinID)
+	}
+	roomState.Skip = skinQuarantine.IsQuarantined
+	roomState.Friends = friendPuuids
+}
+
+// friendPuuids lists the puuids on the LCU friends list.
+func friendPuuids() ([]string, error) {
+	friends, err := lcu.Default().Friends()
+	if err != nil {
+		return nil, err
+	}
+	puuids := make([]string, 0, len(friends))
+	for _, f := range friends {
+		if f.PUUID != "" {
+			puuids = append(puuids, f.PUUID)
+		}
+	}
+	return puuids, nil
+}
+
+// sendFavorites replies with the saved favorites of a champion.
+func sendFavorites(conn *websocket.Conn, championID string) {
+	f := config.Favorites(championID)
+	resp := FavoritesMessage{Type: "favorites", ChampionID: championID, Favorites: &f}
+	data, _ := json.Marshal(resp)
+	writeMessage(conn, data)
+}
+
+// handleGameVersionChange reacts to a game patch: cached overlays built for
+// the old version are dropped and the display is updated.
+func handleGameVersionChange(dir, oldVersion, newVersion string) {
+	display.Log(fmt.Sprintf("Game patched: %s -> %s", oldVersion, newVersion))
+	display.SetGame(game.Patch(newVersion))
+	if overlayCache.SetGameVersion(newVersion) {
+		display.Log("Cleared cached overlays from the previous patch")
+	}
 }
 
 // handleConnection handles a single WebSocket connection
@@ -654,7 +1459,7 @@ func handleConnection(conn *websocket.Conn) {
 		display.Log("Client disconnected")
 	}()
 	clientsMu.Lock()
-	clients[conn] = true
+	clients[conn] = &sync.Mutex{}
 	clientsMu.Unlock()
 	display.SetStatusKey("display.value.connected", nil)
 	display.Log("Client connected")
@@ -699,21 +1504,52 @@ func handleConnection(conn *websocket.Conn) {
 			if path == "" {
 				path = game.FindGameDir()
 			}
-			resp := GamePathMessage{Type: "gamePath", Path: path}
+			resp := GamePathMessage{Type: "gamePath", Path: path, Version: game.Version(path)}
 			data, _ := json.Marshal(resp)
-			conn.WriteMessage(websocket.TextMessage, data)
+			writeMessage(conn, data)
 
 		case "setGamePath":
 			var msg GamePathMessage
 			if err := json.Unmarshal(message, &msg); err != nil {
 				continue
 			}
-			if err := config.SetGamePath(msg.Path); err != nil {
+			if !game.IsValidGameDir(msg.Path) {
+				sendStatus(conn, "error", "League of Legends.exe not found in that folder")
+			} else if err := game.SelectInstallation(msg.Path); err != nil {
 				sendStatus(conn, "error", "Failed to save game path")
 			} else {
-				resp := GamePathMessage{Type: "gamePath", Path: msg.Path}
+				resp := GamePathMessage{Type: "gamePath", Path: config.GamePath(), Version: game.Version(msg.Path)}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
+			}
+
+		case "listInstallations":
+			go func() {
+				resp := map[string]interface{}{
+					"type":          "installations",
+					"installations": game.Installations(),
+					"selected":      config.GamePath(),
+				}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}()
+
+		case "selectInstallation":
+			var msg GamePathMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if err := game.SelectInstallation(msg.Path); err != nil {
+				display.Log(fmt.Sprintf("! Invalid installation: %v", err))
+				sendStatus(conn, "error", "League of Legends.exe not found in that folder")
+			} else {
+				path := config.GamePath()
+				version := game.Version(path)
+				display.Log(fmt.Sprintf("Using game installation %s (%s)", path, version))
+				display.SetGame(game.Patch(version))
+				resp := GamePathMessage{Type: "gamePath", Path: path, Version: version}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
 			}
 
 		case "getSettings":
@@ -729,12 +1565,24 @@ func handleConnection(conn *websocket.Conn) {
 				"autoSelect":            s.AutoSelect,
 				"autoSelectRoles":       roles,
 				"roomParty":             s.RoomParty,
+				"roomPartyLan":          s.RoomPartyLAN,
 				"chatAvailability":      s.ChatAvailability,
 				"chatStatusMessage":     s.ChatStatusMessage,
 				"randomSkin":            s.RandomSkin,
+				"modPriority":           modPriority(),
+				"overlayBackend":        overlay.NewBuilder(s.OverlayBackend).Name(),
+				"overlayAutoRestart":    s.OverlayAutoRestart,
+				"overlayBuildTimeout":   int(buildTimeout() / time.Second),
+				"overlayCacheSize":      overlayCacheSize(),
+				"automationDryRun":      config.AutomationDryRun(),
+				"quarantineThreshold":   config.QuarantineThreshold(),
+				"quarantineCrashWindow": config.QuarantineCrashWindow(),
+				"roomServerUrl":         s.RoomServerURL,
+				"defaultRoomServerUrl":  roomparty.DefaultServerURL,
+				"teammatePolicy":        config.Teammates(),
 			}
 			data, _ := json.Marshal(resp)
-			conn.WriteMessage(websocket.TextMessage, data)
+			writeMessage(conn, data)
 
 		case "setAutoAccept":
 			var msg BoolSettingMessage
@@ -746,7 +1594,7 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := BoolSettingMessage{Type: "autoAccept", Enabled: msg.Enabled}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
 			}
 
 		case "setBenchSwap":
@@ -759,7 +1607,7 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := BoolSettingMessage{Type: "benchSwap", Enabled: msg.Enabled}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
 			}
 
 		case "setBenchSwapSkipCooldown":
@@ -772,7 +1620,7 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := BoolSettingMessage{Type: "benchSwapSkipCooldown", Enabled: msg.Enabled}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
 			}
 
 		case "setStartWithWindows":
@@ -793,7 +1641,7 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := BoolSettingMessage{Type: "startWithWindows", Enabled: msg.Enabled}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
 			}
 
 		case "setAutoUpdate":
@@ -806,7 +1654,7 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := BoolSettingMessage{Type: "autoUpdate", Enabled: msg.Enabled}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
 			}
 
 		case "setAutoSelect":
@@ -819,7 +1667,20 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := BoolSettingMessage{Type: "autoSelect", Enabled: msg.Enabled}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
+			}
+
+		case "setAutomationDryRun":
+			var msg BoolSettingMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if err := config.SetAutomationDryRun(msg.Enabled); err != nil {
+				sendStatus(conn, "error", "Failed to save automation dry-run setting")
+			} else {
+				resp := BoolSettingMessage{Type: "automationDryRun", Enabled: msg.Enabled}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
 			}
 
 		case "setAutoSelectRole":
@@ -838,7 +1699,7 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := AutoSelectRoleMessage{Type: "autoSelectRole", Role: msg.Role, Picks: msg.Picks, Bans: msg.Bans}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
 			}
 
 		case "setRoomParty":
@@ -851,7 +1712,64 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := BoolSettingMessage{Type: "roomParty", Enabled: msg.Enabled}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
+			}
+
+		case "setRoomPartyLan":
+			var msg BoolSettingMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if err := config.SetRoomPartyLAN(msg.Enabled); err != nil {
+				sendStatus(conn, "error", "Failed to save room party LAN setting")
+			} else {
+				resp := BoolSettingMessage{Type: "roomPartyLan", Enabled: msg.Enabled}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}
+
+		case "setRoomServerUrl":
+			var msg RoomServerURLMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			url := strings.TrimSpace(msg.URL)
+			if url != "" && !roomparty.ValidServerURL(url) {
+				sendStatus(conn, "error", "Invalid room server URL (expected ws:// or wss://)")
+				continue
+			}
+			if err := config.SetRoomServerURL(url); err != nil {
+				sendStatus(conn, "error", "Failed to save room server URL")
+			} else {
+				resp := RoomServerURLMessage{Type: "roomServerUrl", URL: url}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}
+
+		case "getTeammatePolicy":
+			resp := TeammatePolicyMessage{Type: "teammatePolicy", Policy: config.Teammates()}
+			data, _ := json.Marshal(resp)
+			writeMessage(conn, data)
+
+		case "setTeammatePolicy":
+			var msg TeammatePolicyMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			p := msg.Policy
+			if p.MaxSkins < 0 || p.MaxDownloadMB < 0 {
+				sendStatus(conn, "error", "Teammate skin limits must not be negative")
+				continue
+			}
+			if err := config.SetTeammates(p); err != nil {
+				sendStatus(conn, "error", "Failed to save teammate skin policy")
+				continue
+			}
+			resp := TeammatePolicyMessage{Type: "teammatePolicy", Policy: config.Teammates()}
+			data, _ := json.Marshal(resp)
+			writeMessage(conn, data)
+			if roomState.IsActive() {
+				go broadcastRoomUpdate(roomState.GetTeammates())
 			}
 
 		case "setRandomSkin":
@@ -859,14 +1777,105 @@ func handleConnection(conn *websocket.Conn) {
 			if err := json.Unmarshal(message, &msg); err != nil {
 				continue
 			}
+			if !randomskin.ValidMode(msg.Mode) {
+				sendStatus(conn, "error", "Invalid random skin mode")
+				continue
+			}
 			if err := config.SetRandomSkin(msg.Mode); err != nil {
 				sendStatus(conn, "error", "Failed to save random skin setting")
 			} else {
 				resp := RandomSkinMessage{Type: "randomSkin", Mode: msg.Mode}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
+			}
+
+		case "setModPriority":
+			var msg ModPriorityMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if !overlay.ValidPriority(msg.Priority) {
+				sendStatus(conn, "error", "Invalid mod priority")
+				continue
+			}
+			if err := config.SetModPriority(msg.Priority); err != nil {
+				sendStatus(conn, "error", "Failed to save mod priority setting")
+			} else {
+				resp := ModPriorityMessage{Type: "modPriority", Priority: msg.Priority}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}
+
+		case "setOverlayBackend":
+			var msg OverlayBackendMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if !overlay.ValidBackend(msg.Backend) {
+				sendStatus(conn, "error", "Invalid overlay backend")
+				continue
+			}
+			if err := config.SetOverlayBackend(msg.Backend); err != nil {
+				sendStatus(conn, "error", "Failed to save overlay backend setting")
+			} else {
+				resp := OverlayBackendMessage{Type: "overlayBackend", Backend: msg.Backend}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}
+
+		case "setOverlayAutoRestart":
+			var msg BoolSettingMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if err := config.SetOverlayAutoRestart(msg.Enabled); err != nil {
+				sendStatus(conn, "error", "Failed to save overlay auto-restart setting")
+			} else {
+				modtools.Overlay().SetAutoRestart(msg.Enabled, gameRunning)
+				resp := BoolSettingMessage{Type: "overlayAutoRestart", Enabled: msg.Enabled}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
 			}
 
+		case "setOverlayBuildTimeout":
+			var msg OverlayBuildTimeoutMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if msg.Seconds < 0 {
+				sendStatus(conn, "error", "Invalid overlay build timeout")
+				continue
+			}
+			if err := config.SetOverlayBuildTimeout(msg.Seconds); err != nil {
+				sendStatus(conn, "error", "Failed to save overlay build timeout")
+			} else {
+				resp := OverlayBuildTimeoutMessage{Type: "overlayBuildTimeout", Seconds: int(buildTimeout() / time.Second)}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}
+
+		case "setOverlayCacheSize":
+			var msg OverlayCacheSizeMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if msg.Size < 1 {
+				sendStatus(conn, "error", "Invalid overlay cache size")
+				continue
+			}
+			if err := config.SetOverlayCacheSize(msg.Size); err != nil {
+				sendStatus(conn, "error", "Failed to save overlay cache size")
+			} else {
+				overlayCache.SetLimit(msg.Size)
+				resp := OverlayCacheSizeMessage{Type: "overlayCacheSize", Size: msg.Size}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}
+
+		case "clearOverlayCache":
+			overlayCache.Clear()
+			sendStatus(conn, "ready", "Overlay cache cleared")
+
 		case "setChatStatus":
 			var msg ChatStatusSettingMessage
 			if err := json.Unmarshal(message, &msg); err != nil {
@@ -877,7 +1886,7 @@ func handleConnection(conn *websocket.Conn) {
 			} else {
 				resp := ChatStatusSettingMessage{Type: "chatStatus", Availability: msg.Availability, StatusMessage: msg.StatusMessage}
 				data, _ := json.Marshal(resp)
-				conn.WriteMessage(websocket.TextMessage, data)
+				writeMessage(conn, data)
 			}
 
 		case "roomPartyJoin":
@@ -889,7 +1898,15 @@ func handleConnection(conn *websocket.Conn) {
 				display.Log("Room Party: join ignored (setting disabled)")
 				continue
 			}
-			roomState.Join(msg.RoomKey, msg.Puuid, msg.TeamPuuids)
+			secret := msg.RoomSecret
+			if secret == "" {
+				secret = roomSecret(msg.RoomKey)
+			}
+			if secret == "" {
+				display.Log("! Room Party: join ignored (no room secret)")
+				continue
+			}
+			roomState.Join(msg.RoomKey, secret, msg.Puuid, msg.TeamPuuids)
 			display.Log(fmt.Sprintf("Room Party: joined room %s with %d teammates", msg.RoomKey, len(msg.TeamPuuids)))
 
 		case "roomPartySkin":
@@ -931,21 +1948,264 @@ func handleConnection(conn *websocket.Conn) {
 				SkinName:      lastSkinName,
 				ChromaName:    lastChromaName,
 				OverlayActive: modtools.IsRunning() && lastSkinID != "",
+				Phase:         string(currentPhase()),
 			}
 			stateMu.Unlock()
 			data, _ := json.Marshal(state)
-			conn.WriteMessage(websocket.TextMessage, data)
+			writeMessage(conn, data)
+
+		case "getSkinCandidates":
+			var msg SkinCandidatesMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			championID := toString(msg.ChampionID)
+			go func() {
+				candidates, err := skin.Candidates(championID)
+				if err != nil {
+					display.Log(fmt.Sprintf("! Failed to list skins for champion %s: %v", championID, err))
+					sendStatus(conn, "error", "Failed to list skins")
+					return
+				}
+				resp := SkinCandidatesMessage{Type: "skinCandidates", ChampionID: championID, Candidates: candidates}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}()
+
+		case "pickRandomSkin":
+			var msg RandomSkinPickMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			championID := toString(msg.ChampionID)
+			mode := msg.Mode
+			if mode == "" {
+				mode = config.RandomSkin()
+			}
+			if !randomskin.ValidMode(mode) {
+				sendStatus(conn, "error", "Invalid random skin mode")
+				continue
+			}
+			go func() {
+				pick, err := randomSkins.Pick(championID, mode)
+				if err != nil {
+					// Reply without a skin so the plugin falls back to its own pick
+					display.Log(fmt.Sprintf("! Random skin for champion %s: %v", championID, err))
+					resp := RandomSkinPickMessage{Type: "randomSkinPick", ChampionID: championID, Mode: mode}
+					data, _ := json.Marshal(resp)
+					writeMessage(conn, data)
+					return
+				}
+				display.Log(fmt.Sprintf("Random skin (%s): %s for champion %s", mode, pick.Name, championID))
+				resp := RandomSkinPickMessage{Type: "randomSkinPick", ChampionID: championID, Mode: mode, Apply: msg.Apply, Skin: &pick}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+				if msg.Apply {
+					handleApply(conn, championID, pick.SkinID, pick.BaseSkinID, msg.ChampionName, pick.Name, "")
+				}
+			}()
+
+		case "getFavorites":
+			var msg FavoritesMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			championID := toString(msg.ChampionID)
+			if championID != "" {
+				sendFavorites(conn, championID)
+				continue
+			}
+			resp := FavoritesMessage{Type: "favorites", All: config.AllFavorites()}
+			data, _ := json.Marshal(resp)
+			writeMessage(conn, data)
+
+		case "setFavorites", "addFavorite", "removeFavorite", "setQueueFavorite":
+			var msg FavoritesMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			championID := toString(msg.ChampionID)
+			if championID == "" {
+				sendStatus(conn, "error", "Missing champion")
+				continue
+			}
+
+			var err error
+			switch msg.Type {
+			case "setFavorites":
+				var f config.ChampionFavorites
+				if msg.Favorites != nil {
+					f = *msg.Favorites
+				}
+				for queue := range f.Queues {
+					if !config.ValidQueueType(queue) {
+						err = fmt.Errorf("invalid queue type %q", queue)
+					}
+				}
+				if err == nil {
+					err = config.SetFavorites(championID, f)
+				}
+			case "addFavorite":
+				if msg.Skin == nil || msg.Skin.SkinID == "" {
+					err = fmt.Errorf("missing skin")
+					break
+				}
+				index := -1
+				if msg.Index != nil {
+					index = *msg.Index
+				}
+				err = config.AddFavorite(championID, *msg.Skin, index)
+			case "removeFavorite":
+				err = config.RemoveFavorite(championID, toString(msg.SkinID))
+			case "setQueueFavorite":
+				if !config.ValidQueueType(msg.Queue) {
+					err = fmt.Errorf("invalid queue type %q", msg.Queue)
+					break
+				}
+				err = config.SetQueueFavorite(championID, msg.Queue, msg.Skin)
+			}
+			if err != nil {
+				display.Log(fmt.Sprintf("! %s for champion %s failed: %v", msg.Type, championID, err))
+				sendStatus(conn, "error", "Failed to save favorite skins")
+				continue
+			}
+			sendFavorites(conn, championID)
+
+		case "resolveFavorite":
+			var msg ResolveFavoriteMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			championID := toString(msg.ChampionID)
+			go func() {
+				queue := msg.Queue
+				if queue == "" {
+					queue = favorites.CurrentQueueType()
+				}
+				resp := ResolveFavoriteMessage{Type: "favoriteSkin", ChampionID: championID, Queue: queue, Prefetch: msg.Prefetch, Apply: msg.Apply}
+				fav, source, ok := favorites.Resolve(championID, queue)
+				if ok {
+					resp.Skin = &fav
+					resp.Source = source
+				}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+				if !ok {
+					return
+				}
+
+				display.Log(fmt.Sprintf("Favorite skin (%s, queue=%q): %s for champion %s", source, queue, fav.SkinID, championID))
+				switch {
+				case msg.Apply:
+					handleApply(conn, championID, fav.SkinID, fav.BaseSkinID, msg.ChampionName, fav.SkinName, fav.ChromaName)
+				case msg.Prefetch:
+					handlePrefetch(conn, championID, fav.SkinID, fav.BaseSkinID, msg.ChampionName, fav.SkinName, fav.ChromaName)
+				}
+			}()
+
+		case "getMostUsedSkins", "getSkinHistory", "getFailureRates":
+			var msg HistoryMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			championID := toString(msg.ChampionID)
+			resp := HistoryMessage{ChampionID: championID, Limit: msg.Limit}
+			switch msg.Type {
+			case "getMostUsedSkins":
+				resp.Type = "mostUsedSkins"
+				resp.Stats = applyHistory.MostUsed(championID, msg.Limit)
+			case "getSkinHistory":
+				resp.Type = "skinHistory"
+				if championID == "" {
+					resp.Entries = applyHistory.Entries(nil, msg.Limit)
+				} else {
+					resp.Entries = applyHistory.Champion(championID, msg.Limit)
+				}
+			case "getFailureRates":
+				resp.Type = "failureRates"
+				resp.MinAttempts = msg.MinAttempts
+				resp.Stats = applyHistory.FailureRates(msg.MinAttempts)
+			}
+			data, _ := json.Marshal(resp)
+			writeMessage(conn, data)
+
+		case "clearHistory":
+			if err := applyHistory.Clear(); err != nil {
+				sendStatus(conn, "error", "Failed to clear history")
+			} else {
+				sendStatus(conn, "ready", "History cleared")
+			}
+
+		case "getQuarantine":
+			resp := QuarantineMessage{Type: "quarantine", Skins: skinQuarantine.All()}
+			data, _ := json.Marshal(resp)
+			writeMessage(conn, data)
+
+		case "releaseQuarantine":
+			var msg ReleaseQuarantineMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			skinID := toString(msg.SkinID)
+			if _, err := skinQuarantine.Release(skinID); err != nil {
+				sendStatus(conn, "error", "Failed to release skin from quarantine")
+				continue
+			}
+			display.Log(fmt.Sprintf("Skin %s released from quarantine", skinID))
+			resp := QuarantineMessage{Type: "quarantine", Skins: skinQuarantine.All()}
+			data, _ := json.Marshal(resp)
+			writeMessage(conn, data)
+
+		case "setQuarantineSettings":
+			var msg QuarantineSettingsMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				continue
+			}
+			if msg.Threshold < 1 {
+				sendStatus(conn, "error", "Quarantine threshold must be at least 1")
+				continue
+			}
+			if msg.CrashWindow < 0 {
+				sendStatus(conn, "error", "Invalid quarantine crash window")
+				continue
+			}
+			if err := config.SetQuarantine(msg.Threshold, msg.CrashWindow); err != nil {
+				sendStatus(conn, "error", "Failed to save quarantine settings")
+			} else {
+				skinQuarantine.SetThreshold(msg.Threshold)
+				resp := QuarantineSettingsMessage{Type: "quarantineSettings", Threshold: msg.Threshold, CrashWindow: msg.CrashWindow}
+				data, _ := json.Marshal(resp)
+				writeMessage(conn, data)
+			}
+
+		case "getGameflowPhase":
+			resp := GameflowPhaseMessage{Type: "gameflowPhase", Phase: string(currentPhase())}
+			data, _ := json.Marshal(resp)
+			writeMessage(conn, data)
+
+		case "getOverlayEvents":
+			resp := map[string]interface{}{
+				"type":   "overlayEvents",
+				"status": modtools.Overlay().Status(),
+				"events": modtools.Overlay().Events(),
+			}
+			data, _ := json.Marshal(resp)
+			writeMessage(conn, data)
 
 		case "getLogs":
 			logs := display.GetLogsJSON()
 			version := display.GetVersion()
+			gamePath := config.GamePath()
 			resp := map[string]interface{}{
-				"type":    "logs",
-				"version": version,
-				"entries": logs,
+				"type":        "logs",
+				"version":     version,
+				"gamePath":    gamePath,
+				"gameVersion": game.Version(gamePath),
+				"gameTrace":   game.Explain(),
+				"entries":     logs,
 			}
 			data, _ := json.Marshal(resp)
-			conn.WriteMessage(websocket.TextMessage, data)
+			writeMessage(conn, data)
 		}
 	}
 }
@@ -967,6 +2227,28 @@ func httpHandler(w http.ResponseWriter, r *http.Request) {
 
 // StartServer starts the WebSocket server
 func StartServer(port int) {
+	// Settings are loaded by now
+	overlayCache.SetLimit(overlayCacheSize())
+	skinQuarantine.SetThreshold(config.QuarantineThreshold())
+	if n, err := randomskin.MigrateUsage(filepath.Join(config.AmeDir, "skin_usage.json"), applyHistory); err != nil {
+		display.Log(fmt.Sprintf("! Failed to import skin usage: %v", err))
+	} else if n > 0 {
+		display.Log(fmt.Sprintf("Imported %d skin uses into the apply history", n))
+	}
+	modtools.Overlay().SetAutoRestart(config.OverlayAutoRestart(), gameRunning)
+
+	// Follow League client events; reconnects on its own across client restarts
+	lcu.Events().OnConnect(func() {
+		display.Log("Connected to League client events")
+	})
+	gameflowMachine.OnChange(handleGameflowChange)
+	gameflowMachine.FollowLCU(lcu.Events(), lcu.Default())
+	automationEngine.Attach(lcu.Events())
+	go lcu.Events().Run(context.Background())
+
+	// Fall back to watching the game process while the LCU is unreachable
+	go gameflowMachine.WatchProcess(context.Background(), gameRunning, lcu.Events().Connected, gameflowPollInterval)
+
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Upgrade") == "websocket" {
 			wsHandler(w, r)
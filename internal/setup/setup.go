@@ -227,7 +227,7 @@ func DeactivatePengu() {
 
 // createDirectories creates base directories (not Pengu-related, those are created after detection)
 func createDirectories() {
-	dirs := []string{config.AmeDir, config.ToolsDir, config.SkinsDir, config.ModsDir, config.OverlayDir}
+	dirs := []string{config.AmeDir, config.ToolsDir, config.SkinsDir, config.ModsDir, config.GlobalModsDir, config.OverlayDir}
 	for _, dir := range dirs {
 		os.MkdirAll(dir, os.ModePerm)
 	}
@@ -0,0 +1,95 @@
+package skin
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/hoangvu12/ame/internal/lcu"
+)
+
+// Candidate is a skin or chroma that could be applied for a champion.
+type Candidate struct {
+	SkinID       string `json:"skinId"`
+	BaseSkinID   string `json:"baseSkinId,omitempty"` // parent skin, set for chromas
+	Name         string `json:"name"`
+	IsBase       bool   `json:"isBase"`
+	Owned        bool   `json:"owned"`        // owned by the logged-in player
+	Downloadable bool   `json:"downloadable"` // listed in the skin catalog
+	Cached       bool   `json:"cached"`       // already downloaded
+}
+
+// Inventory supplies a champion's skins from the player's inventory, as
+// lcu.Client does.
+type Inventory interface {
+	ChampionSkins(championID int) ([]lcu.Skin, error)
+}
+
+// Candidates lists every skin and chroma of a champion, merging the
+// player's inventory from the LCU with the skin catalog and the local
+// download cache. If the LCU is unavailable the catalog alone is used and
+// nothing is marked owned.
+func Candidates(championID string) ([]Candidate, error) {
+	return candidatesFrom(lcu.Default(), championID)
+}
+
+func candidatesFrom(inventory Inventory, championID string) ([]Candidate, error) {
+	catalog, catalogErr := fetchSkinIDs()
+
+	champNum, err := strconv.Atoi(championID)
+	if err != nil {
+		return nil, err
+	}
+
+	skins, lcuErr := inventory.ChampionSkins(champNum)
+	if lcuErr != nil {
+		if catalogErr != nil {
+			return nil, catalogErr
+		}
+		return catalogCandidates(championID, champNum, catalog), nil
+	}
+
+	var out []Candidate
+	for _, s := range skins {
+		id := strconv.Itoa(s.ID)
+		out = append(out, newCandidate(championID, id, "", s.Name, s.IsBase, s.Ownership.Owned, catalog))
+		for _, c := range s.Chromas {
+			out = append(out, newCandidate(championID, strconv.Itoa(c.ID), id, c.Name, false, c.Ownership.Owned, catalog))
+		}
+	}
+	return out, nil
+}
+
+func newCandidate(championID, skinID, baseSkinID, name string, isBase, owned bool, catalog map[string]string) Candidate {
+	_, listed := catalog[skinID]
+	return Candidate{
+		SkinID:       skinID,
+		BaseSkinID:   baseSkinID,
+		Name:         name,
+		IsBase:       isBase,
+		Owned:        owned,
+		Downloadable: listed && !isBase,
+		Cached:       GetCachedPath(championID, skinID) != "",
+	}
+}
+
+// catalogCandidates builds candidates from the catalog alone. Skin IDs are
+// championID*1000 + n, so every catalog entry in that range belongs to the
+// champion; chromas cannot be told apart from skins here.
+func catalogCandidates(championID string, champNum int, catalog map[string]string) []Candidate {
+	var ids []int
+	for key := range catalog {
+		id, err := strconv.Atoi(key)
+		if err != nil || id/1000 != champNum {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	out := make([]Candidate, 0, len(ids))
+	for _, id := range ids {
+		key := strconv.Itoa(id)
+		out = append(out, newCandidate(championID, key, "", catalog[key], id == champNum*1000, false, catalog))
+	}
+	return out
+}
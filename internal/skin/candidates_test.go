@@ -0,0 +1,122 @@
+package skin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hoangvu12/ame/internal/config"
+	"github.com/hoangvu12/ame/internal/lcu"
+)
+
+// fakeInventory starts a fake LCU serving summoner 42's skins and returns a
+// client for it.
+func fakeInventory(t *testing.T, skins map[int][]lcu.Skin) *lcu.Client {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "riot" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/lol-summoner/v1/current-summoner" {
+			json.NewEncoder(w).Encode(lcu.Summoner{SummonerID: 42})
+			return
+		}
+		var champion int
+		if _, err := fmt.Sscanf(r.URL.Path, "/lol-champions/v1/inventories/42/champions/%d/skins", &champion); err == nil {
+			json.NewEncoder(w).Encode(skins[champion])
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	dir := t.TempDir()
+	lockfile := fmt.Sprintf("LeagueClient:1234:%s:token:https", u.Port())
+	if err := os.WriteFile(filepath.Join(dir, "lockfile"), []byte(lockfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return lcu.NewClient(func() string { return dir })
+}
+
+// offline is an Inventory for a League client that is not running.
+type offline struct{}
+
+func (offline) ChampionSkins(int) ([]lcu.Skin, error) { return nil, lcu.ErrNotRunning }
+
+// seedSkinsDir points the download cache at a temp dir holding the given
+// champion/skin archives.
+func seedSkinsDir(t *testing.T, files ...string) {
+	t.Helper()
+	old := config.SkinsDir
+	config.SkinsDir = t.TempDir()
+	t.Cleanup(func() { config.SkinsDir = old })
+	for _, f := range files {
+		path := filepath.Join(config.SkinsDir, filepath.FromSlash(f))
+		os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	seedCatalog(t, "103000", "103001", "103017", "1001")
+	seedSkinsDir(t, "103/103001/103001.zip", "103/103017/103017.fantome")
+	client := fakeInventory(t, map[int][]lcu.Skin{
+		103: {
+			{ID: 103000, Name: "Ahri", IsBase: true, Ownership: lcu.Ownership{Owned: true}},
+			{ID: 103001, Name: "Dynasty Ahri", Chromas: []lcu.Chroma{
+				{ID: 103017, Name: "Ruby", Ownership: lcu.Ownership{Owned: true}},
+			}},
+			{ID: 103002, Name: "Midnight Ahri", Ownership: lcu.Ownership{Owned: true}},
+		},
+	})
+
+	got, err := candidatesFrom(client, "103")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Candidate{
+		{SkinID: "103000", Name: "Ahri", IsBase: true, Owned: true},
+		{SkinID: "103001", Name: "Dynasty Ahri", Downloadable: true, Cached: true},
+		{SkinID: "103017", BaseSkinID: "103001", Name: "Ruby", Owned: true, Downloadable: true, Cached: true},
+		{SkinID: "103002", Name: "Midnight Ahri", Owned: true}, // not in the catalog
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestCandidatesCatalogOnly(t *testing.T) {
+	seedCatalog(t, "103017", "103000", "103001", "1001", "104000")
+	seedSkinsDir(t, "103/103001/103001.zip")
+
+	// Without the LCU nothing is owned and chromas look like skins
+	got, err := candidatesFrom(offline{}, "103")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Candidate{
+		{SkinID: "103000", Name: "skin 103000", IsBase: true},
+		{SkinID: "103001", Name: "skin 103001", Downloadable: true, Cached: true},
+		{SkinID: "103017", Name: "skin 103017", Downloadable: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates:\n got %+v\nwant %+v", got, want)
+	}
+
+	if _, err := candidatesFrom(offline{}, "ahri"); err == nil {
+		t.Error("non-numeric champion: want error")
+	}
+	if got, err := candidatesFrom(offline{}, "999"); err != nil || len(got) != 0 {
+		t.Errorf("champion without skins: got %+v, %v", got, err)
+	}
+}
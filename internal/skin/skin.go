@@ -3,6 +3,7 @@ package skin
 import (
 	"archive/zip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -19,6 +20,9 @@ import (
 const SKIN_BASE_URL = "https://raw.githubusercontent.com/Alban1911/LeagueSkins/main/skins"
 const SKIN_IDS_URL = "https://raw.githubusercontent.com/Alban1911/LeagueSkins/refs/heads/main/resources/en/skin_ids.json"
 
+// ErrTooLarge is returned by DownloadMax when a skin exceeds the size limit.
+var ErrTooLarge = errors.New("skin exceeds the download size limit")
+
 var (
 	skinIDsCache   map[string]string
 	skinIDsCacheMu sync.Mutex
@@ -55,6 +59,40 @@ func fetchSkinIDs() (map[string]string, error) {
 	return data, nil
 }
 
+// Validate checks that a skin shared by another player is a real, downloadable
+// skin of the champion: numeric IDs in the champion's range (champion*1000+n),
+// not the base skin, and listed in the skin catalog.
+func Validate(championID, skinID, baseSkinID string) error {
+	champNum, err := strconv.Atoi(championID)
+	if err != nil || champNum <= 0 {
+		return fmt.Errorf("invalid champion ID %q", championID)
+	}
+	ids := []string{skinID}
+	if baseSkinID != "" {
+		ids = append(ids, baseSkinID)
+	}
+	for _, id := range ids {
+		num, err := strconv.Atoi(id)
+		if err != nil || num/1000 != champNum || strconv.Itoa(num) != id {
+			return fmt.Errorf("skin ID %q does not belong to champion %s", id, championID)
+		}
+	}
+	if skinID == strconv.Itoa(champNum*1000) {
+		return fmt.Errorf("skin %s is the base skin", skinID)
+	}
+
+	catalog, err := fetchSkinIDs()
+	if err != nil {
+		return fmt.Errorf("skin catalog unavailable: %w", err)
+	}
+	for _, id := range ids {
+		if _, ok := catalog[id]; !ok {
+			return fmt.Errorf("skin %s is not in the catalog", id)
+		}
+	}
+	return nil
+}
+
 // resolveEnglishNames looks up English champion/skin/chroma names from the skin IDs mapping
 func resolveEnglishNames(championID, skinID, baseSkinID string) (champName, skinName, chromaName string) {
 	data, err := fetchSkinIDs()
@@ -82,6 +120,12 @@ func resolveEnglishNames(championID, skinID, baseSkinID string) (champName, skin
 
 // Download downloads a skin file (.fantome or .zip)
 func Download(championID, skinID, baseSkinID, championName, skinName, chromaName string) (string, error) {
+	return DownloadMax(0, championID, skinID, baseSkinID, championName, skinName, chromaName)
+}
+
+// DownloadMax is Download with a size limit in bytes (0 for none). Skins over
+// the limit are not kept and ErrTooLarge is returned.
+func DownloadMax(maxBytes int64, championID, skinID, baseSkinID, championName, skinName, chromaName string) (string, error) {
 	// Resolve English names from skin IDs mapping (overrides localized names from client)
 	enChamp, enSkin, enChroma := resolveEnglishNames(championID, skinID, baseSkinID)
 	if enChamp != "" {
@@ -133,9 +177,13 @@ func Download(championID, skinID, baseSkinID, championName, skinName, chromaName
 
 		filePath := filepath.Join(skinDir, fmt.Sprintf("%s.%s", skinID, ext))
 
-		if err := downloadFile(downloadURL, filePath); err == nil {
+		err := downloadFile(downloadURL, filePath, maxBytes)
+		if err == nil {
 			return filePath, nil
 		}
+		if errors.Is(err, ErrTooLarge) {
+			return "", err
+		}
 	}
 
 	return "", fmt.Errorf("skin not available for download")
@@ -208,8 +256,9 @@ func GetCachedPath(championID, skinID string) string {
 	return ""
 }
 
-// downloadFile downloads a file from URL to destination
-func downloadFile(url, dest string) error {
+// downloadFile downloads a file from URL to destination, failing with
+// ErrTooLarge past maxBytes (0 for no limit)
+func downloadFile(url, dest string, maxBytes int64) error {
 	resp, err := http.Get(url)
 	if err != nil {
 		return err
@@ -219,13 +268,26 @@ func downloadFile(url, dest string) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("bad status: %d", resp.StatusCode)
 	}
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return ErrTooLarge
+	}
 
 	out, err := os.Create(dest)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
+	body := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		body = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	n, err := io.Copy(out, body)
+	out.Close()
+	if err == nil && maxBytes > 0 && n > maxBytes {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		os.Remove(dest)
+	}
 	return err
 }
@@ -0,0 +1,56 @@
+package skin
+
+import (
+	"strings"
+	"testing"
+)
+
+// seedCatalog stands in for the downloaded skin catalog.
+func seedCatalog(t *testing.T, ids ...string) {
+	t.Helper()
+	catalog := make(map[string]string, len(ids))
+	for _, id := range ids {
+		catalog[id] = "skin " + id
+	}
+	skinIDsCacheMu.Lock()
+	old := skinIDsCache
+	skinIDsCache = catalog
+	skinIDsCacheMu.Unlock()
+	t.Cleanup(func() {
+		skinIDsCacheMu.Lock()
+		skinIDsCache = old
+		skinIDsCacheMu.Unlock()
+	})
+}
+
+func TestValidate(t *testing.T) {
+	seedCatalog(t, "103000", "103001", "103015", "103016", "1001")
+
+	tests := []struct {
+		name                       string
+		championID, skinID, baseID string
+		err                        string // substring of the expected error, "" for valid
+	}{
+		{"skin", "103", "103001", "", ""},
+		{"chroma", "103", "103016", "103015", ""},
+		{"wrong champion", "103", "1001", "", "does not belong to champion 103"},
+		{"chroma of another champion's skin", "103", "103016", "1001", "does not belong to champion 103"},
+		{"not in catalog", "103", "103002", "", "not in the catalog"},
+		{"chroma base not in catalog", "103", "103016", "103014", "not in the catalog"},
+		{"base skin", "103", "103000", "", "is the base skin"},
+		{"leading zero", "103", "0103001", "", "does not belong"},
+		{"not a number", "103", "103abc", "", "does not belong"},
+		{"bad champion", "ahri", "103001", "", "invalid champion ID"},
+		{"zero champion", "0", "1", "", "invalid champion ID"},
+		{"path in skin ID", "103", "../103001", "", "does not belong"},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.championID, tt.skinID, tt.baseID)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.err)
+		}
+	}
+}
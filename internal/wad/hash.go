@@ -0,0 +1,104 @@
+package wad
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// xxHash primes. Declared as variables so the seed setup below can wrap
+// around instead of overflowing as constant expressions.
+var (
+	prime64_1 uint64 = 11400714785074694791
+	prime64_2 uint64 = 14029467366897019727
+	prime64_3 uint64 = 1609587929392839161
+	prime64_4 uint64 = 9650029242287828579
+	prime64_5 uint64 = 2870177450012600261
+)
+
+// HashPath returns the WAD path hash for a game file path: XXH64 (seed 0)
+// of the lowercased, forward-slash path.
+func HashPath(path string) uint64 {
+	p := strings.ToLower(strings.ReplaceAll(path, "\\", "/"))
+	return xxh64([]byte(p))
+}
+
+// ParseHashName recognizes file names that are already a path hash, as
+// written by extractors for entries with unknown paths (e.g. "1a2b3c4d5e6f7a8b.bin").
+func ParseHashName(name string) (uint64, bool) {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	if len(name) != 16 {
+		return 0, false
+	}
+	h, err := strconv.ParseUint(name, 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return h, true
+}
+
+// xxh64 computes the 64-bit xxHash of b with seed 0.
+func xxh64(b []byte) uint64 {
+	n := len(b)
+	var h uint64
+
+	if n >= 32 {
+		v1 := prime64_1 + prime64_2
+		v2 := prime64_2
+		v3 := uint64(0)
+		v4 := -prime64_1
+		for len(b) >= 32 {
+			v1 = round(v1, binary.LittleEndian.Uint64(b[0:8]))
+			v2 = round(v2, binary.LittleEndian.Uint64(b[8:16]))
+			v3 = round(v3, binary.LittleEndian.Uint64(b[16:24]))
+			v4 = round(v4, binary.LittleEndian.Uint64(b[24:32]))
+			b = b[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = mergeRound(h, v1)
+		h = mergeRound(h, v2)
+		h = mergeRound(h, v3)
+		h = mergeRound(h, v4)
+	} else {
+		h = prime64_5
+	}
+
+	h += uint64(n)
+
+	for ; len(b) >= 8; b = b[8:] {
+		k1 := round(0, binary.LittleEndian.Uint64(b[:8]))
+		h ^= k1
+		h = bits.RotateLeft64(h, 27)*prime64_1 + prime64_4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b[:4])) * prime64_1
+		h = bits.RotateLeft64(h, 23)*prime64_2 + prime64_3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * prime64_5
+		h = bits.RotateLeft64(h, 11) * prime64_1
+	}
+
+	h ^= h >> 33
+	h *= prime64_2
+	h ^= h >> 29
+	h *= prime64_3
+	h ^= h >> 32
+	return h
+}
+
+func round(acc, input uint64) uint64 {
+	acc += input * prime64_2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * prime64_1
+}
+
+func mergeRound(acc, val uint64) uint64 {
+	val = round(0, val)
+	acc ^= val
+	return acc*prime64_1 + prime64_4
+}
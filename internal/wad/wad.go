@@ -0,0 +1,414 @@
+// Package wad reads League of Legends .wad.client archives (version 3.x).
+package wad
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Entry data encodings.
+const (
+	TypeNone      = 0 // stored uncompressed
+	TypeGzip      = 1
+	TypeLink      = 2 // satellite/redirect entry, payload is a path string
+	TypeZstd      = 3
+	TypeZstdMulti = 4 // zstd with leading uncompressed subchunks
+)
+
+const (
+	headerSize   = 272 // magic(2) + version(2) + signature(256) + checksum(8) + count(4)
+	entrySize    = 32
+	subchunkSize = 16 // compressed size(4) + size(4) + checksum(8)
+
+	// maxSizeHint caps buffers preallocated from the (untrusted) entry size.
+	maxSizeHint = 64 << 20
+)
+
+var magic = [2]byte{'R', 'W'}
+
+// Entry is a single table-of-contents record.
+type Entry struct {
+	Hash           uint64
+	Offset         uint32
+	CompressedSize uint32
+	Size           uint32
+	Type           uint8
+	SubchunkCount  uint8
+	Duplicate      bool
+	FirstSubchunk  uint32
+	Checksum       uint64
+}
+
+// Subchunk is one record of a WAD's subchunk table, which splits
+// TypeZstdMulti entries into separately stored pieces.
+type Subchunk struct {
+	CompressedSize uint32
+	Size           uint32
+	Checksum       uint64
+}
+
+// Archive is an opened WAD file.
+type Archive struct {
+	Major     uint8
+	Minor     uint8
+	Checksum  uint64
+	Entries   []Entry
+	Subchunks []Subchunk // nil unless the subchunk table was loaded
+
+	r      io.ReaderAt
+	size   int64 // size of r, or -1 if unknown
+	closer io.Closer
+	index  map[uint64]int
+}
+
+// Open opens and parses the WAD at path.
+func Open(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := Read(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	a.closer = f
+	if toc := subchunkTOCName(path); toc != "" {
+		// Only TypeZstdMulti entries need it; they fail to read without it
+		a.LoadSubchunks(toc)
+	}
+	return a, nil
+}
+
+// subchunkTOCName returns the in-archive path of the subchunk table for the
+// WAD at path, e.g. ".../DATA/FINAL/Champions/Ahri.wad.client" ->
+// "data/final/champions/ahri.wad.subchunktoc". Returns "" if path is not
+// under a DATA directory.
+func subchunkTOCName(path string) string {
+	p := strings.ToLower(filepath.ToSlash(path))
+	if i := strings.LastIndex(p, "/data/"); i >= 0 {
+		p = p[i+1:]
+	} else if !strings.HasPrefix(p, "data/") {
+		return ""
+	}
+	return strings.TrimSuffix(p, ".client") + ".subchunktoc"
+}
+
+// LoadSubchunks reads the subchunk table stored in the archive under name.
+func (a *Archive) LoadSubchunks(name string) error {
+	e, ok := a.Lookup(HashPath(name))
+	if !ok {
+		return fmt.Errorf("subchunk table %s not found", name)
+	}
+	data, err := a.ReadEntry(e)
+	if err != nil {
+		return err
+	}
+	if len(data)%subchunkSize != 0 {
+		return fmt.Errorf("subchunk table %s: size %d is not a multiple of %d", name, len(data), subchunkSize)
+	}
+	chunks := make([]Subchunk, len(data)/subchunkSize)
+	for i := range chunks {
+		b := data[i*subchunkSize:]
+		chunks[i] = Subchunk{
+			CompressedSize: binary.LittleEndian.Uint32(b[0:4]),
+			Size:           binary.LittleEndian.Uint32(b[4:8]),
+			Checksum:       binary.LittleEndian.Uint64(b[8:16]),
+		}
+	}
+	a.Subchunks = chunks
+	return nil
+}
+
+// Read parses a WAD header and table of contents from r.
+func Read(r io.ReaderAt) (*Archive, error) {
+	var hdr [headerSize]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if hdr[0] != magic[0] || hdr[1] != magic[1] {
+		return nil, fmt.Errorf("not a WAD file")
+	}
+
+	a := &Archive{
+		Major: hdr[2],
+		Minor: hdr[3],
+		r:     r,
+		size:  readerSize(r),
+	}
+	if a.Major != 3 {
+		return nil, fmt.Errorf("unsupported WAD version %d.%d", a.Major, a.Minor)
+	}
+	a.Checksum = binary.LittleEndian.Uint64(hdr[260:268])
+	count := binary.LittleEndian.Uint32(hdr[268:272])
+
+	// Archives come from untrusted mods: make sure the table fits in the
+	// file before allocating it.
+	if err := a.checkRange(headerSize, int64(count)*entrySize); err != nil {
+		return nil, fmt.Errorf("entry table of %d entries: %w", count, err)
+	}
+	toc := make([]byte, int(count)*entrySize)
+	if _, err := r.ReadAt(toc, headerSize); err != nil {
+		return nil, fmt.Errorf("failed to read entries: %w", err)
+	}
+
+	a.Entries = make([]Entry, count)
+	a.index = make(map[uint64]int, count)
+	for i := range a.Entries {
+		b := toc[i*entrySize : (i+1)*entrySize]
+		e := Entry{
+			Hash:           binary.LittleEndian.Uint64(b[0:8]),
+			Offset:         binary.LittleEndian.Uint32(b[8:12]),
+			CompressedSize: binary.LittleEndian.Uint32(b[12:16]),
+			Size:           binary.LittleEndian.Uint32(b[16:20]),
+			Type:           b[20] & 0x0F,
+			SubchunkCount:  b[20] >> 4,
+			Checksum:       binary.LittleEndian.Uint64(b[24:32]),
+		}
+		if a.Minor >= 4 {
+			// 3.4 widened the subchunk index to 24 bits and dropped the duplicate flag
+			e.FirstSubchunk = uint32(b[21]) | uint32(b[22])<<8 | uint32(b[23])<<16
+		} else {
+			e.Duplicate = b[21] != 0
+			e.FirstSubchunk = uint32(binary.LittleEndian.Uint16(b[22:24]))
+		}
+		a.Entries[i] = e
+		a.index[e.Hash] = i
+	}
+
+	return a, nil
+}
+
+// readerSize returns the size of r when it can tell, or -1.
+func readerSize(r io.ReaderAt) int64 {
+	switch v := r.(type) {
+	case interface{ Size() int64 }:
+		return v.Size()
+	case interface{ Stat() (os.FileInfo, error) }:
+		if fi, err := v.Stat(); err == nil {
+			return fi.Size()
+		}
+	}
+	return -1
+}
+
+// checkRange reports an error if n bytes at off run past the end of the
+// archive. When the size is unknown it probes the last byte instead.
+func (a *Archive) checkRange(off, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if a.size >= 0 {
+		if off+n > a.size {
+			return fmt.Errorf("runs past end of file (%d > %d bytes)", off+n, a.size)
+		}
+		return nil
+	}
+	var b [1]byte
+	if _, err := a.r.ReadAt(b[:], off+n-1); err != nil {
+		return fmt.Errorf("runs past end of file: %w", err)
+	}
+	return nil
+}
+
+// Close releases the underlying file, if the archive was opened with Open.
+func (a *Archive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
+
+// Lookup returns the entry with the given path hash.
+func (a *Archive) Lookup(hash uint64) (Entry, bool) {
+	i, ok := a.index[hash]
+	if !ok {
+		return Entry{}, false
+	}
+	return a.Entries[i], true
+}
+
+// Hashes returns every entry hash in the archive, sorted.
+func (a *Archive) Hashes() []uint64 {
+	hashes := make([]uint64, len(a.Entries))
+	for i, e := range a.Entries {
+		hashes[i] = e.Hash
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	return hashes
+}
+
+// ReadRaw returns the entry bytes exactly as stored in the archive.
+func (a *Archive) ReadRaw(e Entry) ([]byte, error) {
+	if err := a.checkRange(int64(e.Offset), int64(e.CompressedSize)); err != nil {
+		return nil, fmt.Errorf("entry %016x: %w", e.Hash, err)
+	}
+	data := make([]byte, e.CompressedSize)
+	if len(data) == 0 {
+		return data, nil
+	}
+	if _, err := a.r.ReadAt(data, int64(e.Offset)); err != nil {
+		return nil, fmt.Errorf("entry %016x: %w", e.Hash, err)
+	}
+	return data, nil
+}
+
+// ReadEntry returns the decompressed contents of e.
+func (a *Archive) ReadEntry(e Entry) ([]byte, error) {
+	raw, err := a.ReadRaw(e)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if e.Type == TypeZstdMulti {
+		data, err = a.decompressMulti(e, raw)
+	} else {
+		data, err = Decompress(e.Type, raw, int(e.Size))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("entry %016x: %w", e.Hash, err)
+	}
+	return data, nil
+}
+
+// decompressMulti decodes a TypeZstdMulti entry using the archive's subchunk table.
+func (a *Archive) decompressMulti(e Entry, raw []byte) ([]byte, error) {
+	first, count := int(e.FirstSubchunk), int(e.SubchunkCount)
+	if first+count > len(a.Subchunks) {
+		return nil, fmt.Errorf("subchunks %d-%d not in table of %d", first, first+count, len(a.Subchunks))
+	}
+	return DecompressMulti(raw, int(e.Size), a.Subchunks[first:first+count])
+}
+
+// Decompress decodes raw entry data of the given type. Output larger than
+// the declared size is an error, since entries come from untrusted mods.
+// TypeZstdMulti needs the subchunk table: use DecompressMulti or ReadEntry.
+func Decompress(typ uint8, raw []byte, size int) ([]byte, error) {
+	switch typ {
+	case TypeNone, TypeLink:
+		return raw, nil
+	case TypeGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return readLimited(zr, size)
+	case TypeZstd:
+		return decodeZstd(raw, size)
+	case TypeZstdMulti:
+		return nil, fmt.Errorf("zstd multi entry needs the subchunk table")
+	default:
+		return nil, fmt.Errorf("unknown entry type %d", typ)
+	}
+}
+
+// DecompressMulti decodes a TypeZstdMulti entry split into chunks. A chunk
+// whose compressed size equals its size is stored as is; the rest are zstd.
+func DecompressMulti(raw []byte, size int, chunks []Subchunk) ([]byte, error) {
+	out := make([]byte, 0, sizeHint(size))
+	for i, c := range chunks {
+		n := int(c.CompressedSize)
+		if n > len(raw) {
+			return nil, fmt.Errorf("subchunk %d runs past end of entry", i)
+		}
+		if len(out)+int(c.Size) > size {
+			return nil, fmt.Errorf("subchunks exceed declared size %d", size)
+		}
+		if c.CompressedSize == c.Size {
+			out = append(out, raw[:n]...)
+		} else {
+			data, err := decodeZstd(raw[:n], int(c.Size))
+			if err != nil {
+				return nil, fmt.Errorf("subchunk %d: %w", i, err)
+			}
+			out = append(out, data...)
+		}
+		raw = raw[n:]
+	}
+	return out, nil
+}
+
+func decodeZstd(raw []byte, size int) ([]byte, error) {
+	zr, err := zstd.NewReader(bytes.NewReader(raw), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readLimited(zr, size)
+}
+
+// readLimited reads r to the end, failing once it yields more than size bytes.
+func readLimited(r io.Reader, size int) ([]byte, error) {
+	if size < 0 {
+		size = 0
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, sizeHint(size)))
+	n, err := io.Copy(buf, io.LimitReader(r, int64(size)+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > int64(size) {
+		return nil, fmt.Errorf("decompressed data exceeds declared size %d", size)
+	}
+	return buf.Bytes(), nil
+}
+
+// sizeHint bounds a preallocation taken from an entry's declared size.
+func sizeHint(size int) int {
+	if size < 0 {
+		return 0
+	}
+	if size > maxSizeHint {
+		return maxSizeHint
+	}
+	return size
+}
+
+// Extract writes every entry to destDir. Entries whose hash is found in names
+// are written under their real path; the rest are named by their hex hash.
+func (a *Archive) Extract(destDir string, names map[uint64]string) error {
+	for _, e := range a.Entries {
+		if e.Type == TypeLink {
+			continue
+		}
+		data, err := a.ReadEntry(e)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%016x.bin", e.Hash)
+		if n, ok := names[e.Hash]; ok {
+			name = filepath.FromSlash(n)
+		}
+		fpath := filepath.Join(destDir, name)
+		if !isWithin(destDir, fpath) {
+			return fmt.Errorf("invalid entry path: %s", name)
+		}
+		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fpath, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path stays inside dir (prevents path traversal).
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !filepath.IsAbs(rel) && (len(rel) < 3 || rel[:3] != ".."+string(filepath.Separator))
+}
@@ -0,0 +1,396 @@
+package wad
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildWAD writes an archive with one zstd entry per file, keyed by path hash.
+func buildWAD(t *testing.T, minor uint8, files map[string]string) []byte {
+	t.Helper()
+	w := NewWriter(minor)
+	for path, data := range files {
+		w.AddData(HashPath(path), []byte(data))
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// unsized hides the Size method so Read has to probe.
+type unsized struct{ r io.ReaderAt }
+
+func (u unsized) ReadAt(p []byte, off int64) (int, error) { return u.r.ReadAt(p, off) }
+
+func TestRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"data/characters/ahri/skins/skin1.bin": "skin one",
+		"assets/characters/ahri/ahri.dds":      strings.Repeat("texture", 1000),
+		"empty.bin":                            "",
+	}
+	for _, minor := range []uint8{3, 4} {
+		raw := buildWAD(t, minor, files)
+		a, err := Read(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("3.%d: Read: %v", minor, err)
+		}
+		if a.Major != 3 || a.Minor != minor || len(a.Entries) != len(files) {
+			t.Fatalf("3.%d: got version %d.%d with %d entries", minor, a.Major, a.Minor, len(a.Entries))
+		}
+		hashes := a.Hashes()
+		for i := 1; i < len(hashes); i++ {
+			if hashes[i-1] >= hashes[i] {
+				t.Fatalf("3.%d: hashes not sorted", minor)
+			}
+		}
+		for path, want := range files {
+			e, ok := a.Lookup(HashPath(path))
+			if !ok {
+				t.Fatalf("3.%d: %s missing", minor, path)
+			}
+			got, err := a.ReadEntry(e)
+			if err != nil {
+				t.Fatalf("3.%d: ReadEntry %s: %v", minor, path, err)
+			}
+			if string(got) != want {
+				t.Errorf("3.%d: %s = %q, want %q", minor, path, got, want)
+			}
+		}
+	}
+}
+
+func TestCopyEntries(t *testing.T) {
+	base, err := Read(bytes.NewReader(buildWAD(t, 4, map[string]string{"a.bin": "old a", "b.bin": "b"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Copy everything, then replace one entry
+	w := NewWriter(base.Minor)
+	for _, e := range base.Entries {
+		e.SubchunkCount = 2
+		e.FirstSubchunk = 0x123456
+		w.AddFrom(base, e)
+	}
+	w.AddData(HashPath("a.bin"), []byte("new a"))
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"a.bin", "new a"},
+		{"b.bin", "b"},
+	}
+	for _, tt := range tests {
+		e, _ := a.Lookup(HashPath(tt.path))
+		got, err := a.ReadEntry(e)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+	e, _ := a.Lookup(HashPath("b.bin"))
+	if e.SubchunkCount != 2 || e.FirstSubchunk != 0x123456 {
+		t.Errorf("copied entry subchunks = %d/%#x, want 2/0x123456", e.SubchunkCount, e.FirstSubchunk)
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("gzipped"))
+	zw.Close()
+	zstd := zstdEncoder.EncodeAll([]byte("zstd part"), nil)
+
+	tests := []struct {
+		name string
+		typ  uint8
+		raw  []byte
+		size int
+		want string
+	}{
+		{"none", TypeNone, []byte("plain"), 5, "plain"},
+		{"link", TypeLink, []byte("other/path"), 10, "other/path"},
+		{"gzip", TypeGzip, gz.Bytes(), 7, "gzipped"},
+		{"zstd", TypeZstd, zstd, 9, "zstd part"},
+		{"huge declared size", TypeZstd, zstd, 1 << 31, "zstd part"},
+	}
+	for _, tt := range tests {
+		got, err := Decompress(tt.typ, tt.raw, tt.size)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: got %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	// Output past the declared size is rejected, so a small entry cannot
+	// expand into gigabytes
+	var bomb bytes.Buffer
+	zw = gzip.NewWriter(&bomb)
+	zw.Write(make([]byte, 1<<20))
+	zw.Close()
+	failures := []struct {
+		name string
+		typ  uint8
+		raw  []byte
+		size int
+	}{
+		{"gzip bomb", TypeGzip, bomb.Bytes(), 1000},
+		{"zstd bomb", TypeZstd, zstdEncoder.EncodeAll(make([]byte, 1<<20), nil), 1000},
+		{"zstd one byte over", TypeZstd, zstd, 8},
+		{"zstd multi without table", TypeZstdMulti, zstd, 9},
+		{"unknown type", 9, nil, 0},
+	}
+	for _, tt := range failures {
+		if got, err := Decompress(tt.typ, tt.raw, tt.size); err == nil {
+			t.Errorf("%s: got %d bytes, want error", tt.name, len(got))
+		}
+	}
+}
+
+func TestDecompressMulti(t *testing.T) {
+	// The stored chunk contains a zstd frame magic that must not be decoded
+	stored := []byte("head\x28\xb5\x2f\xfd:")
+	packed := zstdEncoder.EncodeAll([]byte("zstd part"), nil)
+	raw := append(append([]byte(nil), stored...), packed...)
+	chunks := []Subchunk{
+		{CompressedSize: uint32(len(stored)), Size: uint32(len(stored))},
+		{CompressedSize: uint32(len(packed)), Size: 9},
+	}
+	want := string(stored) + "zstd part"
+
+	tests := []struct {
+		name    string
+		raw     []byte
+		size    int
+		chunks  []Subchunk
+		wantErr bool
+	}{
+		{"stored then zstd", raw, len(want), chunks, false},
+		{"past end of entry", raw[:len(raw)-1], len(want), chunks, true},
+		{"over declared size", raw, len(want) - 1, chunks, true},
+		{"chunk larger than declared", raw, len(want), []Subchunk{chunks[0], {CompressedSize: chunks[1].CompressedSize, Size: 4}}, true},
+	}
+	for _, tt := range tests {
+		got, err := DecompressMulti(tt.raw, tt.size, tt.chunks)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: got %q, want error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || string(got) != want {
+			t.Errorf("%s: got %q, %v; want %q", tt.name, got, err, want)
+		}
+	}
+}
+
+func TestSubchunkTable(t *testing.T) {
+	stored := []byte("head\x28\xb5\x2f\xfd:")
+	packed := zstdEncoder.EncodeAll([]byte("zstd part"), nil)
+	toc := make([]byte, 2*subchunkSize)
+	binary.LittleEndian.PutUint32(toc[0:], uint32(len(stored)))
+	binary.LittleEndian.PutUint32(toc[4:], uint32(len(stored)))
+	binary.LittleEndian.PutUint32(toc[16:], uint32(len(packed)))
+	binary.LittleEndian.PutUint32(toc[20:], 9)
+
+	w := NewWriter(4)
+	w.AddData(HashPath("data/final/champions/ahri.wad.subchunktoc"), toc)
+	multi := append(append([]byte(nil), stored...), packed...)
+	w.entries[HashPath("multi.bin")] = writeEntry{
+		Entry: Entry{
+			Hash:           HashPath("multi.bin"),
+			CompressedSize: uint32(len(multi)),
+			Size:           uint32(len(stored) + 9),
+			Type:           TypeZstdMulti,
+			SubchunkCount:  2,
+		},
+		data: multi,
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "Game", "DATA", "FINAL", "Champions", "Ahri.wad.client")
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if len(a.Subchunks) != 2 {
+		t.Fatalf("loaded %d subchunks, want 2", len(a.Subchunks))
+	}
+	e, _ := a.Lookup(HashPath("multi.bin"))
+	if got, err := a.ReadEntry(e); err != nil || string(got) != string(stored)+"zstd part" {
+		t.Errorf("multi entry = %q, %v", got, err)
+	}
+
+	// Read has no path to find the table by
+	b, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.ReadEntry(e); err == nil {
+		t.Error("multi entry without subchunk table: want error")
+	}
+}
+
+func TestSubchunkTOCName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/games/League/Game/DATA/FINAL/Champions/Ahri.wad.client", "data/final/champions/ahri.wad.subchunktoc"},
+		{"/data/lol/Game/DATA/FINAL/UI.wad.client", "data/final/ui.wad.subchunktoc"},
+		{"DATA/FINAL/Maps/Map11.wad.client", "data/final/maps/map11.wad.subchunktoc"},
+		{"/mods/skin_1/WAD/Ahri.wad.client", ""},
+	}
+	for _, tt := range tests {
+		if got := subchunkTOCName(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("subchunkTOCName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	valid := buildWAD(t, 3, map[string]string{"a.bin": "aaaa"})
+
+	withCount := func(count uint32) []byte {
+		b := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint32(b[268:272], count)
+		return b
+	}
+	withOffset := func(offset uint32) []byte {
+		b := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint32(b[headerSize+8:headerSize+12], offset)
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		readErr string // expected Read error, "" if Read succeeds
+	}{
+		{"empty", nil, "failed to read header"},
+		{"truncated header", valid[:100], "failed to read header"},
+		{"bad magic", append([]byte("XX"), valid[2:]...), "not a WAD file"},
+		{"bad version", append([]byte{'R', 'W', 2, 1}, valid[4:]...), "unsupported WAD version"},
+		{"huge count", withCount(0xFFFFFFFF), "runs past end of file"},
+		{"count past end", withCount(2), "runs past end of file"},
+		{"truncated table", valid[:headerSize+10], "runs past end of file"},
+		{"entry past end", withOffset(0xFFFFFF00), ""},
+	}
+	for _, tt := range tests {
+		for _, r := range []io.ReaderAt{bytes.NewReader(tt.data), unsized{bytes.NewReader(tt.data)}} {
+			a, err := Read(r)
+			if tt.readErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.readErr) {
+					t.Errorf("%s (%T): got %v, want error containing %q", tt.name, r, err, tt.readErr)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s (%T): Read: %v", tt.name, r, err)
+				continue
+			}
+			if _, err := a.ReadEntry(a.Entries[0]); err == nil {
+				t.Errorf("%s (%T): ReadEntry: want error", tt.name, r)
+			}
+		}
+	}
+}
+
+func TestOpenAndExtract(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.wad.client")
+	files := map[string]string{"data/a.bin": "a", "data/sub/b.bin": "b"}
+	if err := os.WriteFile(path, buildWAD(t, 3, files), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if a.size <= 0 {
+		t.Errorf("size of opened file = %d, want it from Stat", a.size)
+	}
+
+	out := filepath.Join(dir, "out")
+	names := map[uint64]string{HashPath("data/a.bin"): "data/a.bin"}
+	if err := a.Extract(out, names); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(out, "data", "a.bin")); string(got) != "a" {
+		t.Errorf("named entry = %q, want %q", got, "a")
+	}
+	unnamed := filepath.Join(out, strings.ToLower(hexHash(HashPath("data/sub/b.bin")))+".bin")
+	if got, _ := os.ReadFile(unnamed); string(got) != "b" {
+		t.Errorf("unnamed entry = %q, want %q", got, "b")
+	}
+
+	traversal := map[uint64]string{HashPath("data/a.bin"): "../escape.bin"}
+	if err := a.Extract(out, traversal); err == nil {
+		t.Error("Extract with ../ name: want error")
+	}
+}
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		// XXH64 reference vectors, covering the short and the 32-byte stripe paths
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+		{"data/characters/ahri/skins/skin0.bin", 0x49e643f9c8a74bc7},
+		{strings.Repeat("0123456789abcdef", 5) + "xyz", 0x2cf0ab3f2d7c78c7},
+	}
+	for _, tt := range tests {
+		if got := xxh64([]byte(tt.in)); got != tt.want {
+			t.Errorf("xxh64(%q) = %#x, want %#x", tt.in, got, tt.want)
+		}
+	}
+	if HashPath("DATA\\Characters\\Ahri\\Skins\\Skin0.bin") != 0x49e643f9c8a74bc7 {
+		t.Error("HashPath does not lowercase and normalize slashes")
+	}
+
+	if h, ok := ParseHashName("0123456789abcdef.bin"); !ok || h != 0x0123456789abcdef {
+		t.Errorf("ParseHashName = %#x, %v", h, ok)
+	}
+	if _, ok := ParseHashName("not-a-hash.bin"); ok {
+		t.Error("ParseHashName accepted a non-hash name")
+	}
+}
+
+func hexHash(h uint64) string {
+	const digits = "0123456789abcdef"
+	var b [16]byte
+	for i := 15; i >= 0; i-- {
+		b[i] = digits[h&0xF]
+		h >>= 4
+	}
+	return string(b[:])
+}
@@ -0,0 +1,118 @@
+package wad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Writer assembles a new WAD archive from copied and freshly added entries.
+// Adding an entry with a hash that already exists replaces it.
+type Writer struct {
+	minor   uint8
+	entries map[uint64]writeEntry
+}
+
+type writeEntry struct {
+	Entry
+	src  io.ReaderAt // raw bytes at Entry.Offset, or nil when data is set
+	data []byte
+}
+
+// NewWriter creates a writer for a version 3.minor archive.
+// Use the minor version of the base archive when copying its entries so
+// subchunk indices keep their on-disk width.
+func NewWriter(minor uint8) *Writer {
+	return &Writer{minor: minor, entries: make(map[uint64]writeEntry)}
+}
+
+// Len returns the number of entries that will be written.
+func (w *Writer) Len() int {
+	return len(w.entries)
+}
+
+// AddFrom copies an entry from an existing archive without recompressing it.
+func (w *Writer) AddFrom(a *Archive, e Entry) {
+	w.entries[e.Hash] = writeEntry{Entry: e, src: a.r}
+}
+
+// AddData adds uncompressed data under hash, stored zstd-compressed.
+func (w *Writer) AddData(hash uint64, data []byte) {
+	raw := zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/2))
+	w.entries[hash] = writeEntry{
+		Entry: Entry{
+			Hash:           hash,
+			CompressedSize: uint32(len(raw)),
+			Size:           uint32(len(data)),
+			Type:           TypeZstd,
+		},
+		data: raw,
+	}
+}
+
+var zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+
+// WriteTo writes the archive to out. Entries are sorted by hash as the game expects.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	hashes := make([]uint64, 0, len(w.entries))
+	for h := range w.entries {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	var hdr bytes.Buffer
+	hdr.Write([]byte{magic[0], magic[1], 3, w.minor})
+	hdr.Write(make([]byte, 256)) // signature: unsigned
+	binary.Write(&hdr, binary.LittleEndian, uint64(0))
+	binary.Write(&hdr, binary.LittleEndian, uint32(len(hashes)))
+
+	offset := uint32(headerSize + len(hashes)*entrySize)
+	for _, h := range hashes {
+		e := w.entries[h]
+		var b [entrySize]byte
+		binary.LittleEndian.PutUint64(b[0:8], e.Hash)
+		binary.LittleEndian.PutUint32(b[8:12], offset)
+		binary.LittleEndian.PutUint32(b[12:16], e.CompressedSize)
+		binary.LittleEndian.PutUint32(b[16:20], e.Size)
+		b[20] = e.Type&0x0F | e.SubchunkCount<<4
+		if w.minor >= 4 {
+			b[21] = byte(e.FirstSubchunk)
+			b[22] = byte(e.FirstSubchunk >> 8)
+			b[23] = byte(e.FirstSubchunk >> 16)
+		} else {
+			if e.Duplicate {
+				b[21] = 1
+			}
+			binary.LittleEndian.PutUint16(b[22:24], uint16(e.FirstSubchunk))
+		}
+		binary.LittleEndian.PutUint64(b[24:32], e.Checksum)
+		hdr.Write(b[:])
+		offset += e.CompressedSize
+	}
+
+	n, err := out.Write(hdr.Bytes())
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	for _, h := range hashes {
+		e := w.entries[h]
+		var m int64
+		if e.src != nil {
+			m, err = io.Copy(out, io.NewSectionReader(e.src, int64(e.Offset), int64(e.CompressedSize)))
+		} else {
+			var k int
+			k, err = out.Write(e.data)
+			m = int64(k)
+		}
+		written += m
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
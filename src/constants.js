@@ -16,8 +16,6 @@ export const CONNECTION_BANNER_ID = 'ame-connection-banner';
 export const IN_GAME_PHASES = ['InProgress', 'Reconnect'];
 export const IN_GAME_CONTAINER_ID = 'ame-ingame-container';
 export const IN_GAME_POLL_MS = 500;
-export const AUTO_ACCEPT_DELAY_MS = 2000;
-export const AUTO_SELECT_DELAY_MS = 1500;
 export const AUTO_SELECT_ROLES = [
   { key: 'top', labelKey: 'roles.top', icon: '/fe/lol-parties/icon-position-top.png' },
   { key: 'jungle', labelKey: 'roles.jungle', icon: '/fe/lol-parties/icon-position-jungle.png' },
@@ -8,9 +8,7 @@ import { ensureChromaButton, closeChromaPanel } from './chroma';
 import { resetAutoApply, forceApplyIfNeeded, fetchAndLogGameflow, fetchAndLogTimer, checkAutoApply, lockRetrigger, setChampSelectActive, processClickBack } from './autoApply';
 import { ensureInGameUI, removeInGameUI, updateInGameStatus } from './inGame';
 import { initSettings } from './settings';
-import { handleReadyCheck, cancelPendingAccept, loadAutoAcceptSetting } from './autoAcceptMatch';
 import { ensureBenchSwap, cleanupBenchSwap, loadBenchSwapSetting } from './benchSwap';
-import { loadAutoSelectSetting, handleChampSelectSession, resetAutoSelect } from './autoSelect';
 import { setLastChampionId, setAppliedSkinName, setOwnedSkinIds, resetOwnedSkins, getOwnedSkinChampionId, isOwnedSkin, getPendingForceDefault, setPendingForceDefault } from './state';
 import { readCurrentSkin, findSkinByName } from './skin';
 import { joinRoom, leaveRoom, loadRoomPartySetting, flushPendingRetrigger } from './roomParty';
@@ -175,16 +173,13 @@ export async function init(context) {
   wsConnect();
   initConnectionStatus();
   initSettings();
-  loadAutoAcceptSetting();
   loadBenchSwapSetting();
-  loadAutoSelectSetting();
   loadRoomPartySetting();
   initChatStatus(context);
 
   context.socket.observe('/lol-champ-select/v1/session', (event) => {
     if (event.eventType === 'Delete' || !inChampSelect) return;
     const session = event.data;
-    handleChampSelectSession(session);
     const me = session.myTeam?.find(p => p.cellId === session.localPlayerCellId);
     const champId = me?.championId || null;
     if (champId && champId !== lastChampionId) {
@@ -197,12 +192,6 @@ export async function init(context) {
   });
 
   function handlePhase(phase) {
-    if (phase === 'ReadyCheck') {
-      handleReadyCheck();
-    } else {
-      cancelPendingAccept();
-    }
-
     const wasInChampSelect = inChampSelect;
     inChampSelect = CHAMP_SELECT_PHASES.includes(phase);
 
@@ -223,7 +212,6 @@ export async function init(context) {
       injectionTriggered = false;
       setAppliedSkinName(null);
       resetAutoApply();
-      resetAutoSelect();
       resetRandomSkin();
       setChampSelectActive(true);
       stopSwiftplayObserving();
@@ -241,7 +229,6 @@ export async function init(context) {
         logger.log('forceApplyIfNeeded settled');
         resetAutoApply(true);
       });
-      resetAutoSelect();
       injectionTriggered = true;
     }
 
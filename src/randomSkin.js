@@ -1,11 +1,11 @@
-import { getChampionSkins, getChampionName } from './api';
+import { getChampionSkins } from './api';
 import { isDefaultSkin, getSkinKeyFromItem } from './skin';
-import { getRandomSkinMode } from './websocket';
+import { getRandomSkinMode, requestRandomSkinPick, isConnected } from './websocket';
 import { createLogger } from './logger';
 
 const logger = createLogger('random');
 
-const RANKINGS_API = 'https://api.lolskinrankings.com/api/champions';
+const BACKEND_PICK_TIMEOUT_MS = 5000;
 
 let lastRandomChampionId = null;
 let chosenSkinNum = null; // persisted pick so retries click the same skin
@@ -19,16 +19,17 @@ export function resetRandomSkin() {
   picking = false;
 }
 
-async function fetchSkinRankings(championName) {
-  try {
-    const res = await fetch(`${RANKINGS_API}/${encodeURIComponent(championName)}/skins`);
-    if (!res.ok) return null;
-    const json = await res.json();
-    if (!json.success || !Array.isArray(json.data)) return null;
-    return json.data;
-  } catch {
-    return null;
-  }
+// Ask ame for a pick based on local usage history and favorites.
+// Resolves to the picked skin, or null if ame has no pick.
+function requestBackendPick(championId) {
+  if (!isConnected()) return Promise.resolve(null);
+  return new Promise((resolve) => {
+    const timer = setTimeout(() => resolve(null), BACKEND_PICK_TIMEOUT_MS);
+    requestRandomSkinPick(championId, (skin) => {
+      clearTimeout(timer);
+      resolve(skin);
+    });
+  });
 }
 
 function clickCarouselSkin(skinNum) {
@@ -72,7 +73,7 @@ export async function triggerRandomSkin(championId) {
     return;
   }
 
-  // Guard against concurrent async picks (top3 fetches API)
+  // Guard against concurrent async picks (history modes ask the backend)
   if (picking) return;
   picking = true;
 
@@ -85,24 +86,13 @@ export async function triggerRandomSkin(championId) {
 
     let pool = nonBaseSkins;
 
-    if (mode === 'top3') {
-      const champName = await getChampionName(championId);
-      if (champName) {
-        const rankings = await fetchSkinRankings(champName);
-        if (rankings && rankings.length > 0) {
-          const sorted = [...rankings].sort((a, b) => b.vote_count - a.vote_count);
-          const top3Ids = new Set(sorted.slice(0, 3).map(r => parseInt(r.skin_id, 10)));
-          const matched = nonBaseSkins.filter(s => top3Ids.has(s.id));
-          if (matched.length > 0) {
-            pool = matched;
-          } else {
-            logger.log('no top 3 skins matched loaded skins, falling back to all');
-          }
-        } else {
-          logger.log('rankings API returned no data, falling back to all');
-        }
+    if (mode !== 'all') {
+      const pick = await requestBackendPick(championId);
+      const matched = pick ? nonBaseSkins.filter(s => s.id === Number(pick.skinId)) : [];
+      if (matched.length > 0) {
+        pool = matched;
       } else {
-        logger.log('could not resolve champion name for rankings, falling back to all');
+        logger.log('backend returned no matching pick, falling back to all');
       }
     }
 
@@ -14,6 +14,8 @@ let enabled = false;
 let joined = false;
 let joining = false;
 let currentTeammates = [];
+let currentStatus = []; // per teammate: included in the overlay or why not
+let connectionState = null; // room server connection state from the backend
 let unsubUpdate = null;
 let retriggerDebounceTimer = null;
 
@@ -58,17 +60,28 @@ export async function joinRoom(existingSession) {
     wsSend({
       type: 'roomPartyJoin',
       roomKey,
+      roomSecret: session.chatDetails?.multiUserChatPassword,
       puuid: summoner.puuid,
       teamPuuids,
     });
 
     joined = true;
 
-    unsubUpdate = onRoomPartyUpdate((teammates) => {
-      const oldKey = teammateSkinKey(currentTeammates);
-      const newKey = teammateSkinKey(teammates);
+    unsubUpdate = onRoomPartyUpdate((teammates, connection, status) => {
+      if (connection && connection.state !== connectionState) {
+        connectionState = connection.state;
+        logger.log(` roomPartyUpdate: connection ${connection.state}${connection.lastError ? ` (${connection.lastError})` : ''}`);
+      }
+      const oldKey = teammateSkinKey(currentStatus.filter(s => s.included));
+      const newKey = teammateSkinKey(status.filter(s => s.included));
       logger.log(` roomPartyUpdate: ${teammates.length} teammates, skinKey: "${oldKey}" -> "${newKey}"`);
+      for (const s of status) {
+        if (!s.included && s.reason !== 'no_skin') {
+          logger.log(` roomPartyUpdate: excluded ${s.skinInfo?.skinName || s.puuid} (${s.reason}${s.detail ? `: ${s.detail}` : ''})`);
+        }
+      }
       currentTeammates = teammates;
+      currentStatus = status;
       renderTeammateIndicators();
       if (newKey !== oldKey) {
         // Only retrigger if there are new or changed skins — not when teammates
@@ -123,6 +136,8 @@ export function leaveRoom() {
   wsSend({ type: 'roomPartyLeave' });
   joined = false;
   currentTeammates = [];
+  currentStatus = [];
+  connectionState = null;
   if (unsubUpdate) {
     unsubUpdate();
     unsubUpdate = null;
@@ -155,6 +170,8 @@ async function renderTeammateIndicators() {
     const label = tm.skinInfo.chromaName
       ? `${tm.skinInfo.skinName} (${tm.skinInfo.chromaName})`
       : tm.skinInfo.skinName;
+    const status = currentStatus.find(s => s.puuid === tm.puuid);
+    const excluded = status && !status.included;
 
     const targetIndex = teamOrdered.findIndex(p => p.puuid === tm.puuid);
     if (targetIndex < 0 || targetIndex >= slots.length) continue;
@@ -163,8 +180,10 @@ async function renderTeammateIndicators() {
     slot.style.position = 'relative';
 
     const badge = el('div', {
-      class: ROOM_PARTY_INDICATOR_CLASS,
-      title: t('room_party.tooltip', { label }),
+      class: excluded ? `${ROOM_PARTY_INDICATOR_CLASS} excluded` : ROOM_PARTY_INDICATOR_CLASS,
+      title: excluded
+        ? t('room_party.tooltip_excluded', { label, reason: t(`room_party.reasons.${status.reason}`) })
+        : t('room_party.tooltip', { label }),
     }, el('span', null, label));
 
     slot.appendChild(badge);
@@ -398,6 +398,11 @@ export function injectStyles() {
       overflow: hidden;
       text-overflow: ellipsis;
     }
+    .${ROOM_PARTY_INDICATOR_CLASS}.excluded {
+      border-color: #5b5a56;
+      color: #7e7e7e;
+      text-decoration: line-through;
+    }
     .ame-settings-description {
       margin-top: 8px;
       font-family: var(--font-body);
@@ -24,6 +24,9 @@ let gamePathCallback = null;
 // One-shot callback for logs response
 let logsCallback = null;
 
+// One-shot callback for randomSkinPick response
+let randomSkinPickCallback = null;
+
 // Settings: local cache + pub/sub listeners keyed by setting name
 const settingsCache = {};
 const settingsListeners = {};
@@ -203,7 +206,7 @@ export function wsConnect() {
           overlayActive = !!msg.overlayActive;
           logger.log('State from server:', overlayActive ? 'active' : 'inactive', lastApplyPayload);
         } else if (msg.type === 'roomPartyUpdate') {
-          roomPartyListeners.forEach(cb => cb(msg.teammates || []));
+          roomPartyListeners.forEach(cb => cb(msg.teammates || [], msg.connection || null, msg.teammateStatus || []));
         } else if (msg.type === 'gamePath') {
           if (gamePathCallback) {
             gamePathCallback(msg.path || '');
@@ -217,6 +220,13 @@ export function wsConnect() {
             });
             logsCallback = null;
           }
+        } else if (msg.type === 'skinQuarantined') {
+          toastError(msg.message);
+        } else if (msg.type === 'randomSkinPick') {
+          if (randomSkinPickCallback) {
+            randomSkinPickCallback(msg.skin || null);
+            randomSkinPickCallback = null;
+          }
         } else if (msg.type === 'settings') {
           // Batch settings snapshot — update all registered keys
           for (const key of Object.keys(settingsListeners)) {
@@ -287,6 +297,10 @@ export function requestLogs(cb) {
   logsCallback = cb;
   wsSend({ type: 'getLogs' });
 }
+export function requestRandomSkinPick(championId, cb) {
+  randomSkinPickCallback = cb;
+  wsSend({ type: 'pickRandomSkin', championId });
+}
 export function onConnection(cb) {
   connectionListeners.push(cb);
   cb(wsConnected);